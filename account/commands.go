@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"memrizr/config"
+	"memrizr/logging"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// errUsage 子命令参数错误，打印用法
+var errUsage = errors.New("invalid arguments")
+
+// cliActor 命令行操作在审计日志和用户状态中记录的操作人，全零 UID 与管理员区分
+var cliActor = uuid.Nil
+
+// command account 二进制的子命令，不带参数运行时启动服务
+type command struct {
+	usage []string
+	// standalone 为 true 时不加载配置，cfg 为 nil
+	standalone bool
+	// run 的 args 不包含子命令名，输出写到 stdout，日志写到 stderr
+	run func(ctx context.Context, cfg *config.Config, logger *logrus.Logger, args []string) error
+}
+
+var commands = map[string]command{
+	"migrate": {
+		usage: []string{"migrate up [N] | down [N] | status | force VERSION"},
+		run:   runMigrate,
+	},
+	"user": {
+		usage: []string{
+			"user create [--name NAME] [--admin] [--password-stdin] EMAIL",
+			"user find LOGIN",
+			"user set-password [--password-stdin] LOGIN",
+			"user disable [--reason REASON] LOGIN",
+		},
+		run: runUser,
+	},
+	"sessions": {
+		usage: []string{"sessions revoke LOGIN"},
+		run:   runSessions,
+	},
+	"keys": {
+		usage:      []string{"keys generate [--env ENV] [--dir DIR] [--bits BITS] [--force]"},
+		standalone: true,
+		run:        runKeys,
+	},
+	"token": {
+		usage: []string{"token inspect TOKEN|-"},
+		run:   runToken,
+	},
+}
+
+// runCommand 执行子命令，返回进程的退出码
+func runCommand(args []string) int {
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage()
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		printUsage()
+		return 2
+	}
+
+	// 所有命令都有下一级子命令，不加载配置直接打印用法
+	if len(args) == 1 {
+		printCommandUsage(cmd)
+		return 2
+	}
+
+	var cfg *config.Config
+	logger := logging.Default()
+	if !cmd.standalone {
+		var err error
+		if cfg, err = config.Load(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		if logger, err = logging.New(cfg.Log); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		logging.SetDefault(logger)
+	}
+
+	// Ctrl-C 取消正在执行的操作
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, cfg, logger, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			if err != errUsage {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			printCommandUsage(cmd)
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+
+	return 0
+}
+
+// printUsage 列出所有子命令
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: account [command]")
+	fmt.Fprintln(os.Stderr, "without a command the server is started, commands:")
+	for _, name := range names {
+		for _, u := range commands[name].usage {
+			fmt.Fprintf(os.Stderr, "  account %s\n", u)
+		}
+	}
+}
+
+// printCommandUsage 一个子命令的用法
+func printCommandUsage(cmd command) {
+	for i, u := range cmd.usage {
+		prefix := "usage:"
+		if i > 0 {
+			prefix = "      "
+		}
+		fmt.Fprintf(os.Stderr, "%s account %s\n", prefix, u)
+	}
+}
+
+// withApp 按启动服务时相同的方式连接数据源并注入服务，不启动后台任务
+// 命令追加的领域事件由运行中的服务发布
+func withApp(cfg *config.Config, logger *logrus.Logger, fn func(a *app) error) error {
+	ds, err := initDS(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer ds.Close()
+
+	a, err := inject(ds, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	return fn(a)
+}
+
+// newFlagSet 解析错误由 flag 打印，用法由 runCommand 打印
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {}
+	return fs
+}
+
+// parseFlags 解析参数并检查位置参数的数量
+func parseFlags(fs *flag.FlagSet, args []string, nArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != nArgs {
+		return errUsage
+	}
+	return nil
+}
+
+// countArg 可选的数量参数，未提供时为 def
+func countArg(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	if len(args) > 1 {
+		return 0, errUsage
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: N must be a positive integer", errUsage)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,312 @@
+// Package config 加载并校验服务配置
+//
+// 配置按以下顺序合并，后者覆盖前者：
+//  1. 字段的 default 标签
+//  2. CONFIG_FILE 指定的 YAML 或 TOML 文件，key 为 "postgres.host" 形式的路径
+//  3. 环境变量，空值视为未设置
+//
+// 标记为 secret 的字段也可以通过 <ENV>_FILE 环境变量从文件读取，例如 REFRESH_SECRET_FILE
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storage、邮件、事件后端
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+
+	MailSMTP = "smtp"
+	MailLog  = "log"
+
+	BrokerRedis = "redis"
+	BrokerLog   = "log"
+
+	LogJSON = "json"
+	LogText = "text"
+
+	ReporterLog    = "log"
+	ReporterSentry = "sentry"
+
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+// Config 服务配置
+type Config struct {
+	// Mode gin 运行模式，debug 模式下启动时打印生效的配置
+	Mode string `env:"GIN_MODE" key:"mode" default:"debug" oneof:"debug release test"`
+	// BaseURL 服务对外的路径前缀，用于生成媒体和头像地址
+	BaseURL string `env:"ACCOUNT_API_URL" key:"base_url"`
+	// WatchInterval 检查配置文件和密钥文件是否修改的间隔，0 表示只在 SIGHUP 时重新加载
+	WatchInterval time.Duration `env:"CONFIG_WATCH_INTERVAL" key:"watch_interval" default:"0"`
+
+	Log      LogConfig      `key:"log"`
+	HTTP     HTTPConfig     `key:"http"`
+	Postgres PostgresConfig `key:"postgres"`
+	Redis    RedisConfig    `key:"redis"`
+	Tokens   TokenConfig    `key:"tokens"`
+	Storage  StorageConfig  `key:"storage"`
+	S3       S3Config       `key:"s3"`
+	Events   EventConfig    `key:"events"`
+	Webhooks WebhookConfig  `key:"webhooks"`
+	Mail     MailConfig     `key:"mail"`
+	Users    UserConfig     `key:"users"`
+	Tracing  TracingConfig  `key:"tracing"`
+	Errors   ErrorsConfig   `key:"errors"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	// Level 最低记录级别，可以在重新加载配置时修改
+	Level  string `env:"LOG_LEVEL" key:"level" default:"info" oneof:"debug info warn error"`
+	Format string `env:"LOG_FORMAT" key:"format" default:"json" oneof:"json text"`
+}
+
+// HTTPConfig HTTP 服务配置
+type HTTPConfig struct {
+	Addr           string        `env:"HTTP_ADDR" key:"addr" default:":8080"`
+	HandlerTimeout time.Duration `env:"HANDLER_TIMEOUT" key:"handler_timeout" default:"5s" min:"1"`
+	MaxBodyBytes   int64         `env:"MAX_BODY_BYTES" key:"max_body_bytes" default:"4194304" min:"1"`
+	// ReadyTimeout 就绪检查中所有依赖检查的超时时间
+	ReadyTimeout time.Duration `env:"READY_TIMEOUT" key:"ready_timeout" default:"2s" min:"1"`
+	// MetricsAddr 管理端口地址，暴露 /metrics，为空时不启动
+	MetricsAddr string `env:"METRICS_ADDR" key:"metrics_addr" default:":9090"`
+	// ShutdownDrainDelay 收到终止信号后就绪检查先失败，等待该时间让流量撤离后再关闭服务
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" key:"shutdown_drain_delay" default:"5s"`
+	// TrustedProxies 逗号分隔的反向代理 IP 或 CIDR，只有来自这些地址的 X-Forwarded-For 用于获取客户端 IP
+	// 为空时不信任任何代理，客户端 IP 为连接的远端地址
+	TrustedProxies string `env:"TRUSTED_PROXIES" key:"trusted_proxies"`
+}
+
+// TrustedProxyList 拆分 TrustedProxies，未配置时为空
+func (c HTTPConfig) TrustedProxyList() []string {
+	var proxies []string
+	for _, p := range strings.Split(c.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// PostgresConfig 数据库连接配置
+type PostgresConfig struct {
+	Host     string `env:"PG_HOST" key:"host" default:"localhost"`
+	Port     int    `env:"PG_PORT" key:"port" default:"5432" min:"1"`
+	User     string `env:"PG_USER" key:"user" default:"postgres"`
+	Password string `env:"PG_PASSWORD" key:"password" secret:"true"`
+	DB       string `env:"PG_DB" key:"db" default:"postgres"`
+	SSLMode  string `env:"PG_SSL" key:"ssl" default:"disable" oneof:"disable allow prefer require verify-ca verify-full"`
+	// MigrateOnStart 启动时执行未执行的迁移，多个副本通过 advisory lock 依次执行
+	MigrateOnStart bool `env:"MIGRATE_ON_START" key:"migrate_on_start"`
+}
+
+// ConnString lib/pq 连接字符串
+func (c PostgresConfig) ConnString() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.DB, c.SSLMode)
+}
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	Host string `env:"REDIS_HOST" key:"host" default:"localhost"`
+	Port int    `env:"REDIS_PORT" key:"port" default:"6379" min:"1"`
+}
+
+// Addr host:port
+func (c RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// TokenConfig token 签名配置
+type TokenConfig struct {
+	PrivKeyFile   string        `env:"PRIV_KEY_FILE" key:"priv_key_file" required:"true"`
+	PubKeyFile    string        `env:"PUB_KEY_FILE" key:"pub_key_file" required:"true"`
+	RefreshSecret string        `env:"REFRESH_SECRET" key:"refresh_secret" required:"true" secret:"true"`
+	IDTokenExp    time.Duration `env:"ID_TOKEN_EXP" key:"id_token_exp" default:"15m" min:"1"`
+	RefreshExp    time.Duration `env:"REFRESH_TOKEN_EXP" key:"refresh_token_exp" default:"72h" min:"1"`
+}
+
+// StorageConfig 对象存储配置
+type StorageConfig struct {
+	Backend string `env:"STORAGE_BACKEND" key:"backend" default:"local" oneof:"local s3"`
+	Dir     string `env:"STORAGE_DIR" key:"dir" default:"./uploads"`
+	// PublicURL 公开对象的访问地址，默认为 BaseURL/media
+	PublicURL string `env:"STORAGE_PUBLIC_URL" key:"public_url"`
+	// MediaURLSecret 私有对象签名地址的密钥
+	MediaURLSecret string `env:"MEDIA_URL_SECRET" key:"media_url_secret" required:"true" secret:"true"`
+}
+
+// S3Config S3 兼容对象存储配置，仅在 STORAGE_BACKEND=s3 时使用
+type S3Config struct {
+	Endpoint  string `env:"S3_ENDPOINT" key:"endpoint"`
+	Region    string `env:"S3_REGION" key:"region" default:"us-east-1"`
+	Bucket    string `env:"S3_BUCKET" key:"bucket"`
+	Prefix    string `env:"S3_PREFIX" key:"prefix"`
+	AccessKey string `env:"S3_ACCESS_KEY" key:"access_key" secret:"true"`
+	SecretKey string `env:"S3_SECRET_KEY" key:"secret_key" secret:"true"`
+	UseSSL    bool   `env:"S3_USE_SSL" key:"use_ssl"`
+}
+
+// EventConfig 领域事件发布配置
+type EventConfig struct {
+	Broker       string        `env:"EVENT_BROKER" key:"broker" default:"redis" oneof:"redis log"`
+	Stream       string        `env:"EVENT_STREAM" key:"stream" default:"account:events"`
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" key:"poll_interval" default:"1s" min:"1"`
+	// MaxAttempts 事件发布失败的最大次数，之后标记为 dead 不再重试
+	MaxAttempts int `env:"OUTBOX_MAX_ATTEMPTS" key:"max_attempts" default:"10" min:"1"`
+}
+
+// WebhookConfig webhook 投递配置
+type WebhookConfig struct {
+	Timeout     time.Duration `env:"WEBHOOK_TIMEOUT" key:"timeout" default:"10s" min:"1"`
+	MaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS" key:"max_attempts" default:"8" min:"1"`
+	// AllowPrivateTargets 仅用于本地开发，允许 webhook 指向内网地址
+	AllowPrivateTargets bool `env:"WEBHOOK_ALLOW_PRIVATE_TARGETS" key:"allow_private_targets"`
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Backend      string `env:"MAIL_BACKEND" key:"backend" default:"log" oneof:"smtp log"`
+	From         string `env:"MAIL_FROM" key:"from" default:"no-reply@memrizr.local"`
+	SMTPAddr     string `env:"SMTP_ADDR" key:"smtp_addr"`
+	SMTPUsername string `env:"SMTP_USERNAME" key:"smtp_username"`
+	SMTPPassword string `env:"SMTP_PASSWORD" key:"smtp_password" secret:"true"`
+}
+
+// UserConfig 用户资料相关配置
+type UserConfig struct {
+	EmailConfirmURL    string        `env:"EMAIL_CONFIRM_URL" key:"email_confirm_url" required:"true"`
+	EmailChangeTTL     time.Duration `env:"EMAIL_CHANGE_TTL" key:"email_change_ttl" default:"24h" min:"1"`
+	EmailProviderRules bool          `env:"EMAIL_PROVIDER_RULES" key:"email_provider_rules"`
+	HandleRedirectTTL  time.Duration `env:"HANDLE_REDIRECT_TTL" key:"handle_redirect_ttl" default:"720h" min:"1"`
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	// Exporter span 导出方式，none 时仍然传递请求中的 trace context
+	Exporter    string `env:"TRACING_EXPORTER" key:"exporter" default:"none" oneof:"none stdout otlp"`
+	ServiceName string `env:"TRACING_SERVICE_NAME" key:"service_name" default:"account"`
+	// OTLPEndpoint OTLP/HTTP collector 地址，host:port
+	OTLPEndpoint string `env:"OTLP_ENDPOINT" key:"otlp_endpoint" default:"localhost:4318"`
+	OTLPInsecure bool   `env:"OTLP_INSECURE" key:"otlp_insecure"`
+}
+
+// ErrorsConfig panic 和内部错误的上报配置
+type ErrorsConfig struct {
+	Reporter string `env:"ERROR_REPORTER" key:"reporter" default:"log" oneof:"log sentry"`
+	// SentryDSN Sentry 兼容服务的 DSN，例如 http://key@localhost:9000/1
+	SentryDSN         string        `env:"SENTRY_DSN" key:"sentry_dsn" secret:"true"`
+	SentryEnvironment string        `env:"SENTRY_ENVIRONMENT" key:"sentry_environment" default:"development"`
+	SentryTimeout     time.Duration `env:"SENTRY_TIMEOUT" key:"sentry_timeout" default:"5s" min:"1"`
+}
+
+// ValidationError 配置错误，包含所有发现的问题
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+// File 配置文件路径，未设置时为空
+func File() string {
+	return os.Getenv("CONFIG_FILE")
+}
+
+// Load 从 CONFIG_FILE 和环境变量加载配置
+// 返回的错误为 *ValidationError 时包含所有问题
+func Load() (*Config, error) {
+	return load(File(), os.LookupEnv)
+}
+
+// load 便于测试替换环境变量
+func load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	c := &Config{}
+	l := &loader{lookupEnv: lookupEnv}
+
+	fields := collectFields(c)
+	l.applyDefaults(fields)
+	if path != "" {
+		l.applyFile(fields, path)
+	}
+	l.applyEnv(fields)
+	l.checkFields(fields)
+	c.validate(l)
+
+	if len(l.problems) > 0 {
+		return nil, &ValidationError{Problems: l.problems}
+	}
+
+	return c, nil
+}
+
+// validate 字段之间的校验
+func (c *Config) validate(l *loader) {
+	for _, p := range c.HTTP.TrustedProxyList() {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			l.addf("TRUSTED_PROXIES must contain IP addresses or CIDRs: %q", p)
+		}
+	}
+
+	switch c.Storage.Backend {
+	case StorageLocal:
+		if c.Storage.Dir == "" {
+			l.addf("STORAGE_DIR is required when STORAGE_BACKEND=local")
+		}
+	case StorageS3:
+		if c.S3.Endpoint == "" {
+			l.addf("S3_ENDPOINT is required when STORAGE_BACKEND=s3")
+		}
+		if c.S3.Bucket == "" {
+			l.addf("S3_BUCKET is required when STORAGE_BACKEND=s3")
+		}
+	}
+
+	if c.Mail.Backend == MailSMTP {
+		if c.Mail.SMTPAddr == "" {
+			l.addf("SMTP_ADDR is required when MAIL_BACKEND=smtp")
+		}
+		if c.Mail.From == "" {
+			l.addf("MAIL_FROM is required when MAIL_BACKEND=smtp")
+		}
+	}
+
+	if c.Users.EmailConfirmURL != "" {
+		if u, err := url.Parse(c.Users.EmailConfirmURL); err != nil || !u.IsAbs() {
+			l.addf("EMAIL_CONFIRM_URL must be an absolute URL: %q", c.Users.EmailConfirmURL)
+		}
+	}
+
+	if c.Tracing.Exporter == TracingOTLP && c.Tracing.OTLPEndpoint == "" {
+		l.addf("OTLP_ENDPOINT is required when TRACING_EXPORTER=otlp")
+	}
+
+	if c.Errors.Reporter == ReporterSentry {
+		if u, err := url.Parse(c.Errors.SentryDSN); err != nil || !u.IsAbs() || u.User == nil {
+			l.addf("SENTRY_DSN must be a DSN like http://key@host/1 when ERROR_REPORTER=sentry")
+		}
+	}
+
+	keyFiles := []struct{ env, path string }{
+		{"PRIV_KEY_FILE", c.Tokens.PrivKeyFile},
+		{"PUB_KEY_FILE", c.Tokens.PubKeyFile},
+	}
+	for _, f := range keyFiles {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			l.addf("%s: %v", f.env, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env 测试用的环境变量
+type env map[string]string
+
+func (e env) lookup(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+// validEnv 满足所有必填项的最小环境变量
+func validEnv(t *testing.T) env {
+	dir := t.TempDir()
+	return env{
+		"PRIV_KEY_FILE":     writeFile(t, dir, "priv.pem", "priv"),
+		"PUB_KEY_FILE":      writeFile(t, dir, "pub.pem", "pub"),
+		"REFRESH_SECRET":    "refreshsecret",
+		"MEDIA_URL_SECRET":  "mediasecret",
+		"EMAIL_CONFIRM_URL": "http://localhost:3000/confirm-email",
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		c, err := load("", validEnv(t).lookup)
+
+		require.NoError(t, err)
+		assert.Equal(t, ":8080", c.HTTP.Addr)
+		assert.Equal(t, 5*time.Second, c.HTTP.HandlerTimeout)
+		assert.Equal(t, 5432, c.Postgres.Port)
+		assert.Equal(t, StorageLocal, c.Storage.Backend)
+		assert.Equal(t, 15*time.Minute, c.Tokens.IDTokenExp)
+		assert.Equal(t, TracingNone, c.Tracing.Exporter)
+	})
+
+	t.Run("Plain numbers are seconds", func(t *testing.T) {
+		e := validEnv(t)
+		e["ID_TOKEN_EXP"] = "900"
+		e["HANDLER_TIMEOUT"] = " 2m "
+		e["PG_USER"] = "postgres "
+
+		c, err := load("", e.lookup)
+
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, c.Tokens.IDTokenExp)
+		assert.Equal(t, 2*time.Minute, c.HTTP.HandlerTimeout)
+		assert.Equal(t, "postgres", c.Postgres.User)
+	})
+
+	t.Run("Reports every problem", func(t *testing.T) {
+		e := validEnv(t)
+		delete(e, "REFRESH_SECRET")
+		e["MEDIA_URL_SECRET"] = ""
+		e["HANDLER_TIMEOUT"] = "soon"
+		e["STORAGE_BACKEND"] = "ftp"
+		e["MAIL_BACKEND"] = "smtp"
+		e["WEBHOOK_MAX_ATTEMPTS"] = "0"
+
+		c, err := load("", e.lookup)
+
+		assert.Nil(t, c)
+		verr, ok := err.(*ValidationError)
+		require.True(t, ok)
+		assert.Len(t, verr.Problems, 6)
+		assert.Contains(t, err.Error(), "REFRESH_SECRET is required")
+		assert.Contains(t, err.Error(), "MEDIA_URL_SECRET is required")
+		assert.Contains(t, err.Error(), `HANDLER_TIMEOUT: invalid duration "soon"`)
+		assert.Contains(t, err.Error(), "STORAGE_BACKEND must be one of local, s3")
+		assert.Contains(t, err.Error(), "SMTP_ADDR is required when MAIL_BACKEND=smtp")
+		assert.Contains(t, err.Error(), "WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	})
+
+	t.Run("Sentry reporter requires DSN", func(t *testing.T) {
+		e := validEnv(t)
+		e["ERROR_REPORTER"] = "sentry"
+
+		_, err := load("", e.lookup)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SENTRY_DSN must be a DSN")
+
+		e["SENTRY_DSN"] = "http://public@localhost:9000/1"
+		c, err := load("", e.lookup)
+		require.NoError(t, err)
+		assert.Equal(t, ReporterSentry, c.Errors.Reporter)
+	})
+
+	t.Run("Trusted proxies", func(t *testing.T) {
+		e := validEnv(t)
+
+		c, err := load("", e.lookup)
+		require.NoError(t, err)
+		assert.Empty(t, c.HTTP.TrustedProxyList())
+
+		e["TRUSTED_PROXIES"] = "10.0.0.0/8, 192.168.1.1,"
+		c, err = load("", e.lookup)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, c.HTTP.TrustedProxyList())
+
+		e["TRUSTED_PROXIES"] = "proxy.internal"
+		_, err = load("", e.lookup)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TRUSTED_PROXIES must contain IP addresses or CIDRs")
+	})
+
+	t.Run("YAML file overridden by env", func(t *testing.T) {
+		e := validEnv(t)
+		e["PG_HOST"] = "db.internal"
+		path := writeFile(t, t.TempDir(), "account.yaml", `
+postgres:
+  host: localhost
+  port: 6543
+http:
+  handler_timeout: 30s
+storage:
+  backend: s3
+s3:
+  endpoint: minio:9000
+  bucket: memrizr
+`)
+
+		c, err := load(path, e.lookup)
+
+		require.NoError(t, err)
+		assert.Equal(t, "db.internal", c.Postgres.Host)
+		assert.Equal(t, 6543, c.Postgres.Port)
+		assert.Equal(t, 30*time.Second, c.HTTP.HandlerTimeout)
+		assert.Equal(t, StorageS3, c.Storage.Backend)
+		assert.Equal(t, "memrizr", c.S3.Bucket)
+	})
+
+	t.Run("YAML null uses default", func(t *testing.T) {
+		path := writeFile(t, t.TempDir(), "account.yaml", `
+postgres:
+  port: ~
+http:
+  handler_timeout:
+`)
+
+		c, err := load(path, validEnv(t).lookup)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5432, c.Postgres.Port)
+		assert.Equal(t, 5*time.Second, c.HTTP.HandlerTimeout)
+	})
+
+	t.Run("TOML file with unknown key", func(t *testing.T) {
+		path := writeFile(t, t.TempDir(), "account.toml", `
+[redis]
+host = "cache"
+hots = "typo"
+`)
+
+		_, err := load(path, validEnv(t).lookup)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown key "redis.hots"`)
+	})
+
+	t.Run("Secret from file", func(t *testing.T) {
+		e := validEnv(t)
+		delete(e, "REFRESH_SECRET")
+		e["REFRESH_SECRET_FILE"] = writeFile(t, t.TempDir(), "refresh_secret", "fromfile\n")
+
+		c, err := load("", e.lookup)
+
+		require.NoError(t, err)
+		assert.Equal(t, "fromfile", c.Tokens.RefreshSecret)
+	})
+
+	t.Run("Secret set twice", func(t *testing.T) {
+		e := validEnv(t)
+		e["REFRESH_SECRET_FILE"] = writeFile(t, t.TempDir(), "refresh_secret", "fromfile")
+
+		_, err := load("", e.lookup)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only one of REFRESH_SECRET and REFRESH_SECRET_FILE may be set")
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	e := validEnv(t)
+	e["PG_PASSWORD"] = "hunter2"
+
+	c, err := load("", e.lookup)
+	require.NoError(t, err)
+
+	out := c.Redacted()
+
+	assert.Contains(t, out, "PG_PASSWORD=[redacted]\n")
+	assert.Contains(t, out, "REFRESH_SECRET=[redacted]\n")
+	assert.Contains(t, out, "S3_SECRET_KEY=\n")
+	assert.Contains(t, out, "HANDLER_TIMEOUT=5s\n")
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "refreshsecret")
+}
+
+func TestChanged(t *testing.T) {
+	e := validEnv(t)
+	a, err := load("", e.lookup)
+	require.NoError(t, err)
+
+	e["HANDLER_TIMEOUT"] = "10"
+	e["PG_HOST"] = "db.internal"
+	b, err := load("", e.lookup)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"HANDLER_TIMEOUT", "PG_HOST"}, a.Changed(b))
+	assert.Empty(t, a.Changed(a))
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field 配置项，key 为配置文件中的路径
+type field struct {
+	env    string
+	key    string
+	tag    reflect.StructTag
+	value  reflect.Value
+	secret bool
+}
+
+// loader 加载过程中收集所有问题，最后一次性返回
+type loader struct {
+	lookupEnv func(string) (string, bool)
+	problems  []string
+}
+
+func (l *loader) addf(format string, args ...interface{}) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// collectFields 按声明顺序展开所有配置项
+func collectFields(c *Config) []*field {
+	var fields []*field
+
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			key := prefix + sf.Tag.Get("key")
+
+			if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+				walk(v.Field(i), key+".")
+				continue
+			}
+
+			fields = append(fields, &field{
+				env:    sf.Tag.Get("env"),
+				key:    key,
+				tag:    sf.Tag,
+				value:  v.Field(i),
+				secret: sf.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+
+	return fields
+}
+
+func (l *loader) applyDefaults(fields []*field) {
+	for _, f := range fields {
+		if d, ok := f.tag.Lookup("default"); ok {
+			if err := setValue(f.value, d); err != nil {
+				l.addf("default for %s: %v", f.env, err)
+			}
+		}
+	}
+}
+
+// applyFile 按扩展名解析 YAML 或 TOML 文件，未知的 key 视为错误
+func (l *loader) applyFile(fields []*field, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.addf("CONFIG_FILE: %v", err)
+		return
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		l.addf("CONFIG_FILE: unsupported file extension %q, expected .yaml, .yml or .toml", ext)
+		return
+	}
+	if err != nil {
+		l.addf("CONFIG_FILE: %v", err)
+		return
+	}
+
+	values := map[string]string{}
+	flatten(raw, "", values)
+
+	for _, f := range fields {
+		s, ok := values[f.key]
+		if !ok {
+			continue
+		}
+		delete(values, f.key)
+
+		if err := setValue(f.value, s); err != nil {
+			l.addf("%s in %s: %v", f.key, path, err)
+		}
+	}
+
+	unknown := make([]string, 0, len(values))
+	for k := range values {
+		unknown = append(unknown, k)
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		l.addf("unknown key %q in %s", k, path)
+	}
+}
+
+// applyEnv 环境变量覆盖配置文件，secret 字段支持 <ENV>_FILE
+func (l *loader) applyEnv(fields []*field) {
+	for _, f := range fields {
+		s, ok := l.lookupEnv(f.env)
+		s = strings.TrimSpace(s)
+		hasValue := ok && s != ""
+
+		if f.secret {
+			if path, ok := l.lookupEnv(f.env + "_FILE"); ok && strings.TrimSpace(path) != "" {
+				if hasValue {
+					l.addf("only one of %s and %s_FILE may be set", f.env, f.env)
+					continue
+				}
+
+				data, err := os.ReadFile(strings.TrimSpace(path))
+				if err != nil {
+					l.addf("%s_FILE: %v", f.env, err)
+					continue
+				}
+				s, hasValue = strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+
+		if !hasValue {
+			continue
+		}
+
+		if err := setValue(f.value, s); err != nil {
+			l.addf("%s: %v", f.env, err)
+		}
+	}
+}
+
+// checkFields 检查 required、oneof 和 min 标签
+func (l *loader) checkFields(fields []*field) {
+	for _, f := range fields {
+		if f.tag.Get("required") == "true" && f.value.IsZero() {
+			l.addf("%s is required", f.env)
+			continue
+		}
+
+		if oneof, ok := f.tag.Lookup("oneof"); ok {
+			allowed := strings.Fields(oneof)
+			if !contains(allowed, f.value.String()) {
+				l.addf("%s must be one of %s, got %q", f.env, strings.Join(allowed, ", "), f.value.String())
+			}
+		}
+
+		if min, ok := f.tag.Lookup("min"); ok {
+			m, _ := strconv.ParseInt(min, 10, 64)
+			if f.value.Int() < m {
+				l.addf("%s must be at least %s, got %s", f.env, formatValue(f.value.Type(), m), formatValue(f.value.Type(), f.value.Int()))
+			}
+		}
+	}
+}
+
+// setValue 将字符串解析为字段类型
+// time.Duration 接受 Go 时长格式，纯数字按秒处理以兼容原有的环境变量
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected seconds or a value like 90s or 15m", s)
+	}
+	return d, nil
+}
+
+func formatValue(t reflect.Type, n int64) string {
+	if t == durationType {
+		return time.Duration(n).String()
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// flatten 将嵌套的 map 展开为 "a.b" 形式的 key
+func flatten(m map[string]interface{}, prefix string, out map[string]string) {
+	for k, v := range m {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(v, key+".", out)
+		case nil:
+			// 空值与未设置相同，使用默认值
+			continue
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted 返回生效的配置，每行一个环境变量，secret 字段的值被隐藏
+func (c *Config) Redacted() string {
+	var b strings.Builder
+	for _, f := range collectFields(c) {
+		value := fmt.Sprint(f.value.Interface())
+		if f.secret && value != "" {
+			value = "[redacted]"
+		}
+		fmt.Fprintf(&b, "%s=%s\n", f.env, value)
+	}
+	return b.String()
+}
+
+// Changed 返回与 other 取值不同的配置项的环境变量名
+func (c *Config) Changed(other *Config) []string {
+	var changed []string
+	otherFields := collectFields(other)
+	for i, f := range collectFields(c) {
+		if !reflect.DeepEqual(f.value.Interface(), otherFields[i].value.Interface()) {
+			changed = append(changed, f.env)
+		}
+	}
+	return changed
+}
@@ -3,57 +3,37 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"memrizr/config"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/sirupsen/logrus"
 )
 
 type dataSources struct {
 	DB          *sqlx.DB
 	RedisClient *redis.Client
+	// S3Client 仅在 STORAGE_BACKEND=s3 时初始化
+	S3Client *minio.Client
 }
 
 // 初始化建立连接
-func initDS() (*dataSources, error) {
-	log.Printf("Initializing data sources\n")
-	// 加载 env 数据
-	pgHost := os.Getenv("PG_HOST")
-	pgPort := os.Getenv("PG_PORT")
-	pgUser := os.Getenv("PG_USER")
-	pgPassword := os.Getenv("PG_PASSWORD")
-	pgDB := os.Getenv("PG_DB")
-	pgSSL := os.Getenv("PG_SSL")
-
-	pgConnString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		pgHost,
-		pgPort,
-		pgUser,
-		pgPassword,
-		pgDB,
-		pgSSL,
-	)
-
-	log.Printf("connecting to Postgresql\n")
-	db, err := sqlx.Open("postgres", pgConnString)
-	if err != nil {
-		return nil, fmt.Errorf("error opening db: %w", err)
-	}
+func initDS(cfg *config.Config, logger *logrus.Logger) (*dataSources, error) {
+	logger.Info("Initializing data sources")
 
-	// 验证数据库是否连接正常
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("error connecting to db: %w", err)
+	logger.Info("Connecting to Postgresql")
+	db, err := openDB(cfg.Postgres)
+	if err != nil {
+		return nil, err
 	}
 
 	// 初始化 redis 连接
-	redisHost := os.Getenv("REDIS_HOST")
-	redisPort := os.Getenv("REDIS_PORT")
-
-	log.Printf("Connecting to Redis\n")
+	logger.Info("Connecting to Redis")
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", redisHost, redisPort),
+		Addr:     cfg.Redis.Addr(),
 		Password: "",
 		DB:       0,
 	})
@@ -64,12 +44,52 @@ func initDS() (*dataSources, error) {
 		return nil, fmt.Errorf("error connecting to redis: %w", err)
 	}
 
+	// 初始化 S3 兼容的对象存储连接
+	var s3Client *minio.Client
+	if cfg.Storage.Backend == config.StorageS3 {
+		logger.Info("Connecting to object storage")
+		s3Client, err = minio.New(cfg.S3.Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(cfg.S3.AccessKey, cfg.S3.SecretKey, ""),
+			Secure: cfg.S3.UseSSL,
+			Region: cfg.S3.Region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating object storage client: %w", err)
+		}
+
+		// 验证 bucket 是否存在
+		exists, err := s3Client.BucketExists(context.Background(), cfg.S3.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to object storage: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("object storage bucket %q does not exist", cfg.S3.Bucket)
+		}
+	}
+
 	return &dataSources{
 		DB:          db,
 		RedisClient: rdb,
+		S3Client:    s3Client,
 	}, nil
 }
 
+// openDB 连接 Postgres，子命令只需要数据库时也使用它
+func openDB(c config.PostgresConfig) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", c.ConnString())
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+
+	// 验证数据库是否连接正常
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to db: %w", err)
+	}
+
+	return db, nil
+}
+
 // Close 关闭数据库
 func (d *dataSources) Close() error {
 	if err := d.DB.Close(); err != nil {
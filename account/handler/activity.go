@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// activityReq 用户查询自己的安全事件
+type activityReq struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// adminAuditReq 管理员查询审计日志
+type adminAuditReq struct {
+	User    string     `form:"user" binding:"omitempty,uuid"`
+	Actor   string     `form:"actor" binding:"omitempty,uuid"`
+	Target  string     `form:"target" binding:"omitempty,uuid"`
+	Action  string     `form:"action"`
+	Outcome string     `form:"outcome" binding:"omitempty,oneof=success failure"`
+	Since   *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until   *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor  string     `form:"cursor"`
+	Limit   int        `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// Activity 获取当前用户相关的安全事件
+func (h *Handler) Activity(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	var req activityReq
+
+	if ok := h.bindQuery(c, &req); !ok {
+		return
+	}
+
+	q := &model.AuditQuery{
+		UserID: &authUser.UID,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	}
+
+	h.listAudit(c, q)
+}
+
+// AdminAudit 按条件查询审计日志
+func (h *Handler) AdminAudit(c *gin.Context) {
+	var req adminAuditReq
+
+	if ok := h.bindQuery(c, &req); !ok {
+		return
+	}
+
+	q := &model.AuditQuery{
+		UserID:   parseOptionalUUID(req.User),
+		ActorID:  parseOptionalUUID(req.Actor),
+		TargetID: parseOptionalUUID(req.Target),
+		Action:   req.Action,
+		Outcome:  req.Outcome,
+		Since:    req.Since,
+		Until:    req.Until,
+		Cursor:   req.Cursor,
+		Limit:    req.Limit,
+	}
+
+	h.listAudit(c, q)
+}
+
+// listAudit 查询审计日志并写入响应
+func (h *Handler) listAudit(c *gin.Context, q *model.AuditQuery) {
+	ctx := c.Request.Context()
+	page, err := h.AuditService.List(ctx, q)
+	if err != nil {
+		h.logFailure(c, nil, err, "Failed to list audit events")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseOptionalUUID 解析已验证的 uuid 字符串，空字符串返回 nil
+func parseOptionalUUID(s string) *uuid.UUID {
+	if s == "" {
+		return nil
+	}
+
+	uid := uuid.MustParse(s)
+	return &uid
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"memrizr/model"
+	"memrizr/model/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestActivity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Lists events for the authenticated user", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockPage := &model.AuditPage{
+			Events: []*model.AuditEvent{{ID: 7, ActorID: &uid, Action: model.AuditSignin, Outcome: model.OutcomeSuccess}},
+		}
+
+		mockAuditService := new(mocks.MockAuditService)
+		mockAuditService.On("List", mock.Anything, &model.AuditQuery{UserID: &uid, Limit: 5}).Return(mockPage, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:            router,
+			AuditService: mockAuditService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/me/activity?limit=5", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(mockPage)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockAuditService.AssertExpectations(t)
+	})
+}
+
+func TestAdminAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Filters by target and outcome", func(t *testing.T) {
+		target, _ := uuid.NewRandom()
+
+		mockAuditService := new(mocks.MockAuditService)
+		mockAuditService.On("List", mock.Anything, &model.AuditQuery{
+			TargetID: &target,
+			Action:   model.AuditSignin,
+			Outcome:  model.OutcomeFailure,
+		}).Return(&model.AuditPage{Events: []*model.AuditEvent{}}, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:            router,
+			AuditService: mockAuditService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/audit?target="+target.String()+"&action=user.signin&outcome=failure", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockAuditService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid actor", func(t *testing.T) {
+		mockAuditService := new(mocks.MockAuditService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:            router,
+			AuditService: mockAuditService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/audit?actor=notauuid", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAuditService.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// adminListUsersReq 管理员查询用户列表的 query 参数
+type adminListUsersReq struct {
+	Email         string     `form:"email"`
+	Name          string     `form:"name"`
+	CreatedAfter  *time.Time `form:"createdAfter" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"createdBefore" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status        string     `form:"status" binding:"omitempty,oneof=active suspended disabled"`
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// adminUpdateUserReq 管理员编辑用户请求，只更新提供的字段
+type adminUpdateUserReq struct {
+	Name     *string `json:"name" binding:"omitempty,max=40"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Website  *string `json:"website" binding:"omitempty,url"`
+	ImageURL *string `json:"image_url" binding:"omitempty,url"`
+	Role     *string `json:"role" binding:"omitempty,oneof=user admin"`
+}
+
+// adminStatusReq 管理员暂停或禁用用户请求
+type adminStatusReq struct {
+	Reason    string     `json:"reason" binding:"required,max=500"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// AdminListUsers 分页查询用户
+func (h *Handler) AdminListUsers(c *gin.Context) {
+	var req adminListUsersReq
+
+	if ok := h.bindQuery(c, &req); !ok {
+		return
+	}
+
+	q := &model.UserListQuery{
+		EmailPrefix:   req.Email,
+		Name:          req.Name,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Status:        req.Status,
+		Cursor:        req.Cursor,
+		Limit:         req.Limit,
+	}
+
+	ctx := c.Request.Context()
+	page, err := h.UserService.List(ctx, q)
+	if err != nil {
+		h.logFailure(c, nil, err, "Failed to list users")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// AdminGetUser 获取单个用户及其活跃会话数
+func (h *Handler) AdminGetUser(c *gin.Context) {
+	uid, ok := bindUID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.Get(ctx, uid)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": uid}, err, "Unable to find user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	sessions, err := h.TokenService.CountSessions(ctx, uid)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": uid}, err, "Unable to count sessions for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":           u,
+		"activeSessions": sessions,
+	})
+}
+
+// AdminUpdateUser 编辑用户的任意资料字段
+func (h *Handler) AdminUpdateUser(c *gin.Context) {
+	uid, ok := bindUID(c)
+	if !ok {
+		return
+	}
+
+	var req adminUpdateUserReq
+
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.Get(ctx, uid)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": uid}, err, "Unable to find user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	if req.Name != nil {
+		u.Name = *req.Name
+	}
+	if req.Email != nil {
+		u.Email = *req.Email
+	}
+	if req.Website != nil {
+		u.Website = *req.Website
+	}
+	if req.ImageURL != nil {
+		// 外部图片不生成多个尺寸
+		u.ImageURL = *req.ImageURL
+		u.ImageURLs = model.NewImageURLs(*req.ImageURL)
+	}
+	if req.Role != nil {
+		u.Role = *req.Role
+	}
+
+	actorID := c.MustGet("user").(*model.User).UID
+	if err := h.UserService.AdminUpdate(ctx, actorID, u); err != nil {
+		h.logFailure(c, logrus.Fields{"uid": u.UID}, err, "Failed to update user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": u,
+	})
+}
+
+// AdminSuspendUser 暂停用户，可选到期时间
+func (h *Handler) AdminSuspendUser(c *gin.Context) {
+	var req adminStatusReq
+
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	h.setUserStatus(c, &model.StatusChange{
+		Status:    model.StatusSuspended,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	})
+}
+
+// AdminDisableUser 永久禁用用户
+func (h *Handler) AdminDisableUser(c *gin.Context) {
+	var req adminStatusReq
+
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	h.setUserStatus(c, &model.StatusChange{
+		Status: model.StatusDisabled,
+		Reason: req.Reason,
+	})
+}
+
+// AdminReinstateUser 恢复被暂停或禁用的用户
+func (h *Handler) AdminReinstateUser(c *gin.Context) {
+	h.setUserStatus(c, &model.StatusChange{
+		Status: model.StatusActive,
+	})
+}
+
+// setUserStatus 修改路径参数中用户的状态，操作人为当前认证用户
+func (h *Handler) setUserStatus(c *gin.Context, change *model.StatusChange) {
+	uid, ok := bindUID(c)
+	if !ok {
+		return
+	}
+
+	change.ActorID = c.MustGet("user").(*model.User).UID
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.SetStatus(ctx, uid, change)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": uid}, err, "Failed to set status for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": u,
+	})
+}
+
+// bindUID 解析路径参数中的用户 id，失败时写入 400 响应
+func bindUID(c *gin.Context) (uuid.UUID, bool) {
+	return bindParamUUID(c, "uid")
+}
+
+// bindParamUUID 解析 uuid 类型的路径参数，失败时写入 400 响应
+func bindParamUUID(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		e := apperrors.NewBadRequest(name + " must be a valid uuid")
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
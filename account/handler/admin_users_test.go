@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockPage := &model.UserPage{
+			Users:      []*model.User{{UID: uid, Email: "bob@bob.com"}},
+			NextCursor: "nextcursor",
+		}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("List", mock.Anything, &model.UserListQuery{
+			EmailPrefix: "bob",
+			Cursor:      "somecursor",
+			Limit:       10,
+		}).Return(mockPage, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/users?email=bob&cursor=somecursor&limit=10", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(mockPage)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/users?limit=1000", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminGetUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		mockUser := &model.User{UID: uid, Email: "bob@bob.com"}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("Get", mock.Anything, uid).Return(mockUser, nil)
+		mockTokenService := new(mocks.MockTokenService)
+		mockTokenService.On("CountSessions", mock.Anything, uid).Return(3, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:            router,
+			UserService:  mockUserService,
+			TokenService: mockTokenService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/users/"+uid.String(), nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"user":           mockUser,
+			"activeSessions": 3,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+		mockTokenService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid uid", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/users/notauuid", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminUpdateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Updates only provided fields", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		adminID, _ := uuid.NewRandom()
+		mockUser := &model.User{
+			UID:     uid,
+			Email:   "bob@bob.com",
+			Name:    "Bobby Bobson",
+			Website: "https://bob.com",
+			Role:    model.RoleUser,
+		}
+
+		expectedUser := &model.User{
+			UID:     uid,
+			Email:   "bob@bob.com",
+			Name:    "Robert",
+			Website: "https://bob.com",
+			Role:    model.RoleAdmin,
+		}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("Get", mock.Anything, uid).Return(mockUser, nil)
+		mockUserService.On("AdminUpdate", mock.Anything, adminID, expectedUser).Return(nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: adminID, Role: model.RoleAdmin})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		reqBody, err := json.Marshal(gin.H{
+			"name": "Robert",
+			"role": model.RoleAdmin,
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPut, "/admin/users/"+uid.String(), bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		reqBody, err := json.Marshal(gin.H{
+			"role": "superuser",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPut, "/admin/users/"+uid.String(), bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "AdminUpdate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		adminID, _ := uuid.NewRandom()
+		mockUser := &model.User{UID: uid, Email: "bob@bob.com", Role: model.RoleUser}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("Get", mock.Anything, uid).Return(mockUser, nil)
+		mockUserService.On("AdminUpdate", mock.Anything, adminID, mock.AnythingOfType("*model.User")).
+			Return(apperrors.NewConflict("email", "taken@bob.com"))
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: adminID, Role: model.RoleAdmin})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		reqBody, err := json.Marshal(gin.H{
+			"email": "taken@bob.com",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPut, "/admin/users/"+uid.String(), bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+}
+
+func TestAdminSuspendUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		adminID, _ := uuid.NewRandom()
+		mockUser := &model.User{UID: uid, Status: model.StatusSuspended, StatusReason: "spam"}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("SetStatus", mock.Anything, uid, &model.StatusChange{
+			Status:  model.StatusSuspended,
+			Reason:  "spam",
+			ActorID: adminID,
+		}).Return(mockUser, nil)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: adminID, Role: model.RoleAdmin})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		reqBody, err := json.Marshal(gin.H{
+			"reason": "spam",
+		})
+		assert.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/admin/users/"+uid.String()+"/suspend", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		respBody, err := json.Marshal(gin.H{
+			"user": mockUser,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Reason required", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+
+		router := gin.Default()
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+		})
+
+		request, err := http.NewRequest(http.MethodPost, "/admin/users/"+uid.String()+"/suspend", bytes.NewBufferString("{}"))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookReq 创建 webhook 请求
+type webhookReq struct {
+	URL         string   `json:"url" binding:"required,url"`
+	EventTypes  []string `json:"eventTypes" binding:"required,min=1"`
+	Description string   `json:"description" binding:"max=200"`
+	Active      *bool    `json:"active"`
+}
+
+// updateWebhookReq 编辑 webhook 请求，只更新提供的字段
+type updateWebhookReq struct {
+	URL         *string  `json:"url" binding:"omitempty,url"`
+	EventTypes  []string `json:"eventTypes" binding:"omitempty,min=1"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	Active      *bool    `json:"active"`
+}
+
+// deliveriesReq 查询投递记录的 query 参数
+type deliveriesReq struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// AdminCreateWebhook 注册 webhook，签名密钥只在此时返回
+func (h *Handler) AdminCreateWebhook(c *gin.Context) {
+	var req webhookReq
+
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	actorID := c.MustGet("user").(*model.User).UID
+
+	w := &model.Webhook{
+		URL:         req.URL,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		Active:      req.Active == nil || *req.Active,
+		CreatedBy:   &actorID,
+	}
+
+	ctx := c.Request.Context()
+	if err := h.WebhookService.Create(ctx, w); err != nil {
+		h.logFailure(c, nil, err, "Failed to create webhook")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": w,
+		"secret":  w.Secret,
+	})
+}
+
+// AdminListWebhooks 获取所有 webhook
+func (h *Handler) AdminListWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+	webhooks, err := h.WebhookService.List(ctx)
+	if err != nil {
+		h.logFailure(c, nil, err, "Failed to list webhooks")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+	})
+}
+
+// AdminGetWebhook 获取单个 webhook
+func (h *Handler) AdminGetWebhook(c *gin.Context) {
+	id, ok := bindParamUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	w, err := h.WebhookService.Get(ctx, id)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"webhook_id": id}, err, "Unable to find webhook")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": w,
+	})
+}
+
+// AdminUpdateWebhook 编辑 webhook 的地址、订阅或启用状态
+func (h *Handler) AdminUpdateWebhook(c *gin.Context) {
+	id, ok := bindParamUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req updateWebhookReq
+
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	w, err := h.WebhookService.Get(ctx, id)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"webhook_id": id}, err, "Unable to find webhook")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	if req.URL != nil {
+		w.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		w.EventTypes = req.EventTypes
+	}
+	if req.Description != nil {
+		w.Description = *req.Description
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+
+	if err := h.WebhookService.Update(ctx, w); err != nil {
+		h.logFailure(c, logrus.Fields{"webhook_id": w.ID}, err, "Failed to update webhook")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": w,
+	})
+}
+
+// AdminDeleteWebhook 删除 webhook
+func (h *Handler) AdminDeleteWebhook(c *gin.Context) {
+	id, ok := bindParamUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.WebhookService.Delete(ctx, id); err != nil {
+		h.logFailure(c, logrus.Fields{"webhook_id": id}, err, "Failed to delete webhook")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AdminWebhookDeliveries 分页查询 webhook 的投递记录
+func (h *Handler) AdminWebhookDeliveries(c *gin.Context) {
+	id, ok := bindParamUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req deliveriesReq
+
+	if ok := h.bindQuery(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	page, err := h.WebhookService.Deliveries(ctx, &model.WebhookDeliveryQuery{
+		WebhookID: id,
+		Status:    req.Status,
+		Cursor:    req.Cursor,
+		Limit:     req.Limit,
+	})
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"webhook_id": id}, err, "Failed to list deliveries for webhook")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// AdminRetryDelivery 重新投递失败或已完成的投递记录
+func (h *Handler) AdminRetryDelivery(c *gin.Context) {
+	id, ok := bindParamUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryID"), 10, 64)
+	if err != nil {
+		e := apperrors.NewBadRequest("deliveryID must be an integer")
+
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	d, err := h.WebhookService.RetryDelivery(ctx, id, deliveryID)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{
+			"webhook_id":  id,
+			"delivery_id": deliveryID,
+		}, err, "Failed to retry delivery")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"delivery": d,
+	})
+}
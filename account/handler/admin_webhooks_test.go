@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminCreateWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	actorID, _ := uuid.NewRandom()
+
+	setup := func(ws model.WebhookService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: actorID, Role: model.RoleAdmin})
+		})
+		NewHandler(&Config{
+			R:              router,
+			WebhookService: ws,
+		})
+		return router
+	}
+
+	t.Run("Returns secret once", func(t *testing.T) {
+		mockWebhookService := new(mocks.MockWebhookService)
+		mockWebhookService.On("Create", mock.Anything, mock.MatchedBy(func(w *model.Webhook) bool {
+			return w.URL == "https://partner.example.com/hooks" && w.Active && *w.CreatedBy == actorID
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*model.Webhook).Secret = "whsec_abc"
+		}).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router := setup(mockWebhookService)
+
+		reqBody, _ := json.Marshal(gin.H{
+			"url":        "https://partner.example.com/hooks",
+			"eventTypes": []string{model.EventUserCreated},
+		})
+		request, err := http.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		var body struct {
+			Webhook map[string]interface{} `json:"webhook"`
+			Secret  string                 `json:"secret"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "whsec_abc", body.Secret)
+		assert.NotContains(t, body.Webhook, "secret")
+		mockWebhookService.AssertExpectations(t)
+	})
+
+	t.Run("Service rejects target", func(t *testing.T) {
+		mockWebhookService := new(mocks.MockWebhookService)
+		mockWebhookService.On("Create", mock.Anything, mock.Anything).Return(apperrors.NewBadRequest("url host 10.0.0.1 is not a public address"))
+
+		rr := httptest.NewRecorder()
+		router := setup(mockWebhookService)
+
+		reqBody, _ := json.Marshal(gin.H{
+			"url":        "http://10.0.0.1/hooks",
+			"eventTypes": []string{model.EventUserCreated},
+		})
+		request, err := http.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockWebhookService.AssertExpectations(t)
+	})
+
+	t.Run("Missing event types", func(t *testing.T) {
+		mockWebhookService := new(mocks.MockWebhookService)
+
+		rr := httptest.NewRecorder()
+		router := setup(mockWebhookService)
+
+		reqBody, _ := json.Marshal(gin.H{
+			"url": "https://partner.example.com/hooks",
+		})
+		request, err := http.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewBuffer(reqBody))
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockWebhookService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminWebhookDeliveries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookID, _ := uuid.NewRandom()
+
+	t.Run("Lists dead deliveries", func(t *testing.T) {
+		mockPage := &model.WebhookDeliveryPage{
+			Deliveries: []*model.WebhookDelivery{{ID: 9, WebhookID: webhookID, Status: model.DeliveryDead}},
+			NextCursor: "9",
+		}
+
+		mockWebhookService := new(mocks.MockWebhookService)
+		mockWebhookService.On("Deliveries", mock.Anything, &model.WebhookDeliveryQuery{
+			WebhookID: webhookID,
+			Status:    model.DeliveryDead,
+			Limit:     1,
+		}).Return(mockPage, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		NewHandler(&Config{
+			R:              router,
+			WebhookService: mockWebhookService,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/admin/webhooks/"+webhookID.String()+"/deliveries?status=dead&limit=1", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		respBody, _ := json.Marshal(mockPage)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		mockWebhookService.AssertExpectations(t)
+	})
+
+	t.Run("Retries delivery", func(t *testing.T) {
+		d := &model.WebhookDelivery{ID: 9, WebhookID: webhookID, Status: model.DeliveryPending}
+
+		mockWebhookService := new(mocks.MockWebhookService)
+		mockWebhookService.On("RetryDelivery", mock.Anything, webhookID, int64(9)).Return(d, nil)
+
+		rr := httptest.NewRecorder()
+		router := gin.Default()
+		NewHandler(&Config{
+			R:              router,
+			WebhookService: mockWebhookService,
+		})
+
+		request, err := http.NewRequest(http.MethodPost, "/admin/webhooks/"+webhookID.String()+"/deliveries/9/retry", nil)
+		assert.NoError(t, err)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		mockWebhookService.AssertExpectations(t)
+	})
+}
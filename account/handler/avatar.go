@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"memrizr/model/apperrors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultAvatar 获取生成的默认头像，路径为 /avatars/default/:uid/<size>.png
+// 同一 uid 和尺寸的头像不会变化，允许长期缓存
+func (h *Handler) DefaultAvatar(c *gin.Context) {
+	uid, ok := bindUID(c)
+	if !ok {
+		return
+	}
+
+	file := c.Param("file")
+	size, err := strconv.Atoi(strings.TrimSuffix(file, ".png"))
+	if err != nil || !strings.HasSuffix(file, ".png") {
+		e := apperrors.NewNotFound("avatar", file)
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, uid, size)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(publicMediaMaxAge.Seconds())))
+	c.Header("ETag", etag)
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx := c.Request.Context()
+	data, err := h.AvatarService.Default(ctx, uid, size)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": uid}, err, "Unable to get default avatar")
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", data)
+}
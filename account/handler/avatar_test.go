@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDefaultAvatar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(as model.AvatarService) *gin.Engine {
+		router := gin.Default()
+		NewHandler(&Config{
+			R:             router,
+			AvatarService: as,
+		})
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockAvatarService := new(mocks.MockAvatarService)
+		mockAvatarService.On("Default", mock.Anything, uid, 64).Return([]byte("pngdata"), nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/avatars/default/"+uid.String()+"/64.png", nil)
+		setup(mockAvatarService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "pngdata", rr.Body.String())
+		assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Cache-Control"), "immutable")
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+		mockAvatarService.AssertExpectations(t)
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockAvatarService := new(mocks.MockAvatarService)
+		router := setup(mockAvatarService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/avatars/default/"+uid.String()+"/64.png", nil)
+		request.Header.Set("If-None-Match", `"`+uid.String()+`-64"`)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		mockAvatarService.AssertNotCalled(t, "Default", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid file name", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockAvatarService := new(mocks.MockAvatarService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/avatars/default/"+uid.String()+"/64.jpg", nil)
+		setup(mockAvatarService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockAvatarService.AssertNotCalled(t, "Default", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unsupported size", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockAvatarService := new(mocks.MockAvatarService)
+		mockAvatarService.On("Default", mock.Anything, uid, 100).Return(nil, apperrors.NewNotFound("avatar size", "100"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/avatars/default/"+uid.String()+"/100.png", nil)
+		setup(mockAvatarService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+		mockAvatarService.AssertExpectations(t)
+	})
+}
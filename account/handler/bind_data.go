@@ -1,11 +1,12 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
 	"memrizr/model/apperrors"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -18,7 +19,7 @@ type invalidArgument struct {
 }
 
 // bindData 帮助函数，如果数据没有绑定返回 false
-func bindData(c *gin.Context, req interface{}) bool {
+func (h *Handler) bindData(c *gin.Context, req interface{}) bool {
 	if c.ContentType() != "application/json" {
 		msg := fmt.Sprintf("%s noly accepts Content-Type application/json", c.FullPath())
 
@@ -33,25 +34,9 @@ func bindData(c *gin.Context, req interface{}) bool {
 
 	// 绑定 输入的 json 数据到 结构体上，并检查验证错误
 	if err := c.ShouldBind(req); err != nil {
-		log.Printf("Error binding data: %+v\n", err)
-
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			var invalidArgs []invalidArgument
-
-			for _, err := range errs {
-				invalidArgs = append(invalidArgs, invalidArgument{
-					err.Field(),
-					err.Value().(string),
-					err.Tag(),
-					err.Param(),
-				})
-			}
-			err := apperrors.NewBadRequest("Invalid request parameters. See invalidArgs")
-
-			c.JSON(err.Status(), gin.H{
-				"error":       err,
-				"invalidArgs": invalidArgs,
-			})
+		h.log(c).WithError(err).Info("Error binding data")
+
+		if ok := respondValidationErrors(c, err); ok {
 			return false
 		}
 		fallBack := apperrors.NewInternal()
@@ -62,3 +47,99 @@ func bindData(c *gin.Context, req interface{}) bool {
 
 	return true
 }
+
+// bindMergePatch 按 RFC 7396 JSON Merge Patch 绑定请求数据
+// 返回请求中出现的字段，值为 null 的字段在 req 中保持零值
+func (h *Handler) bindMergePatch(c *gin.Context, req interface{}) (map[string]json.RawMessage, bool) {
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" {
+		msg := fmt.Sprintf("%s only accepts Content-Type application/merge-patch+json", c.FullPath())
+
+		err := apperrors.NewUnsupportedMediaType(msg)
+
+		c.JSON(err.Status(), gin.H{
+			"error": err,
+		})
+
+		return nil, false
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		h.log(c).WithError(err).Info("Error reading merge patch")
+		e := apperrors.NewBadRequest("unable to read request body")
+		c.JSON(e.Status(), gin.H{"error": e})
+		return nil, false
+	}
+
+	// patch 必须是 JSON 对象
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		e := apperrors.NewBadRequest("merge patch must be a JSON object")
+		c.JSON(e.Status(), gin.H{"error": e})
+		return nil, false
+	}
+
+	if err := json.Unmarshal(body, req); err != nil {
+		e := apperrors.NewBadRequest(fmt.Sprintf("invalid merge patch: %v", err))
+		c.JSON(e.Status(), gin.H{"error": e})
+		return nil, false
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		h.log(c).WithError(err).Info("Error validating merge patch")
+
+		if ok := respondValidationErrors(c, err); ok {
+			return nil, false
+		}
+		fallBack := apperrors.NewInternal()
+
+		c.JSON(fallBack.Status(), gin.H{"error": fallBack})
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// bindQuery 绑定 query 参数，如果数据没有绑定返回 false
+func (h *Handler) bindQuery(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		h.log(c).WithError(err).Info("Error binding query")
+
+		if ok := respondValidationErrors(c, err); ok {
+			return false
+		}
+		// query 参数解析失败属于客户端错误
+		e := apperrors.NewBadRequest(err.Error())
+
+		c.JSON(e.Status(), gin.H{"error": e})
+		return false
+	}
+
+	return true
+}
+
+// respondValidationErrors 如果是验证错误，写入 400 响应并返回 true
+func respondValidationErrors(c *gin.Context, err error) bool {
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return false
+	}
+
+	var invalidArgs []invalidArgument
+
+	for _, err := range errs {
+		invalidArgs = append(invalidArgs, invalidArgument{
+			err.Field(),
+			fmt.Sprintf("%v", err.Value()),
+			err.Tag(),
+			err.Param(),
+		})
+	}
+	e := apperrors.NewBadRequest("Invalid request parameters. See invalidArgs")
+
+	c.JSON(e.Status(), gin.H{
+		"error":       e,
+		"invalidArgs": invalidArgs,
+	})
+	return true
+}
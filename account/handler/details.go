@@ -1,12 +1,13 @@
 package handler
 
 import (
-	"log"
+	"fmt"
 	"memrizr/model"
 	"memrizr/model/apperrors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 type detailsReq struct {
@@ -15,15 +16,28 @@ type detailsReq struct {
 	Website string `json:"website" binding:"omitempty,url"`
 }
 
+// detailsPatchReq 部分更新用户资料请求，字段为 nil 表示不存在或为 null
+type detailsPatchReq struct {
+	Name    *string `json:"name" binding:"omitempty,max=40"`
+	Email   *string `json:"email" binding:"omitempty,email"`
+	Website *string `json:"website" binding:"omitempty,url"`
+}
+
 // Details handler
+// 需要 If-Match 携带 GET /me 返回的 ETag
 func (h *Handler) Details(c *gin.Context) {
 	// 检查上下文 是否 存在 user
 	authUser := c.MustGet("user").(*model.User)
 
+	version, ok := bindIfMatch(c)
+	if !ok {
+		return
+	}
+
 	var req detailsReq
 
 	// 检验请求数据
-	if ok := bindData(c, &req); !ok {
+	if ok := h.bindData(c, &req); !ok {
 		return
 	}
 
@@ -32,11 +46,69 @@ func (h *Handler) Details(c *gin.Context) {
 		Name:    req.Name,
 		Email:   req.Email,
 		Website: req.Website,
+		Version: version,
+	}
+
+	ctx := c.Request.Context()
+	pending, err := h.UserService.UpdateDetails(ctx, u)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": u.UID}, err, "Failed to update user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	// 邮箱修改需要确认，返回的用户仍为原邮箱
+	c.Header("ETag", userETag(u))
+	c.JSON(http.StatusOK, gin.H{
+		"user":               u,
+		"emailChangePending": pending,
+	})
+}
+
+// PatchDetails 按 JSON Merge Patch 部分更新用户资料
+// 只更新请求中出现的字段，值为 null 表示清空该字段
+// 需要 If-Match 携带 GET /me 返回的 ETag
+func (h *Handler) PatchDetails(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	version, ok := bindIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req detailsPatchReq
+
+	fields, ok := h.bindMergePatch(c, &req)
+	if !ok {
+		return
+	}
+
+	values := map[string]*string{
+		model.FieldName:    req.Name,
+		model.FieldEmail:   req.Email,
+		model.FieldWebsite: req.Website,
+	}
+
+	p := model.UserPatch{}
+	for field := range fields {
+		v, ok := values[field]
+		if !ok {
+			e := apperrors.NewBadRequest(fmt.Sprintf("unknown field: %s", field))
+			c.JSON(e.Status(), gin.H{
+				"error": e,
+			})
+			return
+		}
+		p[field] = v
 	}
 
 	ctx := c.Request.Context()
-	if err := h.UserService.UpdateDetails(ctx, u); err != nil {
-		log.Printf("Failed to update user: %v\n", err.Error())
+	u, pending, err := h.UserService.PatchDetails(ctx, authUser.UID, version, p)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": authUser.UID}, err, "Failed to patch user")
 
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
@@ -44,7 +116,9 @@ func (h *Handler) Details(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", userETag(u))
 	c.JSON(http.StatusOK, gin.H{
-		"user": u,
+		"user":               u,
+		"emailChangePending": pending,
 	})
 }
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatchDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setup := func(us model.UserService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: us,
+		})
+		return router
+	}
+
+	patch := func(router *gin.Engine, contentType string, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPatch, "/details", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("If-Match", `"3"`)
+		router.ServeHTTP(rr, request)
+		return rr
+	}
+
+	t.Run("Only present fields are updated", func(t *testing.T) {
+		name := "Bobby"
+		expected := model.UserPatch{model.FieldName: &name, model.FieldWebsite: nil}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("PatchDetails", mock.Anything, uid, int64(3), expected).
+			Return(&model.User{UID: uid, Name: name, Email: "bob@bob.com", Version: 4}, false, nil)
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"name":"Bobby","website":null}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"email":"bob@bob.com"`)
+		assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Empty patch", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("PatchDetails", mock.Anything, uid, int64(3), model.UserPatch{}).Return(&model.User{UID: uid}, false, nil)
+
+		rr := patch(setup(mockUserService), "application/json", `{}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Email change pending comes from the service", func(t *testing.T) {
+		email := "Bob@Bob.com"
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("PatchDetails", mock.Anything, uid, int64(3), model.UserPatch{model.FieldEmail: &email}).
+			Return(&model.User{UID: uid, Email: "bob@bob.com", Version: 3}, false, nil)
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"email":"Bob@Bob.com"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"emailChangePending":false`)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"role":"admin"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid email", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"email":"bob"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "invalidArgs")
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong type", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"name":42}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Patch must be an object", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `null`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unsupported content type", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := patch(setup(mockUserService), "text/plain", `{"name":"Bobby"}`)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		email := "taken@bob.com"
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("PatchDetails", mock.Anything, uid, int64(3), model.UserPatch{model.FieldEmail: &email}).
+			Return(nil, false, apperrors.NewConflict("email", email))
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"email":"taken@bob.com"}`)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Missing If-Match", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPatch, "/details", strings.NewReader(`{"name":"Bobby"}`))
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Weak If-Match", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPatch, "/details", strings.NewReader(`{"name":"Bobby"}`))
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+		request.Header.Set("If-Match", `W/"3"`)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		mockUserService.AssertNotCalled(t, "PatchDetails", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Any version", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("PatchDetails", mock.Anything, uid, int64(0), model.UserPatch{}).Return(&model.User{UID: uid}, false, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPatch, "/details", strings.NewReader(`{}`))
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+		request.Header.Set("If-Match", "*")
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Version mismatch", func(t *testing.T) {
+		name := "Bobby"
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("PatchDetails", mock.Anything, uid, int64(3), model.UserPatch{model.FieldName: &name}).
+			Return(nil, false, apperrors.NewPreconditionFailed("user has been modified by another request"))
+
+		rr := patch(setup(mockUserService), "application/merge-patch+json", `{"name":"Bobby"}`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// emailChangeReq 修改邮箱请求
+type emailChangeReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// emailConfirmReq 确认邮箱修改请求
+type emailConfirmReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// RequestEmailChange 请求修改邮箱，向新邮箱发送确认链接
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	var req emailChangeReq
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.UserService.RequestEmailChange(ctx, authUser.UID, req.Email); err != nil {
+		h.logFailure(c, logrus.Fields{"uid": authUser.UID}, err, "Failed to request email change for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "confirmation email sent",
+	})
+}
+
+// ConfirmEmailChange 使用确认链接中的 token 完成邮箱修改
+// token 即为新邮箱的所有权证明，不需要登录
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	var req emailConfirmReq
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.ConfirmEmailChange(ctx, req.Token)
+	if err != nil {
+		h.logFailure(c, nil, err, "Failed to confirm email change")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": u,
+	})
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setup := func(us model.UserService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: us,
+		})
+		return router
+	}
+
+	post := func(router *gin.Engine, path string, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+		return rr
+	}
+
+	t.Run("Request accepted", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("RequestEmailChange", mock.Anything, uid, "new@bob.com").Return(nil)
+
+		rr := post(setup(mockUserService), "/details/email", `{"email":"new@bob.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Request invalid email", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := post(setup(mockUserService), "/details/email", `{"email":"bob"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Confirm success", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("ConfirmEmailChange", mock.Anything, "abc").
+			Return(&model.User{UID: uid, Email: "new@bob.com"}, nil)
+
+		rr := post(setup(mockUserService), "/email/confirm", `{"token":"abc"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"email":"new@bob.com"`)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Confirm conflict", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("ConfirmEmailChange", mock.Anything, "abc").
+			Return(nil, apperrors.NewConflict("email", "new@bob.com"))
+
+		rr := post(setup(mockUserService), "/email/confirm", `{"token":"abc"}`)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Confirm missing token", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := post(setup(mockUserService), "/email/confirm", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "ConfirmEmailChange", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userETag 根据用户版本号生成 ETag
+func userETag(u *model.User) string {
+	return fmt.Sprintf(`"%d"`, u.Version)
+}
+
+// bindIfMatch 从 If-Match 中解析期望的用户版本号，"*" 匹配任意版本并返回 0
+// 缺少 If-Match 返回 428，无法解析的值不可能匹配，返回 412
+func bindIfMatch(c *gin.Context) (int64, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		e := apperrors.NewPreconditionRequired("If-Match header is required")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return 0, false
+	}
+
+	if ifMatch == "*" {
+		return 0, true
+	}
+
+	// If-Match 使用强比较，弱 ETag 不会匹配
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		e := apperrors.NewPreconditionFailed("If-Match does not match the current version")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// handleReq 设置 handle 请求，空字符串表示清除
+type handleReq struct {
+	Handle string `json:"handle" binding:"max=31"`
+}
+
+// HandleAvailability 检查当前用户能否使用 handle
+func (h *Handler) HandleAvailability(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	ctx := c.Request.Context()
+	handle, available, err := h.UserService.CheckHandle(ctx, authUser.UID, c.Param("handle"))
+	if err != nil {
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"handle":    handle,
+		"available": available,
+	})
+}
+
+// SetHandle 设置或清除当前用户的 handle
+func (h *Handler) SetHandle(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	var req handleReq
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.SetHandle(ctx, authUser.UID, req.Handle)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": authUser.UID}, err, "Failed to set handle for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.Header("ETag", userETag(u))
+	c.JSON(http.StatusOK, gin.H{
+		"user": u,
+	})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setup := func(us model.UserService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: us,
+		})
+		return router
+	}
+
+	t.Run("Availability", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("CheckHandle", mock.Anything, uid, "Bobby").Return("bobby", false, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/handles/Bobby/availability", nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"handle":"bobby","available":false}`, rr.Body.String())
+	})
+
+	t.Run("Availability of invalid handle", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("CheckHandle", mock.Anything, uid, "admin").
+			Return("admin", false, apperrors.NewBadRequest("handle is reserved: admin"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/handles/admin/availability", nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Set handle", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("SetHandle", mock.Anything, uid, "Bobby").
+			Return(&model.User{UID: uid, Handle: "bobby", Version: 2}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/me/handle", strings.NewReader(`{"handle":"Bobby"}`))
+		request.Header.Set("Content-Type", "application/json")
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"handle":"bobby"`)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("Set handle taken", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("SetHandle", mock.Anything, uid, "bobby").
+			Return(nil, apperrors.NewConflict("handle", "bobby"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/me/handle", strings.NewReader(`{"handle":"bobby"}`))
+		request.Header.Set("Content-Type", "application/json")
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
@@ -5,16 +5,26 @@ import (
 	"time"
 
 	"memrizr/handler/middleware"
+	"memrizr/logging"
 	"memrizr/model"
 	"memrizr/model/apperrors"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // Handler 保存处理程序运行所需的服务
 type Handler struct {
-	UserService  model.UserService
-	TokenService model.TokenService
+	UserService    model.UserService
+	TokenService   model.TokenService
+	AuditService   model.AuditService
+	WebhookService model.WebhookService
+	MediaService   model.MediaService
+	AvatarService  model.AvatarService
+	HealthService  model.HealthService
+	Limits         *Limits
+	Logger         *logrus.Logger
+	ErrorReporter  model.ErrorReporter
 }
 
 // Config 初始化 handler 包所需的配置数据
@@ -22,37 +32,107 @@ type Config struct {
 	R               *gin.Engine
 	UserService     model.UserService
 	TokenService    model.TokenService
+	AuditService    model.AuditService
+	WebhookService  model.WebhookService
+	MediaService    model.MediaService
+	AvatarService   model.AvatarService
+	HealthService   model.HealthService
 	BaseURL         string
 	TimeoutDuration time.Duration
+	MaxBodyBytes    int64
+	// Limits 可选，为空时使用 TimeoutDuration 和 MaxBodyBytes 创建
+	Limits *Limits
+	// OnTimeout 可选，请求超时后调用
+	OnTimeout func()
+	// Logger 可选，为空时使用 logging.Default
+	Logger *logrus.Logger
+	// ErrorReporter 可选，上报 panic 和意外的内部错误
+	ErrorReporter model.ErrorReporter
 }
 
 // NewHandler 初始化需要注入的路由及初始数据
 // 不返回，因为它直接处理 gin 引擎的引用
 func NewHandler(c *Config) {
+	limits := c.Limits
+	if limits == nil {
+		limits = NewLimits(c.TimeoutDuration, c.MaxBodyBytes)
+	}
+
 	h := &Handler{
-		UserService:  c.UserService,
-		TokenService: c.TokenService,
+		UserService:    c.UserService,
+		TokenService:   c.TokenService,
+		AuditService:   c.AuditService,
+		WebhookService: c.WebhookService,
+		MediaService:   c.MediaService,
+		AvatarService:  c.AvatarService,
+		HealthService:  c.HealthService,
+		Limits:         limits,
+		Logger:         logging.OrDefault(c.Logger),
+		ErrorReporter:  c.ErrorReporter,
 	}
 
 	// g := c.R.Group("/api/account")
 	g := c.R.Group(c.BaseURL)
 
+	// 管理员路由组
+	var ag *gin.RouterGroup
+
 	if gin.Mode() != gin.TestMode {
-		g.Use(middleware.Timeout(c.TimeoutDuration, apperrors.NewServiceUnavailable()))
+		g.Use(middleware.RequestMeta())
+		g.Use(middleware.TimeoutFunc(h.Limits.Timeout, apperrors.NewServiceUnavailable(), c.OnTimeout, h.ErrorReporter))
 		g.GET("/me", middleware.AuthUser(h.TokenService), h.Me)
+		g.GET("/me/activity", middleware.AuthUser(h.TokenService), h.Activity)
+		g.PUT("/me/privacy", middleware.AuthUser(h.TokenService), h.UpdatePrivacy)
+		g.PUT("/me/handle", middleware.AuthUser(h.TokenService), h.SetHandle)
+		g.GET("/handles/:handle/availability", middleware.AuthUser(h.TokenService), h.HandleAvailability)
 		g.POST("/signout", middleware.AuthUser(h.TokenService), h.Signout)
 		g.PUT("/details", middleware.AuthUser(h.TokenService), h.Details)
+		g.PATCH("/details", middleware.AuthUser(h.TokenService), h.PatchDetails)
+		g.POST("/details/email", middleware.AuthUser(h.TokenService), h.RequestEmailChange)
+		g.POST("/image", middleware.AuthUser(h.TokenService), h.Image)
+		g.DELETE("/image", middleware.AuthUser(h.TokenService), h.DeleteImage)
+		ag = g.Group("/admin", middleware.AuthUser(h.TokenService), middleware.AdminUser())
 	} else {
 		g.GET("/me", h.Me)
+		g.GET("/me/activity", h.Activity)
+		g.PUT("/me/privacy", h.UpdatePrivacy)
+		g.PUT("/me/handle", h.SetHandle)
+		g.GET("/handles/:handle/availability", h.HandleAvailability)
 		g.POST("/signout", h.Signout)
 		g.PUT("/details", h.Details)
+		g.PATCH("/details", h.PatchDetails)
+		g.POST("/details/email", h.RequestEmailChange)
+		g.POST("/image", h.Image)
+		g.DELETE("/image", h.DeleteImage)
+		ag = g.Group("/admin")
 	}
 
+	ag.GET("/users", h.AdminListUsers)
+	ag.GET("/users/:uid", h.AdminGetUser)
+	ag.PUT("/users/:uid", h.AdminUpdateUser)
+	ag.POST("/users/:uid/suspend", h.AdminSuspendUser)
+	ag.POST("/users/:uid/disable", h.AdminDisableUser)
+	ag.POST("/users/:uid/reinstate", h.AdminReinstateUser)
+	ag.GET("/audit", h.AdminAudit)
+	ag.POST("/webhooks", h.AdminCreateWebhook)
+	ag.GET("/webhooks", h.AdminListWebhooks)
+	ag.GET("/webhooks/:id", h.AdminGetWebhook)
+	ag.PUT("/webhooks/:id", h.AdminUpdateWebhook)
+	ag.DELETE("/webhooks/:id", h.AdminDeleteWebhook)
+	ag.GET("/webhooks/:id/deliveries", h.AdminWebhookDeliveries)
+	ag.POST("/webhooks/:id/deliveries/:deliveryID/retry", h.AdminRetryDelivery)
+
 	g.POST("/signup", h.Signup)
 	g.POST("/signin", h.Signin)
 	g.POST("/tokens", h.Tokens)
-	g.POST("/image", h.Image)
-	g.DELETE("/image", h.DeleteImage)
+	g.POST("/email/confirm", h.ConfirmEmailChange)
+	g.GET("/users/:id", h.PublicProfile)
+
+	g.GET("/media/*key", h.Media)
+	g.GET("/avatars/default/:uid/:file", h.DefaultAvatar)
+
+	g.GET("/healthz", h.Healthz)
+	g.GET("/readyz", h.Readyz)
 
 	g.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -61,18 +141,25 @@ func NewHandler(c *Config) {
 	})
 }
 
-// Image handler
-func (h *Handler) Image(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's image",
-	})
+// log 带有请求 ID 和 trace ID 的日志记录器
+func (h *Handler) log(c *gin.Context) *logrus.Entry {
+	return h.Logger.WithContext(c.Request.Context())
 }
 
-// DeleteImage handler
-func (h *Handler) DeleteImage(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"hello": "it's deleteImage",
-	})
-}
+// logFailure 记录处理失败的请求，服务端错误为 error 级别，客户端错误为 info 级别
+// 内部错误同时上报，fields 作为附加信息
+func (h *Handler) logFailure(c *gin.Context, fields logrus.Fields, err error, msg string) {
+	status := apperrors.Status(err)
 
+	level := logrus.InfoLevel
+	if status >= http.StatusInternalServerError {
+		level = logrus.ErrorLevel
+	}
+	h.log(c).WithFields(fields).WithError(err).Log(level, msg)
 
+	if status == http.StatusInternalServerError {
+		r := model.NewErrorReport(1, msg, err)
+		r.Extra = fields
+		middleware.Report(h.ErrorReporter, c, r)
+	}
+}
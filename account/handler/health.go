@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"memrizr/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Healthz 存活检查，只要进程能处理请求就返回成功
+func (h *Handler) Healthz(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// readyResp 就绪检查响应，接口不需要认证，只返回每个依赖的状态，错误详情只记录在日志中
+type readyResp struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Readyz 就绪检查，任一依赖不可用或服务正在关闭时返回 503
+func (h *Handler) Readyz(c *gin.Context) {
+	report := h.HealthService.Ready(c.Request.Context())
+
+	resp := readyResp{Status: report.Status}
+	if len(report.Checks) > 0 {
+		resp.Checks = make(map[string]string, len(report.Checks))
+	}
+	for name, r := range report.Checks {
+		resp.Checks[name] = r.Status
+		if r.Status != model.HealthOK {
+			h.log(c).WithFields(logrus.Fields{
+				"check":      name,
+				"error":      r.Error,
+				"latency_ms": r.LatencyMS,
+			}).Warn("Readiness check failed")
+		}
+	}
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, resp)
+}
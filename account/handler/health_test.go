@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"memrizr/model"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(hs model.HealthService) *gin.Engine {
+		router := gin.Default()
+		NewHandler(&Config{
+			R:             router,
+			HealthService: hs,
+		})
+		return router
+	}
+
+	t.Run("Healthz", func(t *testing.T) {
+		mockHealthService := new(mocks.MockHealthService)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+		setup(mockHealthService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockHealthService.AssertNotCalled(t, "Ready", mock.Anything)
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		mockHealthService := new(mocks.MockHealthService)
+		mockHealthService.On("Ready", mock.Anything).Return(&model.HealthReport{
+			Status: model.HealthOK,
+			Checks: map[string]*model.CheckResult{"postgres": {Status: model.HealthOK, LatencyMS: 1}},
+		})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+		setup(mockHealthService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"status":"ok","checks":{"postgres":"ok"}}`, rr.Body.String())
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Not ready", func(t *testing.T) {
+		mockHealthService := new(mocks.MockHealthService)
+		mockHealthService.On("Ready", mock.Anything).Return(&model.HealthReport{
+			Status: model.HealthFailing,
+			Checks: map[string]*model.CheckResult{"redis": {Status: model.HealthFailing, Error: "connection refused"}},
+		})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+		setup(mockHealthService).ServeHTTP(rr, request)
+
+		// 错误详情不出现在响应中
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{"status":"failing","checks":{"redis":"failing"}}`, rr.Body.String())
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		mockHealthService := new(mocks.MockHealthService)
+		mockHealthService.On("Ready", mock.Anything).Return(&model.HealthReport{Status: model.HealthDraining})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+		setup(mockHealthService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{"status":"draining"}`, rr.Body.String())
+	})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Image 上传用户头像
+func (h *Handler) Image(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	// 限制请求体大小，超出时读取会返回 error
+	maxBodyBytes := h.Limits.MaxBodyBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+
+	imageFileHeader, err := c.FormFile("imageFile")
+	if err != nil {
+		h.log(c).WithError(err).Info("Unable to parse multipart/form-data")
+
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			e := apperrors.NewPayloadTooLarge(maxBodyBytes, c.Request.ContentLength)
+			c.JSON(e.Status(), gin.H{
+				"error": e,
+			})
+			return
+		}
+
+		if err == http.ErrMissingFile {
+			e := apperrors.NewBadRequest("Must include an imageFile")
+			c.JSON(e.Status(), gin.H{
+				"error": e,
+			})
+			return
+		}
+
+		e := apperrors.NewBadRequest("Unable to parse multipart/form-data")
+		c.JSON(e.Status(), gin.H{
+			"error": e,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.SetProfileImage(ctx, authUser.UID, imageFileHeader)
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": authUser.UID}, err, "Failed to set profile image for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imageUrls": u.ImageURLs,
+		"message":   "success",
+	})
+}
+
+// DeleteImage 删除用户头像
+func (h *Handler) DeleteImage(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	ctx := c.Request.Context()
+	if err := h.UserService.ClearProfileImage(ctx, authUser.UID); err != nil {
+		h.logFailure(c, logrus.Fields{"uid": authUser.UID}, err, "Failed to delete profile image for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// multipartImage 创建包含 imageFile 字段的 multipart 请求体
+func multipartImage(t *testing.T, field string, content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile(field, "avatar.png")
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	return body, writer.FormDataContentType()
+}
+
+func TestImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setup := func(us model.UserService, maxBodyBytes int64) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:            router,
+			UserService:  us,
+			MaxBodyBytes: maxBodyBytes,
+		})
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("SetProfileImage", mock.Anything, uid, mock.AnythingOfType("*multipart.FileHeader")).
+			Return(&model.User{UID: uid, ImageURLs: model.NewImageURLs("/media/avatars/" + uid.String() + "/a/512.png")}, nil)
+
+		rr := httptest.NewRecorder()
+		router := setup(mockUserService, 1<<20)
+
+		body, contentType := multipartImage(t, "imageFile", []byte("\x89PNG\r\n\x1a\n"))
+		request, err := http.NewRequest(http.MethodPost, "/image", body)
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", contentType)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"512":"/media/avatars/`+uid.String()+`/a/512.png"`)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+		router := setup(mockUserService, 1024)
+
+		body, contentType := multipartImage(t, "imageFile", bytes.Repeat([]byte("a"), 4096))
+		request, err := http.NewRequest(http.MethodPost, "/image", body)
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", contentType)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		mockUserService.AssertNotCalled(t, "SetProfileImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Body limit changed at runtime", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		limits := NewLimits(time.Second, 1<<20)
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: mockUserService,
+			Limits:      limits,
+		})
+
+		limits.Set(time.Second, 1024)
+
+		rr := httptest.NewRecorder()
+		body, contentType := multipartImage(t, "imageFile", bytes.Repeat([]byte("a"), 4096))
+		request, err := http.NewRequest(http.MethodPost, "/image", body)
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", contentType)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		mockUserService.AssertNotCalled(t, "SetProfileImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Missing image file", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := httptest.NewRecorder()
+		router := setup(mockUserService, 1<<20)
+
+		body, contentType := multipartImage(t, "notImageFile", []byte("\x89PNG\r\n\x1a\n"))
+		request, err := http.NewRequest(http.MethodPost, "/image", body)
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", contentType)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "SetProfileImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unsupported media type", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("SetProfileImage", mock.Anything, uid, mock.Anything).
+			Return(nil, apperrors.NewUnsupportedMediaType("imageFile must be 'image/png', 'image/jpeg' or 'image/webp'"))
+
+		rr := httptest.NewRecorder()
+		router := setup(mockUserService, 1<<20)
+
+		body, contentType := multipartImage(t, "imageFile", []byte("GIF89a"))
+		request, err := http.NewRequest(http.MethodPost, "/image", body)
+		assert.NoError(t, err)
+		request.Header.Set("Content-Type", contentType)
+
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+}
+
+func TestDeleteImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	mockUserService := new(mocks.MockUserService)
+	mockUserService.On("ClearProfileImage", mock.Anything, uid).Return(nil)
+
+	rr := httptest.NewRecorder()
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &model.User{UID: uid})
+	})
+	NewHandler(&Config{
+		R:           router,
+		UserService: mockUserService,
+	})
+
+	request, err := http.NewRequest(http.MethodDelete, "/image", nil)
+	assert.NoError(t, err)
+
+	router.ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUserService.AssertExpectations(t)
+}
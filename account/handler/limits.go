@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Limits 请求超时和请求体大小限制，重新加载配置时可以在运行中替换
+type Limits struct {
+	timeout      int64
+	maxBodyBytes int64
+}
+
+// NewLimits 创建 Limits
+func NewLimits(timeout time.Duration, maxBodyBytes int64) *Limits {
+	l := &Limits{}
+	l.Set(timeout, maxBodyBytes)
+	return l
+}
+
+// Set 替换限制，之后开始的请求使用新值
+func (l *Limits) Set(timeout time.Duration, maxBodyBytes int64) {
+	atomic.StoreInt64(&l.timeout, int64(timeout))
+	atomic.StoreInt64(&l.maxBodyBytes, maxBodyBytes)
+}
+
+// Timeout 请求处理超时时间
+func (l *Limits) Timeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&l.timeout))
+}
+
+// MaxBodyBytes 请求体大小限制
+func (l *Limits) MaxBodyBytes() int64 {
+	return atomic.LoadInt64(&l.maxBodyBytes)
+}
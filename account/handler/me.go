@@ -1,12 +1,12 @@
 package handler
 
 import (
-	"log"
 	"memrizr/model"
 	"memrizr/model/apperrors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // Me 获取用户个人详情
@@ -16,7 +16,7 @@ func (h *Handler) Me(c *gin.Context) {
 	user, exists := c.Get("user")
 
 	if !exists {
-		log.Printf("Unable to exteact user from request context for unkonw reason: %v\n", c)
+		h.log(c).Error("Unable to extract user from request context for unknown reason")
 		err := apperrors.NewInternal()
 		c.JSON(err.Status(), gin.H{
 			"error": err,
@@ -32,14 +32,17 @@ func (h *Handler) Me(c *gin.Context) {
 	ctx := c.Request.Context()
 	u, err := h.UserService.Get(ctx, uid)
 	if err != nil {
-		log.Printf("Unable to find user: %v\n%v", uid, err)
+		h.logFailure(c, logrus.Fields{"uid": uid}, err, "Unable to find user")
 		e := apperrors.NewNotFound("user", uid.String())
 
 		c.JSON(e.Status(), gin.H{
 			"error": e,
 		})
+		return
 	}
 
+	// 修改资料时通过 If-Match 携带
+	c.Header("ETag", userETag(u))
 	c.JSON(http.StatusOK, gin.H{
 		"user": u,
 	})
@@ -34,7 +34,7 @@ func TestMe(t *testing.T) {
 		}
 
 		mockUserService := new(mocks.MockUserService)
-		mockUserService.On("Get", mock.AnythingOfType("*context.emptyCtx"), uid).Return(mockUserResp, nil)
+		mockUserService.On("Get", mock.Anything, uid).Return(mockUserResp, nil)
 
 		// a response recorder for getting written http response
 		rr := httptest.NewRecorder()
@@ -127,7 +127,38 @@ func TestMe(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, respErr.Status(), rr.Code)
-		assert.NotEqual(t, respBody, rr.Body.Bytes())
+		assert.Equal(t, respBody, rr.Body.Bytes())
+		assert.Empty(t, rr.Header().Get("ETag"))
 		mockUserService.AssertExpectations(t) // assert that UserService.Get was called
 	})
 }
+
+func TestMeETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	mockUserService := new(mocks.MockUserService)
+	mockUserService.On("Get", mock.Anything, uid).Return(&model.User{UID: uid, Version: 7}, nil)
+
+	rr := httptest.NewRecorder()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &model.User{UID: uid})
+	})
+
+	NewHandler(&Config{
+		R:           router,
+		UserService: mockUserService,
+	})
+
+	request, err := http.NewRequest(http.MethodGet, "/me", nil)
+	assert.NoError(t, err)
+
+	router.ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"7"`, rr.Header().Get("ETag"))
+	mockUserService.AssertExpectations(t)
+}
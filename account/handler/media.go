@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// 公开对象的 key 每次上传都会变化，可以长期缓存
+const publicMediaMaxAge = 365 * 24 * time.Hour
+
+// Media 读取对象存储中的文件，私有对象需要有效的签名地址
+func (h *Handler) Media(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	expires := c.Query("expires")
+
+	ctx := c.Request.Context()
+	obj, err := h.MediaService.Open(ctx, key, expires, c.Query("signature"))
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"key": key}, err, "Unable to open media")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+	defer obj.Body.Close()
+
+	if model.MediaVisibility(key) == model.VisibilityPublic {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(publicMediaMaxAge.Seconds())))
+	} else {
+		// 私有对象只允许客户端缓存到签名过期
+		exp, _ := strconv.ParseInt(expires, 10, 64)
+		maxAge := exp - time.Now().Unix()
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	}
+	c.Header("Content-Type", obj.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	// 支持 Range 和 If-Modified-Since
+	if rs, ok := obj.Body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", obj.ModTime, rs)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, obj.Body, nil)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// nopSeekCloser 为 strings.Reader 增加 Close 方法
+type nopSeekCloser struct {
+	*strings.Reader
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func TestMedia(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(ms model.MediaService) *gin.Engine {
+		router := gin.Default()
+		NewHandler(&Config{
+			R:            router,
+			MediaService: ms,
+		})
+		return router
+	}
+
+	t.Run("Public object is cached long term", func(t *testing.T) {
+		obj := &model.Object{
+			Body:        nopSeekCloser{strings.NewReader("jpegdata")},
+			ContentType: "image/jpeg",
+			Size:        8,
+			ModTime:     time.Now(),
+		}
+
+		mockMediaService := new(mocks.MockMediaService)
+		mockMediaService.On("Open", mock.Anything, "public/avatars/u/s/64.jpg", "", "").Return(obj, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/media/public/avatars/u/s/64.jpg", nil)
+		setup(mockMediaService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "jpegdata", rr.Body.String())
+		assert.Equal(t, "image/jpeg", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Cache-Control"), "public")
+		assert.Contains(t, rr.Header().Get("Cache-Control"), "immutable")
+		mockMediaService.AssertExpectations(t)
+	})
+
+	t.Run("Private object is cached until expiry", func(t *testing.T) {
+		expires := time.Now().Add(time.Minute).Unix()
+		obj := &model.Object{
+			Body:        nopSeekCloser{strings.NewReader("pdfdata")},
+			ContentType: "application/pdf",
+			Size:        7,
+			ModTime:     time.Now(),
+		}
+
+		mockMediaService := new(mocks.MockMediaService)
+		mockMediaService.On("Open", mock.Anything, "private/cards/c1.pdf", mock.Anything, "sig").Return(obj, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/media/private/cards/c1.pdf?signature=sig&expires="+strconv.FormatInt(expires, 10), nil)
+		setup(mockMediaService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, strings.HasPrefix(rr.Header().Get("Cache-Control"), "private, max-age="))
+		mockMediaService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		mockMediaService := new(mocks.MockMediaService)
+		mockMediaService.On("Open", mock.Anything, "private/cards/c1.pdf", "1", "bad").Return(nil, apperrors.NewForbidden("Invalid signature"))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/media/private/cards/c1.pdf?expires=1&signature=bad", nil)
+		setup(mockMediaService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockMediaService.AssertExpectations(t)
+	})
+}
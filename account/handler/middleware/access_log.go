@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AccessLog 替代 gin 默认的请求日志，每个请求记录一行结构化日志
+// 只记录路径不记录 query，签名地址和确认链接的参数不会写入日志
+func AccessLog(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := logger.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+			"method":    c.Request.Method,
+			"path":      c.Request.URL.Path,
+			"route":     c.FullPath(),
+			"status":    status,
+			"latency":   time.Since(start),
+			"client_ip": c.ClientIP(),
+			"bytes":     c.Writer.Size(),
+		})
+
+		switch {
+		case status >= 500:
+			entry.Error("Request completed")
+		case status >= 400:
+			entry.Warn("Request completed")
+		default:
+			entry.Info("Request completed")
+		}
+	}
+}
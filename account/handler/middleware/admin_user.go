@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUser 校验当前认证用户是否为管理员
+// 需要在 AuthUser 之后使用
+func AdminUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists || !user.(*model.User).IsAdmin() {
+			err := apperrors.NewForbidden("Admin privileges required")
+			c.JSON(err.Status(), gin.H{
+				"error": err,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
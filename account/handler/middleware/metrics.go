@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"memrizr/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按 gin 路由模板统计请求数和耗时，未匹配的路由统一记为 unmatched
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.HTTPRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		m.HTTPDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 替代 gin.Recovery，panic 时上报并返回内部错误
+// Timeout 中间件之后的 panic 由 Timeout 自己处理
+func Recovery(reporter model.ErrorReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if p := recover(); p != nil {
+				Report(reporter, c, panicReport(p))
+
+				e := apperrors.NewInternal()
+				c.AbortWithStatusJSON(e.Status(), gin.H{
+					"error": e,
+				})
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// panicReport 需要在 recover 所在的 defer 函数中调用，调用栈从 panic 的位置开始
+func panicReport(p interface{}) *model.ErrorReport {
+	err, _ := p.(error)
+	// 跳过 panicReport、defer 函数和 runtime.gopanic
+	r := model.NewErrorReport(3, fmt.Sprint(p), err)
+	r.Panic = true
+	return r
+}
+
+// Report 补充请求信息和已认证用户后上报，reporter 为空时忽略
+func Report(reporter model.ErrorReporter, c *gin.Context, r *model.ErrorReport) {
+	if reporter == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	r.Request = &model.ReportRequest{
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Route:     c.FullPath(),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: logging.RequestID(ctx),
+	}
+
+	if user, ok := c.Get("user"); ok {
+		if u, ok := user.(*model.User); ok {
+			r.User = &model.ReportUser{UID: u.UID, Role: u.Role}
+		}
+	}
+
+	reporter.Report(ctx, r)
+}
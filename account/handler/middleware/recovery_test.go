@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReporter 记录收到的报告
+type fakeReporter struct {
+	mu      sync.Mutex
+	reports []*model.ErrorReport
+}
+
+func (f *fakeReporter) Report(ctx context.Context, r *model.ErrorReport) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reports = append(f.reports, r)
+}
+
+// only 返回唯一的一份报告
+func (f *fakeReporter) only(t *testing.T) *model.ErrorReport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.Len(t, f.reports, 1)
+	return f.reports[0]
+}
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Reports panic with stack, request and user", func(t *testing.T) {
+		reporter := &fakeReporter{}
+		uid, _ := uuid.NewRandom()
+
+		router := gin.New()
+		router.Use(RequestID())
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid, Role: model.RoleAdmin})
+		})
+		router.Use(Recovery(reporter))
+		router.GET("/boom/:id", func(c *gin.Context) {
+			panic("boom")
+		})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/boom/1?token=secret", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+		r := reporter.only(t)
+		assert.True(t, r.Panic)
+		assert.Equal(t, "boom", r.Message)
+
+		// 第一帧为 panic 的位置
+		require.NotEmpty(t, r.Stack)
+		assert.Contains(t, r.Stack[0].Function, "TestRecovery")
+
+		require.NotNil(t, r.Request)
+		assert.Equal(t, http.MethodGet, r.Request.Method)
+		assert.Equal(t, "/boom/1", r.Request.Path)
+		assert.Equal(t, "/boom/:id", r.Request.Route)
+		assert.Equal(t, rr.Header().Get(RequestIDHeader), r.Request.RequestID)
+		require.NotNil(t, r.User)
+		assert.Equal(t, uid, r.User.UID)
+		assert.Equal(t, model.RoleAdmin, r.User.Role)
+	})
+
+	t.Run("Nil reporter", func(t *testing.T) {
+		router := gin.New()
+		router.Use(Recovery(nil))
+		router.GET("/boom", func(c *gin.Context) {
+			panic("boom")
+		})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/boom", nil)
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reporter := &fakeReporter{}
+
+	router := gin.New()
+	router.Use(Recovery(reporter))
+	router.Use(TimeoutFunc(func() time.Duration { return time.Second }, apperrors.NewServiceUnavailable(), nil, reporter))
+	router.GET("/boom", func(c *gin.Context) {
+		panic("boom in handler goroutine")
+	})
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/boom", nil)
+	router.ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	// 只由 Timeout 上报一次，调用栈来自处理程序所在的 goroutine
+	r := reporter.only(t)
+	assert.True(t, r.Panic)
+	assert.Equal(t, "boom in handler goroutine", r.Message)
+	require.NotEmpty(t, r.Stack)
+	assert.Contains(t, r.Stack[0].Function, "TestTimeoutPanic")
+	assert.Equal(t, "/boom", r.Request.Route)
+}
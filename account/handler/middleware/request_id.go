@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"memrizr/logging"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID 只接受较短的、不含空白和特殊字符的客户端请求 ID，避免伪造日志内容
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 使用客户端传入的请求 ID，没有或者不合法时生成新的 ID
+// ID 写入响应头并放入请求上下文，通过上下文记录的日志都会带上它
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"memrizr/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMeta 将客户端 IP 和 User-Agent 写入请求上下文，供服务层记录审计日志
+func RequestMeta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := model.WithRequestMeta(c.Request.Context(), &model.RequestMeta{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"memrizr/model"
 	"memrizr/model/apperrors"
 	"net/http"
 	"sync"
@@ -14,25 +15,32 @@ import (
 )
 
 func Timeout(timeout time.Duration, errTimeout *apperrors.Error) gin.HandlerFunc {
+	return TimeoutFunc(func() time.Duration { return timeout }, errTimeout, nil, nil)
+}
+
+// TimeoutFunc 每个请求开始时调用 timeout 获取超时时间，用于运行时修改超时设置
+// onTimeout 不为空时在每次超时后调用，reporter 不为空时上报处理程序中的 panic
+func TimeoutFunc(timeout func() time.Duration, errTimeout *apperrors.Error, onTimeout func(), reporter model.ErrorReporter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 设置gin writer 为 自定义的writer
 		tw := &timeoutWriter{ResponseWriter: c.Writer, h: make(http.Header)}
 		c.Writer = tw
 
 		// 包装带超时的上下文
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout())
 		defer cancel()
 
 		// 更新 gin 请求上下文
 		c.Request = c.Request.WithContext(ctx)
 
-		finished := make(chan struct{})        // 表示处理程序已完成
-		panicChan := make(chan interface{}, 1) // 用于在我们无法恢复的情况下处理恐慌
+		finished := make(chan struct{})               // 表示处理程序已完成
+		panicChan := make(chan *model.ErrorReport, 1) // 用于在我们无法恢复的情况下处理恐慌
 
 		go func() {
 			defer func() {
 				if p := recover(); p != nil {
-					panicChan <- p
+					// 调用栈只能在 panic 的 goroutine 中获取
+					panicChan <- panicReport(p)
 				}
 			}()
 
@@ -41,8 +49,10 @@ func Timeout(timeout time.Duration, errTimeout *apperrors.Error) gin.HandlerFunc
 		}()
 
 		select {
-		case <-panicChan:
+		case r := <-panicChan:
 			// 如果我们无法从恐慌中恢复，请发送内部服务器错误
+			Report(reporter, c, r)
+
 			e := apperrors.NewInternal()
 			tw.ResponseWriter.WriteHeader(e.Status())
 			eResp, _ := json.Marshal(gin.H{
@@ -74,6 +84,10 @@ func Timeout(timeout time.Duration, errTimeout *apperrors.Error) gin.HandlerFunc
 			tw.ResponseWriter.Write(eResp)
 			c.Abort()
 			tw.SetTimedOut()
+
+			if onTimeout != nil {
+				onTimeout()
+			}
 		}
 	}
 }
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// privacyReq 修改公开资料可见性请求，所有字段必填
+type privacyReq struct {
+	Public      *bool `json:"public" binding:"required"`
+	ShowWebsite *bool `json:"showWebsite" binding:"required"`
+	ShowAvatar  *bool `json:"showAvatar" binding:"required"`
+}
+
+// PublicProfile 获取用户的公开资料，不需要登录
+// id 可以是 uid 或 handle，旧 handle 重定向到当前 handle
+func (h *Handler) PublicProfile(c *gin.Context) {
+	id := c.Param("id")
+
+	var p *model.PublicProfile
+	var err error
+
+	ctx := c.Request.Context()
+	if uid, parseErr := uuid.Parse(id); parseErr == nil {
+		p, err = h.UserService.GetPublicProfile(ctx, uid)
+	} else {
+		p, err = h.UserService.GetPublicProfileByHandle(ctx, id)
+		if err == nil && !strings.EqualFold(strings.TrimPrefix(id, "@"), p.Handle) {
+			// 相对地址，保持在同一路由组下，handle 已被清除时重定向到 uid
+			location := p.Handle
+			if location == "" {
+				location = p.UID.String()
+			}
+			c.Redirect(http.StatusFound, location)
+			return
+		}
+	}
+
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"id": id}, err, "Unable to get public profile")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile": p,
+	})
+}
+
+// UpdatePrivacy 修改当前用户公开资料的可见性
+func (h *Handler) UpdatePrivacy(c *gin.Context) {
+	authUser := c.MustGet("user").(*model.User)
+
+	var req privacyReq
+	if ok := h.bindData(c, &req); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.UserService.UpdatePrivacy(ctx, authUser.UID, &model.ProfilePrivacy{
+		Public:      *req.Public,
+		ShowWebsite: *req.ShowWebsite,
+		ShowAvatar:  *req.ShowAvatar,
+	})
+	if err != nil {
+		h.logFailure(c, logrus.Fields{"uid": authUser.UID}, err, "Failed to update privacy for user")
+
+		c.JSON(apperrors.Status(err), gin.H{
+			"error": err,
+		})
+		return
+	}
+
+	c.Header("ETag", userETag(u))
+	c.JSON(http.StatusOK, gin.H{
+		"privacy": u.Privacy(),
+	})
+}
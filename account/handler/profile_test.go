@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPublicProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(us model.UserService) *gin.Engine {
+		router := gin.Default()
+		NewHandler(&Config{
+			R:           router,
+			UserService: us,
+		})
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("GetPublicProfile", mock.Anything, uid).Return(&model.PublicProfile{UID: uid, Name: "Bobby"}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/users/"+uid.String(), nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"name":"Bobby"`)
+		assert.NotContains(t, rr.Body.String(), "email")
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Not public", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("GetPublicProfile", mock.Anything, uid).Return(nil, apperrors.NewNotFound("user", uid.String()))
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/users/"+uid.String(), nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("By handle", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("GetPublicProfileByHandle", mock.Anything, "@Bobby").
+			Return(&model.PublicProfile{UID: uid, Handle: "bobby", Name: "Bobby"}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/users/@Bobby", nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"handle":"bobby"`)
+		mockUserService.AssertNotCalled(t, "GetPublicProfile", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Old handle redirects", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("GetPublicProfileByHandle", mock.Anything, "bob").
+			Return(&model.PublicProfile{UID: uid, Handle: "bobby"}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/users/bob", nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "/users/bobby", rr.Header().Get("Location"))
+	})
+
+	t.Run("Old handle redirects to uid without current handle", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("GetPublicProfileByHandle", mock.Anything, "bob").
+			Return(&model.PublicProfile{UID: uid}, nil)
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/users/bob", nil)
+		setup(mockUserService).ServeHTTP(rr, request)
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "/users/"+uid.String(), rr.Header().Get("Location"))
+	})
+}
+
+func TestUpdatePrivacy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uid, _ := uuid.NewRandom()
+
+	setup := func(us model.UserService) *gin.Engine {
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("user", &model.User{UID: uid})
+		})
+		NewHandler(&Config{
+			R:           router,
+			UserService: us,
+		})
+		return router
+	}
+
+	put := func(router *gin.Engine, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodPut, "/me/privacy", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, request)
+		return rr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		p := &model.ProfilePrivacy{Public: true, ShowWebsite: false, ShowAvatar: true}
+
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("UpdatePrivacy", mock.Anything, uid, p).
+			Return(&model.User{UID: uid, ProfilePublic: true, ShowAvatar: true}, nil)
+
+		rr := put(setup(mockUserService), `{"public":true,"showWebsite":false,"showAvatar":true}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"showWebsite":false`)
+		mockUserService.AssertExpectations(t)
+	})
+
+	t.Run("Missing field", func(t *testing.T) {
+		mockUserService := new(mocks.MockUserService)
+
+		rr := put(setup(mockUserService), `{"public":true}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockUserService.AssertNotCalled(t, "UpdatePrivacy", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
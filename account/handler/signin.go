@@ -1,17 +1,18 @@
 package handler
 
 import (
-	"log"
 	"memrizr/model"
 	"memrizr/model/apperrors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
-// signinReq 登录请求结构体
+// signinReq 登录请求结构体，email 和 handle 二选一
 type signinReq struct {
-	Email    string `json:"email" binding:"required,email"`
+	Email    string `json:"email" binding:"required_without=Handle,omitempty,email"`
+	Handle   string `json:"handle" binding:"required_without=Email,omitempty,max=31"`
 	Password string `json:"password" binding:"required,gte=6,lte=30"`
 }
 
@@ -20,19 +21,20 @@ func (h *Handler) Signin(c *gin.Context) {
 	var req signinReq
 
 	// 检验请求数据
-	if ok := bindData(c, &req); !ok {
+	if ok := h.bindData(c, &req); !ok {
 		return
 	}
 
 	u := &model.User{
 		Email:    req.Email,
+		Handle:   req.Handle,
 		Password: req.Password,
 	}
 
 	ctx := c.Request.Context()
 	err := h.UserService.Signin(ctx, u)
 	if err != nil {
-		log.Printf("Failed to sign in user: %v\n", err.Error())
+		h.logFailure(c, nil, err, "Failed to sign in user")
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
 		})
@@ -42,7 +44,7 @@ func (h *Handler) Signin(c *gin.Context) {
 	// 创建 token pair 并转化为字符串
 	tokens, err := h.TokenService.NewTokenPairFromUser(ctx, u, "")
 	if err != nil {
-		log.Printf("Failed to create tokens for user: %v\n", err.Error())
+		h.logFailure(c, logrus.Fields{"uid": u.UID}, err, "Failed to create tokens for user")
 
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
@@ -58,7 +58,7 @@ func TestSignin(t *testing.T) {
 		password := "pwdoesnotmatch123"
 
 		mockUSArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
+			mock.Anything,
 			&model.User{Email: email, Password: password},
 		}
 
@@ -93,14 +93,14 @@ func TestSignin(t *testing.T) {
 		password := "pwworksgreat123"
 
 		mockUSArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
+			mock.Anything,
 			&model.User{Email: email, Password: password},
 		}
 
 		mockUserService.On("Signin", mockUSArgs...).Return(nil)
 
 		mockTSArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
+			mock.Anything,
 			&model.User{Email: email, Password: password},
 			"",
 		}
@@ -145,14 +145,14 @@ func TestSignin(t *testing.T) {
 		password := "cannotproducetoken"
 
 		mockUSArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
+			mock.Anything,
 			&model.User{Email: email, Password: password},
 		}
 
 		mockUserService.On("Signin", mockUSArgs...).Return(nil)
 
 		mockTSArgs := mock.Arguments{
-			mock.AnythingOfType("*context.emptyCtx"),
+			mock.Anything,
 			&model.User{Email: email, Password: password},
 			"",
 		}
@@ -1,12 +1,12 @@
 package handler
 
 import (
-	"log"
 	"memrizr/model"
 	"memrizr/model/apperrors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // signupReq 注册请求结构体
@@ -20,7 +20,7 @@ func (h *Handler) Signup(c *gin.Context) {
 	var req signupReq
 
 	// 检验请求数据
-	if ok := bindData(c, &req); !ok {
+	if ok := h.bindData(c, &req); !ok {
 		return
 	}
 
@@ -33,7 +33,7 @@ func (h *Handler) Signup(c *gin.Context) {
 	err := h.UserService.Signup(ctx, u)
 
 	if err != nil {
-		log.Printf("Faild to sign up user: %v\n", err.Error())
+		h.logFailure(c, nil, err, "Failed to sign up user")
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
 		})
@@ -44,7 +44,7 @@ func (h *Handler) Signup(c *gin.Context) {
 	tokens, err := h.TokenService.NewTokenPairFromUser(ctx, u, "")
 
 	if err != nil {
-		log.Printf("Faild to create tokens for user: %v\n", err.Error())
+		h.logFailure(c, logrus.Fields{"uid": u.UID}, err, "Failed to create tokens for user")
 
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
@@ -22,7 +22,7 @@ func TestSignup(t *testing.T) {
 	// 测试没有邮箱和密码
 	t.Run("Email and Password Required", func(t *testing.T) {
 		mockUserService := new(mocks.MockUserService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("*model.User")).Return(nil)
+		mockUserService.On("Signup", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -56,7 +56,7 @@ func TestSignup(t *testing.T) {
 	// 无效邮箱测试用例
 	t.Run("Invalid Email", func(t *testing.T) {
 		mockUserService := new(mocks.MockUserService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("*model.User")).Return(nil)
+		mockUserService.On("Signup", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -91,7 +91,7 @@ func TestSignup(t *testing.T) {
 	// 密码太短测试用例
 	t.Run("Password too short", func(t *testing.T) {
 		mockUserService := new(mocks.MockUserService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("*model.User")).Return(nil)
+		mockUserService.On("Signup", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -126,7 +126,7 @@ func TestSignup(t *testing.T) {
 	// 密码太长测试用例
 	t.Run("Password too long", func(t *testing.T) {
 		mockUserService := new(mocks.MockUserService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("*model.User")).Return(nil)
+		mockUserService.On("Signup", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -166,7 +166,7 @@ func TestSignup(t *testing.T) {
 		}
 
 		mockUserService := new(mocks.MockUserService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), u).Return(apperrors.NewConflict("User Already Exists", u.Email))
+		mockUserService.On("Signup", mock.Anything, u).Return(apperrors.NewConflict("User Already Exists", u.Email))
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -212,8 +212,8 @@ func TestSignup(t *testing.T) {
 
 		mockUserService := new(mocks.MockUserService)
 		mockTokenService := new(mocks.MockTokenService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), u).Return(nil)
-		mockTokenService.On("NewTokenPairFromUser", mock.AnythingOfType("*context.emptyCtx"), u, "").Return(mockTokenResp, nil)
+		mockUserService.On("Signup", mock.Anything, u).Return(nil)
+		mockTokenService.On("NewTokenPairFromUser", mock.Anything, u, "").Return(mockTokenResp, nil)
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -265,8 +265,8 @@ func TestSignup(t *testing.T) {
 
 		mockUserService := new(mocks.MockUserService)
 		mockTokenService := new(mocks.MockTokenService)
-		mockUserService.On("Signup", mock.AnythingOfType("*context.emptyCtx"), u).Return(nil)
-		mockTokenService.On("NewTokenPairFromUser", mock.AnythingOfType("*context.emptyCtx"), u, "").Return(nil, mockErrorResponse)
+		mockUserService.On("Signup", mock.Anything, u).Return(nil)
+		mockTokenService.On("NewTokenPairFromUser", mock.Anything, u, "").Return(nil, mockErrorResponse)
 
 		// ResponseRecorder 获取 http 响应
 		rr := httptest.NewRecorder()
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"log"
 	"memrizr/model/apperrors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 type tokensReq struct {
@@ -17,7 +17,7 @@ func (h *Handler) Tokens(c *gin.Context) {
 	var req tokensReq
 
 	// 检验请求数据
-	if ok := bindData(c, &req); !ok {
+	if ok := h.bindData(c, &req); !ok {
 		return
 	}
 
@@ -45,7 +45,7 @@ func (h *Handler) Tokens(c *gin.Context) {
 	// 创建 fresh 的token对
 	tokens, err := h.TokenService.NewTokenPairFromUser(ctx, u, refreshToken.ID.String())
 	if err != nil {
-		log.Printf("Failed to create tokens for user: %+v. Error: %v\n", u, err.Error())
+		h.logFailure(c, logrus.Fields{"uid": u.UID}, err, "Failed to create tokens for user")
 
 		c.JSON(apperrors.Status(err), gin.H{
 			"error": err,
@@ -1,102 +1,263 @@
 package main
 
 import (
-	"fmt"
-	"io/ioutil"
-	"log"
+	"context"
+	"memrizr/config"
 	"memrizr/handler"
+	"memrizr/handler/middleware"
+	"memrizr/metrics"
+	"memrizr/model"
 	"memrizr/repository"
 	"memrizr/service"
+	"memrizr/tracing"
 	"os"
-	"strconv"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
 )
 
+// outbox 每次发布的事件数量
+const outboxBatchSize = 100
+
+// webhook 投递设置
+const (
+	webhookBatchSize    = 20
+	webhookPollInterval = 2 * time.Second
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+)
+
+// worker 随服务启动的后台任务，ctx 取消时退出
+type worker interface {
+	Run(ctx context.Context)
+}
+
+// app 注入完成的服务
+type app struct {
+	router   *gin.Engine
+	metrics  *metrics.Metrics
+	workers  []worker
+	reloader *reloader
+	health   model.HealthService
+
+	// 命令行子命令使用
+	userService  model.UserService
+	tokenService model.TokenService
+}
+
 // 初始化 处理器
 // 注入存储层
 // 注入服务层
 // 注入处理层
-func inject(d *dataSources) (*gin.Engine, error) {
-	log.Println("Injecting data sources")
+func inject(d *dataSources, cfg *config.Config, logger *logrus.Logger) (*app, error) {
+	logger.Info("Injecting data sources")
+
+	// 指标
+	m := metrics.New()
+	m.RegisterDB(d.DB, "postgres")
+	m.RegisterRedis(d.RedisClient)
+
+	// 链路追踪，TRACING_EXPORTER=none 时为 noop
+	tp := otel.GetTracerProvider()
 
 	// 存储层
-	userRepository := repository.NewUserRepository(d.DB)
-	tokenRepository := repository.NewTokenRepository(d.RedisClient)
-	// 服务层
-	userService := service.NewUserService(&service.USConfig{
-		UserRepository: userRepository,
-	})
-	// 加载 rsa keys
-	privKeyFile := os.Getenv("PRIV_KEY_FILE")
-	priv, err := ioutil.ReadFile(privKeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("could not read private key pem file: %W\n", err)
-	}
+	userRepository := tracing.NewUserRepository(repository.NewUserRepository(d.DB), tp)
+	tokenRepository := tracing.NewTokenRepository(repository.NewTokenRepository(d.RedisClient), tp)
+	auditRepository := repository.NewAuditRepository(d.DB)
+	eventRepository := repository.NewEventRepository(d.DB)
+	webhookRepository := repository.NewWebhookRepository(d.DB)
+	emailChangeRepository := repository.NewEmailChangeRepository(d.DB)
+	handleRedirectRepository := repository.NewHandleRedirectRepository(d.DB)
+	transactor := repository.NewTransactor(d.DB)
 
-	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(priv)
-	if err != nil {
-		return nil, fmt.Errorf("could not read private key: %W\n", err)
-	}
+	baseURL := cfg.BaseURL
 
-	pubKeyFile := os.Getenv("PUB_KEY_FILE")
-	pub, err := ioutil.ReadFile(pubKeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("could not read private key pem file: %W\n", err)
+	// 对象存储，后端在加载配置时已校验
+	var storage model.ObjectStorage
+	switch cfg.Storage.Backend {
+	case config.StorageLocal:
+		storage = repository.NewLocalStorage(cfg.Storage.Dir)
+	case config.StorageS3:
+		storage = repository.NewS3Storage(d.S3Client, cfg.S3.Bucket, cfg.S3.Prefix)
 	}
 
-	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(pub)
-	if err != nil {
-		return nil, fmt.Errorf("could not read private key: %W\n", err)
+	// 私有对象通过 ACCOUNT_API_URL/media 的签名地址访问，公开对象默认也是
+	mediaURL := baseURL + "/media"
+	storagePublicURL := cfg.Storage.PublicURL
+	if storagePublicURL == "" {
+		storagePublicURL = mediaURL
 	}
 
-	// 从 env 中加载 refresh token secret
-	refreshSecret := os.Getenv("REFRESH_SECRET")
+	urlSigner := repository.NewURLSigner(cfg.Storage.MediaURLSecret, storagePublicURL, mediaURL)
+	imageRepository := repository.NewImageRepository(storage, urlSigner)
 
-	// 从 env 中获取 过期时间设置
-	idTokenExp := os.Getenv("ID_TOKEN_EXP")
-	refreshTokenExp := os.Getenv("REFRESH_TOKEN_EXP")
+	// 领域事件发布
+	var eventBroker model.EventBroker
+	switch cfg.Events.Broker {
+	case config.BrokerRedis:
+		eventBroker = repository.NewRedisEventBroker(d.RedisClient, cfg.Events.Stream, 0)
+	case config.BrokerLog:
+		eventBroker = repository.NewLogEventBroker()
+	}
 
-	idExp, err := strconv.ParseInt(idTokenExp, 0, 64)
-	if err != nil {
-		return nil, fmt.Errorf("could not parse ID_TOKEN_EXP as int: %w", err)
+	// 事件同时转换为 webhook 投递记录
+	// 投递记录在 relay 的事务中创建并且按 (webhook, event) 幂等，放在前面
+	// broker 不可用时投递记录照常提交，事件重试时不会重复创建
+	eventBroker = service.NewMultiBroker(service.NewWebhookBroker(webhookRepository), eventBroker)
+
+	// 邮件发送
+	var mailer model.Mailer
+	switch cfg.Mail.Backend {
+	case config.MailSMTP:
+		mailer = repository.NewSMTPMailer(cfg.Mail.SMTPAddr, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	case config.MailLog:
+		mailer = repository.NewLogMailer(os.Stderr)
 	}
 
-	refreshExp, err := strconv.ParseInt(refreshTokenExp, 0, 64)
-	if err != nil {
-		return nil, fmt.Errorf("could not parse REFRESH_TOKEN_EXP as int: %w", err)
+	// 错误上报，sentry 的报告由后台任务发送
+	var errorReporter model.ErrorReporter
+	var workers []worker
+	switch cfg.Errors.Reporter {
+	case config.ReporterLog:
+		errorReporter = repository.NewLogErrorReporter()
+	case config.ReporterSentry:
+		sentry, err := repository.NewSentryReporter(cfg.Errors.SentryDSN, cfg.Errors.SentryEnvironment, cfg.Errors.SentryTimeout)
+		if err != nil {
+			return nil, err
+		}
+		errorReporter = sentry
+		workers = append(workers, sentry)
 	}
 
-	tokenService := service.NewTokenService(&service.TSConfig{
-		TokenRepository:       tokenRepository,
-		PrivateKey:            privKey,
-		PublicKey:             pubKey,
-		RefreshSecret:         refreshSecret,
-		IDExpirationSecs:      idExp,
-		RefreshExpirationSecs: refreshExp,
+	// 服务层
+	healthService := service.NewHealthService(&service.HSConfig{
+		Checks:  []model.HealthCheck{repository.NewPGHealthCheck(d.DB), repository.NewRedisHealthCheck(d.RedisClient)},
+		Timeout: cfg.HTTP.ReadyTimeout,
 	})
+	avatarService := service.NewAvatarService(&service.AVSConfig{
+		BaseURL: baseURL + "/avatars/default",
+		Logger:  logger,
+	})
+	// 注册、登录和刷新 token 的指标根据审计事件统计
+	auditService := metrics.NewAuditService(service.NewAuditService(&service.ASConfig{
+		AuditRepository: auditRepository,
+		Logger:          logger,
+	}), m)
+	userService := tracing.NewUserService(service.NewUserService(&service.USConfig{
+		UserRepository:  userRepository,
+		TokenRepository: tokenRepository,
+		EventRepository: eventRepository,
+		Transactor:      transactor,
+		AuditService:    auditService,
+		ImageRepository: imageRepository,
+		AvatarService:   avatarService,
 
-	// 路由器
-	router := gin.Default()
+		EmailChangeRepository: emailChangeRepository,
+		Mailer:                mailer,
+		EmailConfirmURL:       cfg.Users.EmailConfirmURL,
+		EmailChangeTTL:        cfg.Users.EmailChangeTTL,
+		EmailProviderRules:    cfg.Users.EmailProviderRules,
 
-	baseURL := os.Getenv("ACCOUNT_API_URL")
+		HandleRedirectRepository: handleRedirectRepository,
+		HandleRedirectTTL:        cfg.Users.HandleRedirectTTL,
 
-	// 读取超时设置的时间
-	handlerTimeout := os.Getenv("HANDLER_TIMEOUT")
-	ht, err := strconv.ParseInt(handlerTimeout, 0, 64)
+		Logger: logger,
+	}), tp)
+	eventRelay := service.NewEventRelay(&service.ERConfig{
+		Transactor:      transactor,
+		EventRepository: eventRepository,
+		Broker:          eventBroker,
+		PollInterval:    cfg.Events.PollInterval,
+		BatchSize:       outboxBatchSize,
+		MaxAttempts:     cfg.Events.MaxAttempts,
+		Logger:          logger,
+	})
+	mediaService := service.NewMediaService(&service.MSConfig{
+		Storage: storage,
+		Signer:  urlSigner,
+	})
+	webhookService := service.NewWebhookService(&service.WSConfig{
+		WebhookRepository:   webhookRepository,
+		AllowPrivateTargets: cfg.Webhooks.AllowPrivateTargets,
+		Logger:              logger,
+	})
+	webhookDispatcher := service.NewWebhookDispatcher(&service.WDConfig{
+		WebhookRepository: webhookRepository,
+		Client:            service.NewSSRFSafeClient(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateTargets),
+		PollInterval:      webhookPollInterval,
+		BatchSize:         webhookBatchSize,
+		MaxAttempts:       cfg.Webhooks.MaxAttempts,
+		BaseBackoff:       webhookBaseBackoff,
+		MaxBackoff:        webhookMaxBackoff,
+		Logger:            logger,
+	})
+	// 加载 rsa keys
+	keys, err := loadTokenKeys(cfg.Tokens)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse HANDLER_TIMEOUT as int: %w", err)
+		return nil, err
+	}
+
+	tokenService := tracing.NewTokenService(service.NewTokenService(&service.TSConfig{
+		TokenRepository:       tokenRepository,
+		PrivateKey:            keys.PrivateKey,
+		PublicKey:             keys.PublicKey,
+		RefreshSecret:         keys.RefreshSecret,
+		IDExpirationSecs:      keys.IDExpirationSecs,
+		RefreshExpirationSecs: keys.RefreshExpirationSecs,
+		EventRepository:       eventRepository,
+		AuditService:          auditService,
+		Logger:                logger,
+	}), tp)
+
+	// 路由器，请求日志由 AccessLog 记录，请求 ID 需要在其他中间件之前设置
+	router := gin.New()
+	// 默认不信任 X-Forwarded-For，避免客户端伪造日志和审计中的 IP
+	if err := router.SetTrustedProxies(cfg.HTTP.TrustedProxyList()); err != nil {
+		return nil, err
 	}
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AccessLog(logger))
+	router.Use(middleware.Recovery(errorReporter))
+	router.Use(middleware.Tracing(cfg.Tracing.ServiceName, tp, otel.GetTextMapPropagator()))
+	router.Use(middleware.Metrics(m))
+
+	// 请求限制可以在重新加载配置时替换
+	limits := handler.NewLimits(cfg.HTTP.HandlerTimeout, cfg.HTTP.MaxBodyBytes)
 
 	handler.NewHandler(&handler.Config{
-		R:               router,
-		UserService:     userService,
-		TokenService:    tokenService,
-		BaseURL:         baseURL,
-		TimeoutDuration: time.Duration(time.Duration(ht) * time.Second),
+		R:              router,
+		UserService:    userService,
+		TokenService:   tokenService,
+		AuditService:   auditService,
+		WebhookService: webhookService,
+		MediaService:   mediaService,
+		AvatarService:  avatarService,
+		HealthService:  healthService,
+		BaseURL:        baseURL,
+		Limits:         limits,
+		OnTimeout:      m.HTTPTimeouts.Inc,
+		Logger:         logger,
+		ErrorReporter:  errorReporter,
 	})
 
-	return router, nil
+	r := &reloader{
+		cfg:          cfg,
+		tokenService: tokenService,
+		limits:       limits,
+		logger:       logger,
+	}
+	watcher := &configWatcher{reloader: r, interval: cfg.WatchInterval}
+
+	return &app{
+		router:   router,
+		metrics:  m,
+		workers:  append(workers, eventRelay, webhookDispatcher, watcher),
+		reloader: r,
+		health:   healthService,
+
+		userService:  userService,
+		tokenService: tokenService,
+	}, nil
 }
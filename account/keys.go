@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"memrizr/config"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runKeys account keys 子命令，生成 id token 签名使用的 RSA 密钥对
+// 文件名与 PRIV_KEY_FILE 和 PUB_KEY_FILE 的默认值一致，例如 rsa_private_dev.pem
+func runKeys(ctx context.Context, cfg *config.Config, logger *logrus.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return errUsage
+	}
+
+	fs := newFlagSet("keys generate")
+	env := fs.String("env", "dev", "environment suffix of the file names")
+	dir := fs.String("dir", ".", "output directory")
+	bits := fs.Int("bits", 2048, "RSA key size")
+	force := fs.Bool("force", false, "overwrite existing files")
+	if err := parseFlags(fs, args[1:], 0); err != nil {
+		return err
+	}
+	if *bits < 2048 {
+		return fmt.Errorf("%w: BITS must be at least 2048", errUsage)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, *bits)
+	if err != nil {
+		return fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("unable to encode private key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return fmt.Errorf("unable to encode public key: %w", err)
+	}
+
+	privFile := filepath.Join(*dir, fmt.Sprintf("rsa_private_%s.pem", *env))
+	pubFile := filepath.Join(*dir, fmt.Sprintf("rsa_public_%s.pem", *env))
+
+	// 先检查两个文件，避免只写入其中一个
+	if !*force {
+		for _, f := range []string{privFile, pubFile} {
+			if _, err := os.Stat(f); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", f)
+			}
+		}
+	}
+
+	if err := writePEM(privFile, "PRIVATE KEY", privDER, 0600, *force); err != nil {
+		return err
+	}
+	if err := writePEM(pubFile, "PUBLIC KEY", pubDER, 0644, *force); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s and %s\n", privFile, pubFile)
+	return nil
+}
+
+// writePEM 不覆盖已有的文件，除非 force 为 true
+func writePEM(path string, blockType string, der []byte, perm os.FileMode, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, flags, perm)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := pem.Encode(f, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	return f.Close()
+}
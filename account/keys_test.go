@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"memrizr/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysGenerate(t *testing.T) {
+	t.Run("Writes a key pair loadable by the server", func(t *testing.T) {
+		dir := t.TempDir()
+
+		err := runKeys(context.Background(), nil, nil, []string{"generate", "--env", "test", "--dir", dir})
+		require.NoError(t, err)
+
+		privFile := filepath.Join(dir, "rsa_private_test.pem")
+		pubFile := filepath.Join(dir, "rsa_public_test.pem")
+
+		info, err := os.Stat(privFile)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		info, err = os.Stat(pubFile)
+		require.NoError(t, err)
+		// umask 可能去掉部分权限，但不会增加
+		assert.Equal(t, os.FileMode(0), info.Mode().Perm()&^0644)
+		assert.NotZero(t, info.Mode().Perm()&0400)
+
+		// 与启动和重新加载配置时相同的方式读取
+		keys, err := loadTokenKeys(config.TokenConfig{PrivKeyFile: privFile, PubKeyFile: pubFile})
+		require.NoError(t, err)
+		assert.True(t, keys.PrivateKey.PublicKey.Equal(keys.PublicKey))
+		assert.Equal(t, 2048, keys.PrivateKey.N.BitLen())
+	})
+
+	t.Run("Refuses to overwrite without force", func(t *testing.T) {
+		dir := t.TempDir()
+		args := []string{"generate", "--env", "test", "--dir", dir}
+		pubFile := filepath.Join(dir, "rsa_public_test.pem")
+
+		require.NoError(t, runKeys(context.Background(), nil, nil, args))
+		before, err := ioutil.ReadFile(pubFile)
+		require.NoError(t, err)
+
+		err = runKeys(context.Background(), nil, nil, args)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+
+		after, err := ioutil.ReadFile(pubFile)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+
+		require.NoError(t, runKeys(context.Background(), nil, nil, append(args, "--force")))
+		after, err = ioutil.ReadFile(pubFile)
+		require.NoError(t, err)
+		assert.NotEqual(t, before, after)
+	})
+
+	t.Run("Refuses to write only the missing file", func(t *testing.T) {
+		dir := t.TempDir()
+		privFile := filepath.Join(dir, "rsa_private_test.pem")
+		require.NoError(t, ioutil.WriteFile(privFile, []byte("existing"), 0600))
+
+		err := runKeys(context.Background(), nil, nil, []string{"generate", "--env", "test", "--dir", dir})
+		assert.Error(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, "rsa_public_test.pem"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"rotate"},
+			{"generate", "--bits", "1024"},
+			{"generate", "extra"},
+		} {
+			err := runKeys(context.Background(), nil, nil, args)
+			assert.True(t, errors.Is(err, errUsage), args)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+// Package logging 创建结构化日志记录器
+//
+// 通过 logger.WithContext(ctx) 记录的日志会带上 ctx 中的请求 ID 和 trace ID，
+// 所有日志在输出前都会经过脱敏，去掉 token、密码和邮箱地址
+package logging
+
+import (
+	"context"
+	"fmt"
+	"memrizr/config"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 日志字段名
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+)
+
+// defaultLogger 加载配置之前以及没有注入日志记录器时使用
+// 启动时通过 SetDefault 替换为按配置创建的日志记录器
+var defaultLogger = newLogger(logrus.InfoLevel, &logrus.TextFormatter{})
+
+// New 按配置创建日志记录器
+func New(c config.LogConfig) (*logrus.Logger, error) {
+	level, err := logrus.ParseLevel(c.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var formatter logrus.Formatter
+	switch c.Format {
+	case config.LogJSON:
+		formatter = &logrus.JSONFormatter{}
+	case config.LogText:
+		formatter = &logrus.TextFormatter{FullTimestamp: true}
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", c.Format)
+	}
+
+	return newLogger(level, formatter), nil
+}
+
+// Default 没有 Config 结构体的存储层和辅助函数使用的日志记录器
+// 调用 SetDefault 之前为文本格式、info 级别
+func Default() *logrus.Logger {
+	return defaultLogger
+}
+
+// SetDefault 替换 Default，只在启动时、注入之前调用
+func SetDefault(l *logrus.Logger) {
+	defaultLogger = l
+}
+
+// OrDefault l 为空时返回 Default，用于 Config 结构体中可选的 Logger 字段
+func OrDefault(l *logrus.Logger) *logrus.Logger {
+	if l == nil {
+		return defaultLogger
+	}
+	return l
+}
+
+func newLogger(level logrus.Level, formatter logrus.Formatter) *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(os.Stderr)
+	l.SetLevel(level)
+	l.SetFormatter(formatter)
+	// 先添加上下文字段，再统一脱敏
+	l.AddHook(contextHook{})
+	l.AddHook(redactHook{})
+	return l
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 将请求 ID 放入上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 上下文中的请求 ID，没有时为空
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHook 从日志的上下文中读取请求 ID 和 trace ID
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(e *logrus.Entry) error {
+	if e.Context == nil {
+		return nil
+	}
+
+	if id := RequestID(e.Context); id != "" {
+		e.Data[FieldRequestID] = id
+	}
+	if sc := trace.SpanContextFromContext(e.Context); sc.IsValid() {
+		e.Data[FieldTraceID] = sc.TraceID().String()
+	}
+
+	return nil
+}
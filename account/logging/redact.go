@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted 替换敏感值的文本
+const Redacted = "[redacted]"
+
+var (
+	// jwtPattern id token 和 refresh token
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+	// bearerPattern Authorization 头
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+\S+`)
+	// secretPattern key=value、key: value 以及 %+v 输出的结构体字段，例如 Password:xxx、?token=xxx
+	secretPattern = regexp.MustCompile(`(?i)\b(password|passwd|secret|token)(\s*[:=]\s*)("[^"]*"|[^\s,&}\]]+)`)
+	// emailPattern 邮箱地址只保留域名
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}`)
+)
+
+// Redact 去掉文本中的 token、密码和邮箱地址
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	s = secretPattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		return "***" + email[strings.LastIndex(email, "@"):]
+	})
+	return s
+}
+
+// sensitiveKey 字段名本身表示敏感值时整个值都被替换
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") ||
+		strings.Contains(key, "secret") ||
+		key == "token" ||
+		strings.HasSuffix(key, "_token") ||
+		key == "authorization"
+}
+
+// redactHook 对消息和所有字段脱敏
+// 数字、布尔值和时长原样保留，其他类型格式化为字符串后脱敏
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(e *logrus.Entry) error {
+	e.Message = Redact(e.Message)
+
+	for k, v := range e.Data {
+		if sensitiveKey(k) {
+			e.Data[k] = Redacted
+			continue
+		}
+
+		switch v := v.(type) {
+		case nil, bool, int, int64, uint, uint64, float64, time.Duration:
+		case string:
+			e.Data[k] = Redact(v)
+		case error:
+			e.Data[k] = Redact(v.Error())
+		default:
+			e.Data[k] = Redact(fmt.Sprintf("%+v", v))
+		}
+	}
+
+	return nil
+}
@@ -2,64 +2,165 @@ package main
 
 import (
 	"context"
-	"log"
+	"memrizr/config"
+	"memrizr/logging"
+	"memrizr/migrations"
+	"memrizr/tracing"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// 可以在这里插入其他的记录器，以进行结构化或分层的日志记录
-	log.Println("Starting server...")
+	// 带参数时执行子命令，见 commands.go
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	logging.Default().Info("Starting server...")
+
+	// 加载配置，所有问题一次性报告
+	cfg, err := config.Load()
+	if err != nil {
+		logging.Default().WithError(err).Fatal("Unable to load configuration")
+	}
+
+	// 按配置创建日志记录器，没有 Config 结构体的存储层也使用它
+	logger, err := logging.New(cfg.Log)
+	if err != nil {
+		logging.Default().WithError(err).Fatal("Unable to create logger")
+	}
+	logging.SetDefault(logger)
+
+	gin.SetMode(cfg.Mode)
+	if cfg.Mode == gin.DebugMode {
+		logger.Infof("Effective configuration:\n%s", cfg.Redacted())
+	}
+
+	// 链路追踪需要在注入前设置，包装的服务使用全局的 TracerProvider
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.WithError(err).Fatal("Unable to initialize tracing")
+	}
 
 	// 初始化数据库
-	ds, err := initDS()
+	ds, err := initDS(cfg, logger)
 	if err != nil {
-		log.Fatalf("Unable to initialize data sources: %v\n", err)
+		logger.WithError(err).Fatal("Unable to initialize data sources")
+	}
+
+	// 多个副本同时启动时通过 advisory lock 依次执行，后获得锁的副本没有需要执行的迁移
+	if cfg.Postgres.MigrateOnStart {
+		m, err := migrations.NewMigrator(ds.DB)
+		if err != nil {
+			logger.WithError(err).Fatal("Unable to load migrations")
+		}
+
+		applied, err := m.Up(context.Background(), 0)
+		if err != nil {
+			logger.WithError(err).Fatal("Unable to apply migrations")
+		}
+		logger.WithField("applied", applied).Info("Database schema is up to date")
 	}
 
-	// router := gin.Default()
-	router, err := inject(ds)
+	a, err := inject(ds, cfg, logger)
 	if err != nil {
-		log.Fatalf("Failure to inject data sources: %v\n", err)
+		logger.WithError(err).Fatal("Failure to inject data sources")
+	}
+
+	// 启动后台任务
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, w := range a.workers {
+		wg.Add(1)
+		go func(w worker) {
+			defer wg.Done()
+			w.Run(workerCtx)
+		}(w)
 	}
 
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+		Addr:    cfg.HTTP.Addr,
+		Handler: a.router,
 	}
 
 	// 优雅的服务器关闭 - https://github.com/gin-gonic/examples/blob/master/graceful-shutdown/graceful-shutdown/server.go
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to initialize server: %v\n", err)
+			logger.WithError(err).Fatal("Failed to initialize server")
 		}
 	}()
 
-	log.Printf("Listening on port %v\n", srv.Addr)
+	logger.WithField("addr", srv.Addr).Info("Listening")
+
+	// 管理端口只暴露指标，不经过 traefik
+	var adminSrv *http.Server
+	if cfg.HTTP.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", a.metrics.Handler())
+		adminSrv = &http.Server{
+			Addr:    cfg.HTTP.MetricsAddr,
+			Handler: mux,
+		}
+
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.WithError(err).Fatal("Failed to initialize admin server")
+			}
+		}()
+
+		logger.WithField("addr", adminSrv.Addr).Info("Serving metrics")
+	}
 
-	// 等待终止信号的通道
-	quit := make(chan os.Signal)
+	// 等待终止信号的通道，SIGHUP 重新加载配置和密钥
+	quit := make(chan os.Signal, 1)
 
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	// 这将阻塞，直到一个信号被传递到 quit 通道
-	<-quit
+	// 这将阻塞，直到一个终止信号被传递到 quit 通道
+	for sig := <-quit; sig == syscall.SIGHUP; sig = <-quit {
+		logger.Info("Received SIGHUP, reloading configuration")
+		if err := a.reloader.Reload(); err != nil {
+			logger.WithError(err).Error("Reload failed, keeping the current configuration")
+		}
+	}
+
+	// 就绪检查先失败，等待负载均衡撤走流量后再关闭服务器
+	logger.WithField("delay", cfg.HTTP.ShutdownDrainDelay).Info("Draining before shutdown")
+	a.health.Drain()
+	time.Sleep(cfg.HTTP.ShutdownDrainDelay)
 
 	// 上下文用于通知服务器它有5秒钟的时间来完成当前正在处理的请求
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// 关闭数据库
-	if err := ds.Close(); err != nil {
-		log.Fatalf("A problem occurred gracefully shutting down data sources: %v\n", err)
+	// 关闭服务器，等待正在处理的请求完成后再关闭数据库
+	logger.Info("Shutting down server...")
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.WithError(err).Warn("Server forced to shutdown")
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			logger.WithError(err).Warn("Admin server forced to shutdown")
+		}
 	}
 
-	// 关闭服务器
-	log.Println("Shutting down server...")
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v\n", err)
+	// 停止后台任务，在关闭数据库之前等待其退出
+	stopWorkers()
+	wg.Wait()
+
+	// 导出剩余的 span
+	if err := shutdownTracing(ctx); err != nil {
+		logger.WithError(err).Warn("Unable to flush traces")
+	}
+
+	// 关闭数据库
+	if err := ds.Close(); err != nil {
+		logger.WithError(err).Fatal("A problem occurred gracefully shutting down data sources")
 	}
 }
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"memrizr/model"
+)
+
+// auditService 根据审计事件统计注册、登录和刷新 token 的结果
+type auditService struct {
+	model.AuditService
+	m *Metrics
+}
+
+// NewAuditService 包装 AuditService，记录审计事件的同时更新指标
+func NewAuditService(next model.AuditService, m *Metrics) model.AuditService {
+	return &auditService{AuditService: next, m: m}
+}
+
+// Record 实现 AuditService 接口 Record 方法
+func (s *auditService) Record(ctx context.Context, e *model.AuditEvent) {
+	outcome := e.Outcome
+	if outcome == "" {
+		outcome = model.OutcomeSuccess
+	}
+
+	switch e.Action {
+	case model.AuditSignup:
+		s.m.Signups.WithLabelValues(outcome).Inc()
+	case model.AuditSignin:
+		s.m.Signins.WithLabelValues(outcome).Inc()
+	case model.AuditTokenRefresh:
+		s.m.TokenRefreshes.WithLabelValues(outcome).Inc()
+	}
+
+	s.AuditService.Record(ctx, e)
+}
@@ -0,0 +1,105 @@
+// Package metrics Prometheus 指标
+//
+// 所有指标注册在独立的 Registry 上，通过 Handler 在管理端口暴露
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "account"
+
+// Metrics 服务的所有指标
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	// HTTPRequests 按 gin 路由、方法和状态码统计请求数
+	HTTPRequests *prometheus.CounterVec
+	// HTTPDuration 按 gin 路由、方法和状态码统计请求耗时
+	HTTPDuration *prometheus.HistogramVec
+	// HTTPTimeouts 超时中间件返回超时错误的次数
+	HTTPTimeouts prometheus.Counter
+
+	// Signups、Signins、TokenRefreshes 按结果 success 或 failure 统计
+	Signups        *prometheus.CounterVec
+	Signins        *prometheus.CounterVec
+	TokenRefreshes *prometheus.CounterVec
+}
+
+// New 创建并注册所有指标，包括 Go 运行时和进程指标
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		HTTPTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "timeouts_total",
+			Help:      "Requests aborted by the handler timeout.",
+		}),
+		Signups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "signups_total",
+			Help:      "Signups by outcome.",
+		}, []string{"outcome"}),
+		Signins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "signins_total",
+			Help:      "Signins by outcome.",
+		}, []string{"outcome"}),
+		TokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "token_refreshes_total",
+			Help:      "Token refreshes by outcome.",
+		}, []string{"outcome"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPDuration,
+		m.HTTPTimeouts,
+		m.Signups,
+		m.Signins,
+		m.TokenRefreshes,
+	)
+
+	return m
+}
+
+// RegisterDB 注册数据库连接池指标
+func (m *Metrics) RegisterDB(db *sqlx.DB, name string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db.DB, name))
+}
+
+// RegisterRedis 注册 Redis 连接池指标
+func (m *Metrics) RegisterRedis(rdb *redis.Client) {
+	m.Registry.MustRegister(newRedisPoolCollector(rdb))
+}
+
+// Handler 暴露指标的 HTTP handler
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"memrizr/handler/middleware"
+	"memrizr/metrics"
+	"memrizr/model"
+	"memrizr/model/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditService(t *testing.T) {
+	m := metrics.New()
+
+	mockAuditService := new(mocks.MockAuditService)
+	mockAuditService.On("Record", mock.Anything, mock.Anything).Return()
+
+	as := metrics.NewAuditService(mockAuditService, m)
+	as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditSignin})
+	as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditSignin, Outcome: model.OutcomeFailure})
+	as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditSignin, Outcome: model.OutcomeFailure})
+	as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditSignup})
+	as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditTokenRefresh, Outcome: model.OutcomeFailure})
+	as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditSignout})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Signins.WithLabelValues(model.OutcomeSuccess)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Signins.WithLabelValues(model.OutcomeFailure)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Signups.WithLabelValues(model.OutcomeSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.TokenRefreshes.WithLabelValues(model.OutcomeFailure)))
+	mockAuditService.AssertNumberOfCalls(t, "Record", 6)
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := metrics.New()
+	router := gin.New()
+	router.Use(middleware.Metrics(m))
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/nothing"} {
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(rr, request)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/users/:id", http.MethodGet, "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("unmatched", http.MethodGet, "404")))
+
+	rr := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	m.Handler().ServeHTTP(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `account_http_request_duration_seconds_count{method="GET",route="/users/:id",status="404"} 2`)
+	assert.Contains(t, rr.Body.String(), "go_goroutines")
+}
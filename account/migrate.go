@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"memrizr/config"
+	"memrizr/migrations"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runMigrate account migrate 子命令，使用配置中的数据库
+func runMigrate(ctx context.Context, cfg *config.Config, logger *logrus.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	db, err := openDB(cfg.Postgres)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := countArg(args[1:], 0)
+		if err != nil {
+			return err
+		}
+
+		applied, err := m.Up(ctx, n)
+		fmt.Printf("Applied %d migration(s)\n", applied)
+		return err
+
+	case "down":
+		// 默认只回滚一个，避免误删所有表
+		n, err := countArg(args[1:], 1)
+		if err != nil {
+			return err
+		}
+
+		reverted, err := m.Down(ctx, n)
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+		return err
+
+	case "status":
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		s, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(s)
+		return nil
+
+	case "force":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		version, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("%w: VERSION must be a migration version or 0", errUsage)
+		}
+
+		if err := m.Force(ctx, uint(version)); err != nil {
+			return err
+		}
+		logger.WithField("version", version).Warn("Forced schema version")
+		return nil
+	}
+
+	return errUsage
+}
+
+// printMigrationStatus 当前版本和每个迁移是否已执行
+func printMigrationStatus(s *migrations.Status) {
+	fmt.Printf("Version: %d", s.Version)
+	if s.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Printf("\nPending: %d\n\n", len(s.Pending()))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, mig := range s.Migrations {
+		status := "pending"
+		if mig.Version <= s.Version {
+			status = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", mig.Version, mig.Name, status)
+	}
+	w.Flush()
+}
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS users_name_trgm_idx;
+DROP INDEX IF EXISTS users_email_prefix_idx;
+DROP INDEX IF EXISTS users_created_at_uid_idx;
+
+ALTER TABLE users DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+DROP EXTENSION IF EXISTS pg_trgm;
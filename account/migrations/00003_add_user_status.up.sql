@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'suspended', 'disabled'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_expires_at TIMESTAMPTZ;
+-- 记录最后一次修改状态的管理员及时间
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_changed_by uuid;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS users_status_idx ON users (status);
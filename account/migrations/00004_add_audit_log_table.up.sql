@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor_id uuid,
+    target_id uuid,
+    action VARCHAR NOT NULL,
+    outcome VARCHAR NOT NULL,
+    ip VARCHAR NOT NULL DEFAULT '',
+    user_agent VARCHAR NOT NULL DEFAULT '',
+    details JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_id_idx ON audit_log (actor_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_log_target_id_idx ON audit_log (target_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_log_action_idx ON audit_log (action, id DESC);
+CREATE INDEX IF NOT EXISTS audit_log_occurred_at_idx ON audit_log (occurred_at);
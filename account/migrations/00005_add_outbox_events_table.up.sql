@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id uuid PRIMARY KEY,
+    event_type VARCHAR NOT NULL,
+    aggregate_id uuid NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR NOT NULL DEFAULT ''
+);
+
+-- relay 只扫描未发布的事件
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events (occurred_at) WHERE published_at IS NULL;
@@ -0,0 +1,6 @@
+-- 各尺寸头像的地址，image_url 保留最大尺寸
+ALTER TABLE users ADD COLUMN IF NOT EXISTS image_urls JSONB NOT NULL DEFAULT '{}';
+
+UPDATE users
+SET image_urls = jsonb_build_object('64', image_url, '256', image_url, '512', image_url)
+WHERE image_url <> '' AND image_urls = '{}';
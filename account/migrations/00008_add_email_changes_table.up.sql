@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+    uid uuid PRIMARY KEY REFERENCES users (uid) ON DELETE CASCADE,
+    new_email VARCHAR NOT NULL,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
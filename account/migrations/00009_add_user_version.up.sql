@@ -0,0 +1,2 @@
+-- 乐观锁版本号，每次更新用户时加一
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS show_avatar;
+ALTER TABLE users DROP COLUMN IF EXISTS show_website;
+ALTER TABLE users DROP COLUMN IF EXISTS profile_public;
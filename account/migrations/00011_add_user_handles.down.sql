@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS handle_redirects;
+DROP INDEX IF EXISTS users_handle_key;
+ALTER TABLE users DROP COLUMN IF EXISTS handle;
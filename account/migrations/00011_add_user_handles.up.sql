@@ -0,0 +1,15 @@
+-- handle 统一保存为小写，空字符串表示未设置
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR NOT NULL DEFAULT ''
+    CHECK (handle = lower(handle));
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_handle_key ON users (handle) WHERE handle <> '';
+
+-- 改名后旧 handle 在过期前继续指向原用户
+CREATE TABLE IF NOT EXISTS handle_redirects (
+    handle VARCHAR PRIMARY KEY,
+    uid uuid NOT NULL REFERENCES users (uid) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS handle_redirects_uid_idx ON handle_redirects (uid);
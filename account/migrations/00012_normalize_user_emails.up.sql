@@ -0,0 +1,30 @@
+-- 邮箱改为不区分大小写唯一，先检查已有数据中的冲突
+-- 存在冲突时列出所有冲突的邮箱并中止迁移，需要人工合并或修改后重新执行
+DO $$
+DECLARE
+    collision RECORD;
+    total INT := 0;
+BEGIN
+    FOR collision IN
+        SELECT lower(btrim(email)) AS email, array_agg(uid::text || ' <' || email || '>' ORDER BY created_at) AS users
+        FROM users
+        GROUP BY lower(btrim(email))
+        HAVING count(*) > 1
+    LOOP
+        RAISE WARNING 'email collision %: %', collision.email, array_to_string(collision.users, ', ');
+        total := total + 1;
+    END LOOP;
+
+    IF total > 0 THEN
+        RAISE EXCEPTION '% email collision(s) found, resolve them before running this migration', total;
+    END IF;
+END
+$$;
+
+-- 去掉首尾空白，域名转为小写
+UPDATE users
+SET email = substring(btrim(email) FROM '^(.*)@') || '@' || lower(substring(btrim(email) FROM '@([^@]*)$'))
+WHERE btrim(email) LIKE '%@%';
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
@@ -0,0 +1,5 @@
+-- 超过最大发布次数的事件标记为 dead，relay 不再重试
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dead_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS outbox_events_unpublished_idx;
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events (occurred_at) WHERE published_at IS NULL AND dead_at IS NULL;
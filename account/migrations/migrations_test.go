@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Embedded migrations", func(t *testing.T) {
+		migrations, err := Load()
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+
+		// 版本从 1 开始连续
+		for i, m := range migrations {
+			assert.Equal(t, uint(i+1), m.Version, m.Name)
+		}
+		assert.Equal(t, "add_users_table", migrations[0].Name)
+	})
+
+	t.Run("Sorted by version", func(t *testing.T) {
+		migrations, err := load(fstest.MapFS{
+			"00010_b.up.sql":   {Data: []byte("b up")},
+			"00010_b.down.sql": {Data: []byte("b down")},
+			"00002_a.up.sql":   {Data: []byte("a up")},
+			"00002_a.down.sql": {Data: []byte("a down")},
+			"README.md":        {Data: []byte("ignored")},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Migration{
+			{Version: 2, Name: "a", Up: "a up", Down: "a down"},
+			{Version: 10, Name: "b", Up: "b up", Down: "b down"},
+		}, migrations)
+	})
+
+	t.Run("Invalid files", func(t *testing.T) {
+		cases := map[string]fstest.MapFS{
+			"Missing down": {
+				"00001_a.up.sql": {Data: []byte("up")},
+			},
+			"Empty up": {
+				"00001_a.up.sql":   {Data: []byte("")},
+				"00001_a.down.sql": {Data: []byte("down")},
+			},
+			"Different names": {
+				"00001_a.up.sql":   {Data: []byte("up")},
+				"00001_b.down.sql": {Data: []byte("down")},
+			},
+			"Version zero": {
+				"00000_a.up.sql":   {Data: []byte("up")},
+				"00000_a.down.sql": {Data: []byte("down")},
+			},
+		}
+
+		for name, fsys := range cases {
+			t.Run(name, func(t *testing.T) {
+				_, err := load(fsys)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestStatusPending(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 5}}
+
+	assert.Len(t, (&Status{Version: 0, Migrations: migrations}).Pending(), 3)
+	assert.Equal(t, []Migration{{Version: 5}}, (&Status{Version: 2, Migrations: migrations}).Pending())
+	assert.Empty(t, (&Status{Version: 5, Migrations: migrations}).Pending())
+	// 数据库版本比二进制新时没有可执行的迁移
+	assert.Empty(t, (&Status{Version: 9, Migrations: migrations}).Pending())
+}
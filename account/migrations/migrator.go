@@ -0,0 +1,263 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"memrizr/logging"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// lockKey 迁移使用的 Postgres advisory lock，所有副本相同，值为 "memrizr" 的 ASCII
+const lockKey int64 = 0x6d656d72697a72
+
+// schema_migrations 只有一行，与 golang-migrate 的 postgres 驱动一致
+const createTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+
+// Migrator 在持有 advisory lock 的连接上执行迁移，多个副本同时执行时依次进行
+type Migrator struct {
+	DB         *sqlx.DB
+	Migrations []Migration
+}
+
+// NewMigrator 使用内嵌的迁移文件
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Migrations: migrations,
+	}, nil
+}
+
+// Status 数据库当前的迁移状态
+type Status struct {
+	// Version 当前版本，0 表示没有执行过迁移
+	Version uint
+	// Dirty 上次迁移中途失败，需要手动修复后执行 force
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Pending 尚未执行的迁移
+func (s *Status) Pending() []Migration {
+	for i, m := range s.Migrations {
+		if m.Version > s.Version {
+			return s.Migrations[i:]
+		}
+	}
+	return nil
+}
+
+// Status 读取当前版本
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	var s *Status
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		s = &Status{Version: version, Dirty: dirty, Migrations: m.Migrations}
+		return nil
+	})
+
+	return s, err
+}
+
+// Up 执行最多 n 个未执行的迁移，n <= 0 时执行全部，返回执行的数量
+// 每个迁移与版本更新在同一个事务中，失败时回滚，不会留下 dirty 状态
+func (m *Migrator) Up(ctx context.Context, n int) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return dirtyError(version)
+		}
+
+		pending := (&Status{Version: version, Migrations: m.Migrations}).Pending()
+		if n > 0 && n < len(pending) {
+			pending = pending[:n]
+		}
+
+		for _, mig := range pending {
+			if err := apply(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up failed: %w", mig.Version, mig.Name, err)
+			}
+			applied++
+
+			logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+				"version": mig.Version,
+				"name":    mig.Name,
+			}).Info("Applied migration")
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down 回滚最近的 n 个迁移，返回回滚的数量
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return dirtyError(version)
+		}
+
+		i := m.index(version)
+		if version != 0 && i < 0 {
+			return fmt.Errorf("database version %d is not known to this binary", version)
+		}
+
+		for ; i >= 0 && reverted < n; i-- {
+			mig := m.Migrations[i]
+			var prev uint
+			if i > 0 {
+				prev = m.Migrations[i-1].Version
+			}
+
+			if err := apply(ctx, conn, mig.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down failed: %w", mig.Version, mig.Name, err)
+			}
+			reverted++
+
+			logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+				"version": mig.Version,
+				"name":    mig.Name,
+			}).Info("Reverted migration")
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force 设置版本并清除 dirty 状态，不执行任何迁移
+// 用于手动修复失败的迁移之后，version 为 0 表示没有执行过迁移
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+// index 版本在 Migrations 中的位置，不存在时返回 -1
+func (m *Migrator) index(version uint) int {
+	for i, mig := range m.Migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// withLock 获取一个连接并持有 advisory lock，其他副本在此等待
+// advisory lock 属于会话，因此所有语句都在同一个连接上执行
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.DB.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get db connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("unable to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// 连接回到连接池后会话仍然存在，必须显式释放
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			logging.Default().WithError(err).Warn("Unable to release migration lock")
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createTableQuery); err != nil {
+		return fmt.Errorf("unable to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// readVersion 表为空时版本为 0
+func readVersion(ctx context.Context, conn *sqlx.Conn) (uint, bool, error) {
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+
+	err := conn.GetContext(ctx, &row, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read schema version: %w", err)
+	}
+
+	return uint(row.Version), row.Dirty, nil
+}
+
+// apply 在一个事务中执行语句并更新版本
+func apply(ctx context.Context, conn *sqlx.Conn, query string, version uint) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setVersion 替换 schema_migrations 中唯一的一行
+func setVersion(ctx context.Context, e sqlx.ExecerContext, version uint) error {
+	if _, err := e.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("unable to update schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+
+	if _, err := e.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+		return fmt.Errorf("unable to update schema version: %w", err)
+	}
+
+	return nil
+}
+
+func dirtyError(version uint) error {
+	return fmt.Errorf("database is dirty at version %d, fix it manually and run migrate force %d", version, version)
+}
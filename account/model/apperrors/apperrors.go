@@ -14,11 +14,14 @@ const (
 	Authorization        Type = "AUTHORIZATION"        // Authentication Failures -
 	BadRequest           Type = "BADREQUEST"           // Validation errors / BadInput
 	Conflict             Type = "CONFLICT"             // Already exists (eg, create account with existent email) - 409
+	Forbidden            Type = "FORBIDDEN"            // Authenticated but not allowed to access the resource - 403
 	Internal             Type = "INTERNAL"             // Server (500) and fallback errors
 	NotFound             Type = "NOTFOUND"             // For not finding resource
 	PayloadTooLarge      Type = "PAYLOADTOOLARGE"      // for uploading tons of JSON, or an image over the limit - 413
 	UnsupportedMediaType Type = "UNSUPPORTEDMEDIATYPE" // for http 415
 	ServiceUnavailable   Type = "SERVICE_UNAVAILABLE"  // For long running handlers
+	PreconditionFailed   Type = "PRECONDITIONFAILED"   // If-Match does not match the current version - 412
+	PreconditionRequired Type = "PRECONDITIONREQUIRED" // Conditional request required but If-Match missing - 428
 )
 
 // Error 应用程序的自定义错误
@@ -43,6 +46,8 @@ func (e *Error) Status() int {
 		return http.StatusBadRequest
 	case Conflict:
 		return http.StatusConflict
+	case Forbidden:
+		return http.StatusForbidden
 	case Internal:
 		return http.StatusInternalServerError
 	case NotFound:
@@ -53,6 +58,10 @@ func (e *Error) Status() int {
 		return http.StatusUnsupportedMediaType
 	case ServiceUnavailable:
 		return http.StatusServiceUnavailable
+	case PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case PreconditionRequired:
+		return http.StatusPreconditionRequired
 	default:
 		return http.StatusInternalServerError
 	}
@@ -94,6 +103,14 @@ func NewConflict(name string, value string) *Error {
 	}
 }
 
+// NewForbidden 创建 403 error
+func NewForbidden(reason string) *Error {
+	return &Error{
+		Type:    Forbidden,
+		Message: reason,
+	}
+}
+
 // NewInternal 创建 500 error 或者 unknow error
 func NewInternal() *Error {
 	return &Error{
@@ -133,3 +150,19 @@ func NewServiceUnavailable() *Error {
 		Message: fmt.Sprintf("Service unavailable or timed out"),
 	}
 }
+
+// NewPreconditionFailed 创建 412 error
+func NewPreconditionFailed(reason string) *Error {
+	return &Error{
+		Type:    PreconditionFailed,
+		Message: reason,
+	}
+}
+
+// NewPreconditionRequired 创建 428 error
+func NewPreconditionRequired(reason string) *Error {
+	return &Error{
+		Type:    PreconditionRequired,
+		Message: reason,
+	}
+}
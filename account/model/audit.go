@@ -0,0 +1,116 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 审计事件动作
+const (
+	AuditSignup         = "user.signup"
+	AuditSignin         = "user.signin"
+	AuditSignout        = "user.signout"
+	AuditTokenRefresh   = "token.refresh"
+	AuditDetailsUpdate  = "user.details_update"
+	AuditEmailRequest   = "user.email_change_request"
+	AuditEmailChange    = "user.email_change"
+	AuditPrivacyUpdate  = "user.privacy_update"
+	AuditHandleChange   = "user.handle_change"
+	AuditAdminCreate    = "admin.user_create"
+	AuditAdminUpdate    = "admin.user_update"
+	AuditAdminSuspend   = "admin.user_suspend"
+	AuditAdminDisable   = "admin.user_disable"
+	AuditAdminReinstate = "admin.user_reinstate"
+	AuditAdminPassword  = "admin.user_password_reset"
+)
+
+// 审计事件结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// AuditEvent 安全审计事件
+type AuditEvent struct {
+	ID         int64        `db:"id" json:"id"`
+	OccurredAt time.Time    `db:"occurred_at" json:"occurred_at"`
+	ActorID    *uuid.UUID   `db:"actor_id" json:"actor_id,omitempty"`
+	TargetID   *uuid.UUID   `db:"target_id" json:"target_id,omitempty"`
+	Action     string       `db:"action" json:"action"`
+	Outcome    string       `db:"outcome" json:"outcome"`
+	IP         string       `db:"ip" json:"ip"`
+	UserAgent  string       `db:"user_agent" json:"user_agent"`
+	Details    AuditDetails `db:"details" json:"details,omitempty"`
+}
+
+// AuditDetails 审计事件的附加信息，以 JSONB 存储
+type AuditDetails map[string]string
+
+// Value 实现 driver.Valuer 接口
+func (d AuditDetails) Value() (driver.Value, error) {
+	if d == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(d)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (d *AuditDetails) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*d = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for AuditDetails: %T", src)
+	}
+	return json.Unmarshal(b, d)
+}
+
+// AuditQuery 审计日志查询条件
+// UserID 匹配操作人或目标用户
+type AuditQuery struct {
+	UserID   *uuid.UUID
+	ActorID  *uuid.UUID
+	TargetID *uuid.UUID
+	Action   string
+	Outcome  string
+	Since    *time.Time
+	Until    *time.Time
+	Cursor   string
+	Limit    int
+}
+
+// AuditPage 审计日志的一页数据
+type AuditPage struct {
+	Events     []*AuditEvent `json:"events"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// RequestMeta 发起请求的客户端信息
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
+
+type requestMetaKey struct{}
+
+// WithRequestMeta 将客户端信息保存到上下文
+func WithRequestMeta(ctx context.Context, m *RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, m)
+}
+
+// RequestMetaFromContext 从上下文获取客户端信息，不存在时返回 nil
+func RequestMetaFromContext(ctx context.Context) *RequestMeta {
+	m, _ := ctx.Value(requestMetaKey{}).(*RequestMeta)
+	return m
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailMessage 待发送的纯文本邮件
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// EmailChange 待确认的邮箱修改，每个用户只保留最新的一条
+// 只保存确认 token 的 hash
+type EmailChange struct {
+	UID       uuid.UUID `db:"uid"`
+	NewEmail  string    `db:"new_email"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
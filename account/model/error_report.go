@@ -0,0 +1,81 @@
+package model
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrorReport 上报给错误跟踪服务的 panic 或意外的内部错误
+// Err 为空时 Message 即错误内容，panic 的值不是 error 时就是这种情况
+type ErrorReport struct {
+	ID        string
+	Timestamp time.Time
+	Message   string
+	Err       error
+	Panic     bool
+	// Stack 调用栈，最近的调用在前
+	Stack   []StackFrame
+	Request *ReportRequest
+	User    *ReportUser
+	Extra   map[string]interface{}
+}
+
+// StackFrame 调用栈中的一帧
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// ReportRequest 出错的请求，不包含 query 和请求头中的凭证
+type ReportRequest struct {
+	Method    string
+	Path      string
+	Route     string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// ReportUser 出错时已认证的用户
+type ReportUser struct {
+	UID  uuid.UUID
+	Role string
+}
+
+// NewErrorReport 创建带 ID、时间和调用栈的报告
+// skip 为跳过的调用层数，0 表示从调用 NewErrorReport 的函数开始
+func NewErrorReport(skip int, message string, err error) *ErrorReport {
+	return &ErrorReport{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now(),
+		Message:   message,
+		Err:       err,
+		Stack:     CaptureStack(skip + 1),
+	}
+}
+
+// CaptureStack 当前 goroutine 的调用栈
+// 在 recover 所在的 defer 函数中调用时包含 panic 发生的位置
+func CaptureStack(skip int) []StackFrame {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pc)
+
+	frames := runtime.CallersFrames(pc[:n])
+	stack := make([]StackFrame, 0, n)
+	for {
+		f, more := frames.Next()
+		stack = append(stack, StackFrame{
+			Function: f.Function,
+			File:     f.File,
+			Line:     f.Line,
+		})
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
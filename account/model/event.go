@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 领域事件类型
+const (
+	EventUserCreated    = "user.created"
+	EventUserUpdated    = "user.updated"
+	EventUserDeleted    = "user.deleted"
+	EventSessionCreated = "session.created"
+	EventSessionRevoked = "session.revoked"
+)
+
+// Event 账户相关的领域事件，通过 outbox 表可靠投递
+type Event struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	Type        string          `db:"event_type" json:"type"`
+	AggregateID uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	OccurredAt  time.Time       `db:"occurred_at" json:"occurred_at"`
+	PublishedAt *time.Time      `db:"published_at" json:"-"`
+	Attempts    int             `db:"attempts" json:"-"`
+	LastError   string          `db:"last_error" json:"-"`
+	// DeadAt 超过最大发布次数的时间，不再重试
+	DeadAt *time.Time `db:"dead_at" json:"-"`
+}
+
+// NewEvent 创建领域事件，payload 序列化为 JSON
+func NewEvent(eventType string, aggregateID uuid.UUID, payload interface{}) (*Event, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:          id,
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		OccurredAt:  time.Now().UTC(),
+	}, nil
+}
+
+// SessionEventPayload 会话事件的数据
+type SessionEventPayload struct {
+	UID     uuid.UUID `json:"uid"`
+	TokenID string    `json:"token_id,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HandleRedirect 改名后旧 handle 的重定向记录，过期前旧 handle 不能被其他用户使用
+type HandleRedirect struct {
+	Handle    string    `db:"handle"`
+	UID       uuid.UUID `db:"uid"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "context"
+
+// 健康检查状态
+const (
+	HealthOK       = "ok"
+	HealthFailing  = "failing"
+	HealthDraining = "draining"
+)
+
+// HealthCheck 依赖检查，例如数据库连接
+type HealthCheck interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// HealthReport 就绪检查结果，Checks 的 key 为依赖名称
+type HealthReport struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult 单个依赖的检查结果
+type CheckResult struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// Ready 是否可以接收流量
+func (r *HealthReport) Ready() bool {
+	return r.Status == HealthOK
+}
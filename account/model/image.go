@@ -0,0 +1,67 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// AvatarSizes 头像生成的尺寸，单位为像素，从小到大排列
+var AvatarSizes = []int{64, 256, 512}
+
+// ImageURLs 各尺寸头像的地址，key 为尺寸，以 JSONB 存储
+type ImageURLs map[string]string
+
+// NewImageURLs 所有尺寸使用同一个地址，用于外部图片
+func NewImageURLs(url string) ImageURLs {
+	if url == "" {
+		return ImageURLs{}
+	}
+
+	urls := make(ImageURLs, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		urls.Set(size, url)
+	}
+	return urls
+}
+
+// Set 设置尺寸对应的地址
+func (u ImageURLs) Set(size int, url string) {
+	u[strconv.Itoa(size)] = url
+}
+
+// Largest 最大尺寸的地址，用于兼容只读取 image_url 的客户端
+func (u ImageURLs) Largest() string {
+	for i := len(AvatarSizes) - 1; i >= 0; i-- {
+		if url, ok := u[strconv.Itoa(AvatarSizes[i])]; ok {
+			return url
+		}
+	}
+	return ""
+}
+
+// Value 实现 driver.Valuer 接口
+func (u ImageURLs) Value() (driver.Value, error) {
+	if u == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(u)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (u *ImageURLs) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*u = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for ImageURLs: %T", src)
+	}
+	return json.Unmarshal(b, u)
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"context"
+	"io"
+	"mime/multipart"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,23 +14,173 @@ type UserService interface {
 	Get(ctx context.Context, uid uuid.UUID) (*User, error)
 	Signup(ctx context.Context, u *User) error
 	Signin(ctx context.Context, u *User) error
-	UpdateDetails(ctx context.Context, u *User) error
+	UpdateDetails(ctx context.Context, u *User) (bool, error)
+	PatchDetails(ctx context.Context, uid uuid.UUID, version int64, p UserPatch) (*User, bool, error)
+	RequestEmailChange(ctx context.Context, uid uuid.UUID, email string) error
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
+	GetPublicProfile(ctx context.Context, uid uuid.UUID) (*PublicProfile, error)
+	UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *ProfilePrivacy) (*User, error)
+	GetPublicProfileByHandle(ctx context.Context, handle string) (*PublicProfile, error)
+	CheckHandle(ctx context.Context, uid uuid.UUID, handle string) (string, bool, error)
+	SetHandle(ctx context.Context, uid uuid.UUID, handle string) (*User, error)
+	List(ctx context.Context, q *UserListQuery) (*UserPage, error)
+	AdminCreate(ctx context.Context, actorID uuid.UUID, u *User) error
+	AdminUpdate(ctx context.Context, actorID uuid.UUID, u *User) error
+	SetStatus(ctx context.Context, uid uuid.UUID, c *StatusChange) (*User, error)
+	Lookup(ctx context.Context, login string) (*User, error)
+	SetPassword(ctx context.Context, actorID uuid.UUID, uid uuid.UUID, password string) error
+	SetProfileImage(ctx context.Context, uid uuid.UUID, imageFileHeader *multipart.FileHeader) (*User, error)
+	ClearProfileImage(ctx context.Context, uid uuid.UUID) error
 }
 
 // UserRepository 用户存储服务
 type UserRepository interface {
 	FindByID(ctx context.Context, uid uuid.UUID) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByHandle(ctx context.Context, handle string) (*User, error)
 	Create(ctx context.Context, u *User) error
 	Update(ctx context.Context, u *User) error
+	Patch(ctx context.Context, uid uuid.UUID, version int64, p UserPatch) (*User, error)
+	List(ctx context.Context, q *UserListQuery) (*UserPage, error)
+	Save(ctx context.Context, u *User) error
+	UpdateStatus(ctx context.Context, uid uuid.UUID, c *StatusChange) (*User, error)
+	UpdatePassword(ctx context.Context, uid uuid.UUID, password string) (*User, error)
+	UpdateImage(ctx context.Context, uid uuid.UUID, urls ImageURLs) (*User, error)
+	UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *ProfilePrivacy) (*User, error)
+	UpdateHandle(ctx context.Context, uid uuid.UUID, handle string) (*User, error)
+}
+
+// HandleRedirectRepository 旧 handle 重定向记录的存储
+// Find 只返回未过期的记录
+type HandleRedirectRepository interface {
+	Save(ctx context.Context, r *HandleRedirect) error
+	Find(ctx context.Context, handle string) (*HandleRedirect, error)
+	Delete(ctx context.Context, handle string) error
+}
+
+// EmailChangeRepository 待确认邮箱修改的存储
+// Save 覆盖用户之前未确认的修改，Take 读取并删除
+type EmailChangeRepository interface {
+	Save(ctx context.Context, c *EmailChange) error
+	Take(ctx context.Context, tokenHash string) (*EmailChange, error)
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, m *EmailMessage) error
+}
+
+// ImageRepository 用户图片存储接口
+// DeleteProfile 忽略不属于该存储的地址，例如管理员手动设置的外部图片
+type ImageRepository interface {
+	UpdateProfile(ctx context.Context, objName string, contentType string, r io.Reader, size int64) (string, error)
+	DeleteProfile(ctx context.Context, imageURL string) error
+}
+
+// AvatarService 默认头像服务
+type AvatarService interface {
+	Default(ctx context.Context, uid uuid.UUID, size int) ([]byte, error)
+	DefaultURLs(uid uuid.UUID) ImageURLs
+}
+
+// MediaService 对象存储文件的访问
+// key 为私有对象时需要校验签名和过期时间
+type MediaService interface {
+	Open(ctx context.Context, key string, expires string, signature string) (*Object, error)
+}
+
+// URLSigner 签发和校验对象的访问地址
+type URLSigner interface {
+	URL(key string, ttl time.Duration) string
+	Verify(key string, expires string, signature string) error
+	KeyFromURL(url string) (string, bool)
+}
+
+// ObjectStorage 对象存储接口，key 使用 "/" 分隔
+// 读取不存在的对象时返回 NotFound，删除不存在的对象不返回 error
+type ObjectStorage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// AuditService 审计日志服务
+type AuditService interface {
+	Record(ctx context.Context, e *AuditEvent)
+	List(ctx context.Context, q *AuditQuery) (*AuditPage, error)
+}
+
+// AuditRepository 审计日志存储接口
+type AuditRepository interface {
+	Create(ctx context.Context, e *AuditEvent) error
+	List(ctx context.Context, q *AuditQuery) (*AuditPage, error)
+}
+
+// Transactor 数据库事务管理
+// fn 中使用传入的 ctx 调用存储层方法即可加入同一个事务
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// EventRepository 领域事件 outbox 存储接口
+type EventRepository interface {
+	Append(ctx context.Context, e *Event) error
+	LockUnpublished(ctx context.Context, limit int) ([]*Event, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+	MarkDead(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+// EventBroker 领域事件发布接口
+type EventBroker interface {
+	Publish(ctx context.Context, e *Event) error
+}
+
+// WebhookService webhook 管理服务
+type WebhookService interface {
+	Create(ctx context.Context, w *Webhook) error
+	Get(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	List(ctx context.Context) ([]*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Deliveries(ctx context.Context, q *WebhookDeliveryQuery) (*WebhookDeliveryPage, error)
+	RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) (*WebhookDelivery, error)
+}
+
+// WebhookRepository webhook 及投递记录存储接口
+type WebhookRepository interface {
+	Create(ctx context.Context, w *Webhook) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	List(ctx context.Context) ([]*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindSubscribed(ctx context.Context, eventType string) ([]*Webhook, error)
+	CreateDelivery(ctx context.Context, d *WebhookDelivery) error
+	FindDelivery(ctx context.Context, webhookID uuid.UUID, id int64) (*WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+	ListDeliveries(ctx context.Context, q *WebhookDeliveryQuery) (*WebhookDeliveryPage, error)
+}
+
+// ErrorReporter 错误上报接口，Report 不能阻塞请求处理
+type ErrorReporter interface {
+	Report(ctx context.Context, r *ErrorReport)
+}
+
+// HealthService 就绪检查接口
+type HealthService interface {
+	Ready(ctx context.Context) *HealthReport
+	Drain()
 }
 
 // TokenService Token服务接口
 type TokenService interface {
 	NewTokenPairFromUser(ctx context.Context, u *User, prevIDToken string) (*TokenPair, error)
 	Signout(ctx context.Context, uid uuid.UUID) error
+	CountSessions(ctx context.Context, uid uuid.UUID) (int, error)
 	ValidateIDToken(tokenString string) (*User, error)
 	ValidateRefreshToken(refreshTokenString string) (*RefreshToken, error)
+	SetKeys(k *TokenKeys) error
 }
 
 // TokenRepository Token存储接口
@@ -36,4 +188,5 @@ type TokenRepository interface {
 	SetRefreshToken(ctx context.Context, userID string, tokenID string, expiresIn time.Duration) error
 	DeleteRefreshToken(ctx context.Context, userID string, prevTokenID string) error
 	DeleteUserRefreshTokens(ctx context.Context, userID string) error
+	CountUserRefreshTokens(ctx context.Context, userID string) (int, error)
 }
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 对象可见性，作为 key 的第一级目录
+// 公开对象使用固定地址，私有对象只能通过签名地址访问
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// uuidPattern uuid.UUID.String() 的格式
+const uuidPattern = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`
+
+// legacyAvatarKey 引入可见性前缀之前上传的头像，对象没有迁移到 public/，只有符合旧命名格式的 key 按公开对象处理
+// 原图为 avatars/<uid>/<uuid>.<ext>，处理后的尺寸为 avatars/<uid>/<set>/<size>.<ext>
+var legacyAvatarKey = regexp.MustCompile(`^avatars/` + uuidPattern + `/(` + uuidPattern + `\.(png|jpg|webp)|` + uuidPattern + `/[0-9]+\.(png|jpg))$`)
+
+// IsLegacyAvatarKey 判断 key 是否为旧格式的头像
+func IsLegacyAvatarKey(key string) bool {
+	return legacyAvatarKey.MatchString(key)
+}
+
+// MediaKey 拼接可见性和对象名
+func MediaKey(visibility string, name string) string {
+	return visibility + "/" + name
+}
+
+// MediaVisibility 根据 key 获取可见性，未知前缀视为私有
+func MediaVisibility(key string) string {
+	if strings.HasPrefix(key, VisibilityPublic+"/") || IsLegacyAvatarKey(key) {
+		return VisibilityPublic
+	}
+	return VisibilityPrivate
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditRepository 模拟审计日志存储
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(ctx context.Context, e *model.AuditEvent) error {
+	ret := m.Called(ctx, e)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	ret := m.Called(ctx, q)
+
+	var r0 *model.AuditPage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.AuditPage)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
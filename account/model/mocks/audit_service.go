@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditService 模拟审计日志服务
+type MockAuditService struct {
+	mock.Mock
+}
+
+// Record 模拟记录审计事件
+func (m *MockAuditService) Record(ctx context.Context, e *model.AuditEvent) {
+	m.Called(ctx, e)
+}
+
+// List 模拟查询审计事件
+func (m *MockAuditService) List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	ret := m.Called(ctx, q)
+
+	var r0 *model.AuditPage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.AuditPage)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAvatarService 模拟默认头像服务
+type MockAvatarService struct {
+	mock.Mock
+}
+
+func (m *MockAvatarService) Default(ctx context.Context, uid uuid.UUID, size int) ([]byte, error) {
+	ret := m.Called(ctx, uid, size)
+
+	var r0 []byte
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]byte)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockAvatarService) DefaultURLs(uid uuid.UUID) model.ImageURLs {
+	ret := m.Called(uid)
+
+	var r0 model.ImageURLs
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(model.ImageURLs)
+	}
+
+	return r0
+}
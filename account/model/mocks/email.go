@@ -0,0 +1,56 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEmailChangeRepository 模拟待确认邮箱修改的存储
+type MockEmailChangeRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailChangeRepository) Save(ctx context.Context, c *model.EmailChange) error {
+	ret := m.Called(ctx, c)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockEmailChangeRepository) Take(ctx context.Context, tokenHash string) (*model.EmailChange, error) {
+	ret := m.Called(ctx, tokenHash)
+
+	var r0 *model.EmailChange
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.EmailChange)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// MockMailer 模拟邮件发送
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg *model.EmailMessage) error {
+	ret := m.Called(ctx, msg)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
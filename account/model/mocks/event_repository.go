@@ -0,0 +1,100 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEventRepository 模拟 outbox 存储
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) Append(ctx context.Context, e *model.Event) error {
+	ret := m.Called(ctx, e)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockEventRepository) LockUnpublished(ctx context.Context, limit int) ([]*model.Event, error) {
+	ret := m.Called(ctx, limit)
+
+	var r0 []*model.Event
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]*model.Event)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockEventRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	ret := m.Called(ctx, id)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockEventRepository) MarkDead(ctx context.Context, id uuid.UUID, reason string) error {
+	ret := m.Called(ctx, id, reason)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockEventRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	ret := m.Called(ctx, id, reason)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+// MockEventBroker 模拟事件发布
+type MockEventBroker struct {
+	mock.Mock
+}
+
+func (m *MockEventBroker) Publish(ctx context.Context, e *model.Event) error {
+	ret := m.Called(ctx, e)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+// MockTransactor 模拟事务管理，直接执行 fn
+type MockTransactor struct {
+	mock.Mock
+}
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.Called(ctx)
+	return fn(ctx)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHandleRedirectRepository 模拟旧 handle 重定向记录的存储
+type MockHandleRedirectRepository struct {
+	mock.Mock
+}
+
+func (m *MockHandleRedirectRepository) Save(ctx context.Context, r *model.HandleRedirect) error {
+	ret := m.Called(ctx, r)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockHandleRedirectRepository) Find(ctx context.Context, handle string) (*model.HandleRedirect, error) {
+	ret := m.Called(ctx, handle)
+
+	var r0 *model.HandleRedirect
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.HandleRedirect)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockHandleRedirectRepository) Delete(ctx context.Context, handle string) error {
+	ret := m.Called(ctx, handle)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHealthService 模拟就绪检查服务
+type MockHealthService struct {
+	mock.Mock
+}
+
+// Ready 模拟 Ready 方法
+func (m *MockHealthService) Ready(ctx context.Context) *model.HealthReport {
+	ret := m.Called(ctx)
+
+	var r0 *model.HealthReport
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.HealthReport)
+	}
+
+	return r0
+}
+
+// Drain 模拟 Drain 方法
+func (m *MockHealthService) Drain() {
+	m.Called()
+}
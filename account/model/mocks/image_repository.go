@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockImageRepository 模拟图片存储
+type MockImageRepository struct {
+	mock.Mock
+}
+
+func (m *MockImageRepository) UpdateProfile(ctx context.Context, objName string, contentType string, r io.Reader, size int64) (string, error) {
+	ret := m.Called(ctx, objName, contentType, r, size)
+
+	var r0 string
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockImageRepository) DeleteProfile(ctx context.Context, imageURL string) error {
+	ret := m.Called(ctx, imageURL)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
@@ -0,0 +1,100 @@
+package mocks
+
+import (
+	"context"
+	"io"
+	"memrizr/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMediaService 模拟对象存储文件访问服务
+type MockMediaService struct {
+	mock.Mock
+}
+
+func (m *MockMediaService) Open(ctx context.Context, key string, expires string, signature string) (*model.Object, error) {
+	ret := m.Called(ctx, key, expires, signature)
+
+	var r0 *model.Object
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.Object)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// MockURLSigner 模拟地址签名
+type MockURLSigner struct {
+	mock.Mock
+}
+
+func (m *MockURLSigner) URL(key string, ttl time.Duration) string {
+	ret := m.Called(key, ttl)
+	return ret.String(0)
+}
+
+func (m *MockURLSigner) Verify(key string, expires string, signature string) error {
+	ret := m.Called(key, expires, signature)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockURLSigner) KeyFromURL(url string) (string, bool) {
+	ret := m.Called(url)
+	return ret.String(0), ret.Bool(1)
+}
+
+// MockObjectStorage 模拟对象存储
+type MockObjectStorage struct {
+	mock.Mock
+}
+
+func (m *MockObjectStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	ret := m.Called(ctx, key, r, size, contentType)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockObjectStorage) Get(ctx context.Context, key string) (*model.Object, error) {
+	ret := m.Called(ctx, key)
+
+	var r0 *model.Object
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.Object)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockObjectStorage) Delete(ctx context.Context, key string) error {
+	ret := m.Called(ctx, key)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
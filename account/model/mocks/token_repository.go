@@ -44,3 +44,14 @@ func (m *MockTokenRepository) DeleteUserRefreshTokens(ctx context.Context, userI
 
 	return r0
 }
+
+func (m *MockTokenRepository) CountUserRefreshTokens(ctx context.Context, userID string) (int, error) {
+	ret := m.Called(ctx, userID)
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return ret.Int(0), r1
+}
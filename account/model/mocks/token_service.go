@@ -75,3 +75,27 @@ func (m *MockTokenService) Signout(ctx context.Context, uid uuid.UUID) error {
 
 	return r0
 }
+
+// CountSessions 模拟统计会话数
+func (m *MockTokenService) CountSessions(ctx context.Context, uid uuid.UUID) (int, error) {
+	ret := m.Called(ctx, uid)
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return ret.Int(0), r1
+}
+
+// SetKeys 模拟替换签名密钥
+func (m *MockTokenService) SetKeys(k *model.TokenKeys) error {
+	ret := m.Called(k)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
@@ -65,3 +65,142 @@ func (m *MockUserRepository) Update(ctx context.Context, u *model.User) error {
 
 	return r0
 }
+
+func (m *MockUserRepository) Patch(ctx context.Context, uid uuid.UUID, version int64, p model.UserPatch) (*model.User, error) {
+	ret := m.Called(ctx, uid, version, p)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *model.ProfilePrivacy) (*model.User, error) {
+	ret := m.Called(ctx, uid, p)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) FindByHandle(ctx context.Context, handle string) (*model.User, error) {
+	ret := m.Called(ctx, handle)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) UpdateHandle(ctx context.Context, uid uuid.UUID, handle string) (*model.User, error) {
+	ret := m.Called(ctx, uid, handle)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) List(ctx context.Context, q *model.UserListQuery) (*model.UserPage, error) {
+	ret := m.Called(ctx, q)
+
+	var r0 *model.UserPage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.UserPage)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) Save(ctx context.Context, u *model.User) error {
+	ret := m.Called(ctx, u)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockUserRepository) UpdateStatus(ctx context.Context, uid uuid.UUID, c *model.StatusChange) (*model.User, error) {
+	ret := m.Called(ctx, uid, c)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, uid uuid.UUID, password string) (*model.User, error) {
+	ret := m.Called(ctx, uid, password)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockUserRepository) UpdateImage(ctx context.Context, uid uuid.UUID, urls model.ImageURLs) (*model.User, error) {
+	ret := m.Called(ctx, uid, urls)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"memrizr/model"
+	"mime/multipart"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -56,9 +57,261 @@ func (m *MockUserService) Signin(ctx context.Context, u *model.User) error {
 }
 
 // UpdateDetails 模拟 UpdateDetails 方法
-func (m *MockUserService) UpdateDetails(ctx context.Context, u *model.User) error {
+func (m *MockUserService) UpdateDetails(ctx context.Context, u *model.User) (bool, error) {
 	ret := m.Called(ctx, u)
 
+	var r0 bool
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// PatchDetails 模拟 PatchDetails 方法
+func (m *MockUserService) PatchDetails(ctx context.Context, uid uuid.UUID, version int64, p model.UserPatch) (*model.User, bool, error) {
+	ret := m.Called(ctx, uid, version, p)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 bool
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return r0, r1, r2
+}
+
+// RequestEmailChange 模拟 RequestEmailChange 方法
+func (m *MockUserService) RequestEmailChange(ctx context.Context, uid uuid.UUID, email string) error {
+	ret := m.Called(ctx, uid, email)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+// ConfirmEmailChange 模拟 ConfirmEmailChange 方法
+func (m *MockUserService) ConfirmEmailChange(ctx context.Context, token string) (*model.User, error) {
+	ret := m.Called(ctx, token)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// GetPublicProfile 模拟 GetPublicProfile 方法
+func (m *MockUserService) GetPublicProfile(ctx context.Context, uid uuid.UUID) (*model.PublicProfile, error) {
+	ret := m.Called(ctx, uid)
+
+	var r0 *model.PublicProfile
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.PublicProfile)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// UpdatePrivacy 模拟 UpdatePrivacy 方法
+func (m *MockUserService) UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *model.ProfilePrivacy) (*model.User, error) {
+	ret := m.Called(ctx, uid, p)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// GetPublicProfileByHandle 模拟 GetPublicProfileByHandle 方法
+func (m *MockUserService) GetPublicProfileByHandle(ctx context.Context, handle string) (*model.PublicProfile, error) {
+	ret := m.Called(ctx, handle)
+
+	var r0 *model.PublicProfile
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.PublicProfile)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// CheckHandle 模拟 CheckHandle 方法
+func (m *MockUserService) CheckHandle(ctx context.Context, uid uuid.UUID, handle string) (string, bool, error) {
+	ret := m.Called(ctx, uid, handle)
+
+	var r2 error
+	if ret.Get(2) != nil {
+		r2 = ret.Get(2).(error)
+	}
+
+	return ret.String(0), ret.Bool(1), r2
+}
+
+// SetHandle 模拟 SetHandle 方法
+func (m *MockUserService) SetHandle(ctx context.Context, uid uuid.UUID, handle string) (*model.User, error) {
+	ret := m.Called(ctx, uid, handle)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// List 模拟 List 方法
+func (m *MockUserService) List(ctx context.Context, q *model.UserListQuery) (*model.UserPage, error) {
+	ret := m.Called(ctx, q)
+
+	var r0 *model.UserPage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.UserPage)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// AdminUpdate 模拟 AdminUpdate 方法
+func (m *MockUserService) AdminUpdate(ctx context.Context, actorID uuid.UUID, u *model.User) error {
+	ret := m.Called(ctx, actorID, u)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+// SetStatus 模拟 SetStatus 方法
+func (m *MockUserService) SetStatus(ctx context.Context, uid uuid.UUID, c *model.StatusChange) (*model.User, error) {
+	ret := m.Called(ctx, uid, c)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// Lookup 模拟 Lookup 方法
+func (m *MockUserService) Lookup(ctx context.Context, login string) (*model.User, error) {
+	ret := m.Called(ctx, login)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// AdminCreate 模拟 AdminCreate 方法
+func (m *MockUserService) AdminCreate(ctx context.Context, actorID uuid.UUID, u *model.User) error {
+	ret := m.Called(ctx, actorID, u)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+// SetPassword 模拟 SetPassword 方法
+func (m *MockUserService) SetPassword(ctx context.Context, actorID uuid.UUID, uid uuid.UUID, password string) error {
+	ret := m.Called(ctx, actorID, uid, password)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+// SetProfileImage 模拟 SetProfileImage 方法
+func (m *MockUserService) SetProfileImage(ctx context.Context, uid uuid.UUID, imageFileHeader *multipart.FileHeader) (*model.User, error) {
+	ret := m.Called(ctx, uid, imageFileHeader)
+
+	var r0 *model.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.User)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+// ClearProfileImage 模拟 ClearProfileImage 方法
+func (m *MockUserService) ClearProfileImage(ctx context.Context, uid uuid.UUID) error {
+	ret := m.Called(ctx, uid)
+
 	var r0 error
 	if ret.Get(0) != nil {
 		r0 = ret.Get(0).(error)
@@ -0,0 +1,166 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookRepository 模拟 webhook 存储
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, w *model.Webhook) error {
+	ret := m.Called(ctx, w)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	ret := m.Called(ctx, id)
+
+	var r0 *model.Webhook
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.Webhook)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	ret := m.Called(ctx)
+
+	var r0 []*model.Webhook
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]*model.Webhook)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookRepository) Update(ctx context.Context, w *model.Webhook) error {
+	ret := m.Called(ctx, w)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ret := m.Called(ctx, id)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookRepository) FindSubscribed(ctx context.Context, eventType string) ([]*model.Webhook, error) {
+	ret := m.Called(ctx, eventType)
+
+	var r0 []*model.Webhook
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]*model.Webhook)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	ret := m.Called(ctx, d)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookRepository) FindDelivery(ctx context.Context, webhookID uuid.UUID, id int64) (*model.WebhookDelivery, error) {
+	ret := m.Called(ctx, webhookID, id)
+
+	var r0 *model.WebhookDelivery
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.WebhookDelivery)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	ret := m.Called(ctx, limit, lease)
+
+	var r0 []*model.WebhookDelivery
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]*model.WebhookDelivery)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	ret := m.Called(ctx, d)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, q *model.WebhookDeliveryQuery) (*model.WebhookDeliveryPage, error) {
+	ret := m.Called(ctx, q)
+
+	var r0 *model.WebhookDeliveryPage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.WebhookDeliveryPage)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,111 @@
+package mocks
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookService 模拟 webhook 服务
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) Create(ctx context.Context, w *model.Webhook) error {
+	ret := m.Called(ctx, w)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookService) Get(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	ret := m.Called(ctx, id)
+
+	var r0 *model.Webhook
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.Webhook)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookService) List(ctx context.Context) ([]*model.Webhook, error) {
+	ret := m.Called(ctx)
+
+	var r0 []*model.Webhook
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]*model.Webhook)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookService) Update(ctx context.Context, w *model.Webhook) error {
+	ret := m.Called(ctx, w)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	ret := m.Called(ctx, id)
+
+	var r0 error
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(error)
+	}
+
+	return r0
+}
+
+func (m *MockWebhookService) Deliveries(ctx context.Context, q *model.WebhookDeliveryQuery) (*model.WebhookDeliveryPage, error) {
+	ret := m.Called(ctx, q)
+
+	var r0 *model.WebhookDeliveryPage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.WebhookDeliveryPage)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
+
+func (m *MockWebhookService) RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) (*model.WebhookDelivery, error) {
+	ret := m.Called(ctx, webhookID, deliveryID)
+
+	var r0 *model.WebhookDelivery
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*model.WebhookDelivery)
+	}
+
+	var r1 error
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(error)
+	}
+
+	return r0, r1
+}
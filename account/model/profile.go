@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProfilePrivacy 用户控制的公开资料可见性设置
+// Public 为 false 时其他人无法查看资料
+type ProfilePrivacy struct {
+	Public      bool `json:"public"`
+	ShowWebsite bool `json:"showWebsite"`
+	ShowAvatar  bool `json:"showAvatar"`
+}
+
+// PublicProfile 其他用户可见的公开资料
+type PublicProfile struct {
+	UID       uuid.UUID `json:"uid"`
+	Handle    string    `json:"handle,omitempty"`
+	Name      string    `json:"name"`
+	ImageURLs ImageURLs `json:"image_urls,omitempty"`
+	Website   string    `json:"website,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Privacy 返回用户的可见性设置
+func (u *User) Privacy() *ProfilePrivacy {
+	return &ProfilePrivacy{
+		Public:      u.ProfilePublic,
+		ShowWebsite: u.ShowWebsite,
+		ShowAvatar:  u.ShowAvatar,
+	}
+}
+
+// PublicProfile 按可见性设置生成公开资料
+func (u *User) PublicProfile() *PublicProfile {
+	p := &PublicProfile{
+		UID:       u.UID,
+		Handle:    u.Handle,
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt,
+	}
+
+	if u.ShowWebsite {
+		p.Website = u.Website
+	}
+
+	if u.ShowAvatar {
+		p.ImageURLs = u.ImageURLs
+	}
+
+	return p
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"io"
+	"time"
+)
+
+// Object 从对象存储读取的对象，调用方负责关闭 Body
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
@@ -1,6 +1,10 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"crypto/rsa"
+
+	"github.com/google/uuid"
+)
 
 // RefreshToken 存储 token 属性
 type RefreshToken struct {
@@ -19,3 +23,12 @@ type TokenPair struct {
 	IDToken
 	RefreshToken
 }
+
+// TokenKeys token 签名密钥和有效期，运行时可以整体替换
+type TokenKeys struct {
+	PrivateKey            *rsa.PrivateKey
+	PublicKey             *rsa.PublicKey
+	RefreshSecret         string
+	IDExpirationSecs      int64
+	RefreshExpirationSecs int64
+}
@@ -1,15 +1,100 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// 用户状态
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusDisabled  = "disabled"
+)
+
+// 用户资料中允许部分更新的字段
+const (
+	FieldName    = "name"
+	FieldEmail   = "email"
+	FieldWebsite = "website"
+)
+
 // User 用户模型
 type User struct {
-	UID      uuid.UUID `db:"uid" json:"uid"`
-	Email    string    `db:"email" json:"email"`
-	Password string    `db:"password" json:"-"`
-	Name     string    `db:"name" json:"name"`
-	ImageURL string    `db:"image_url" json:"image_url"`
-	Website  string    `db:"website" json:"website"`
+	UID       uuid.UUID `db:"uid" json:"uid"`
+	Email     string    `db:"email" json:"email"`
+	Handle    string    `db:"handle" json:"handle,omitempty"`
+	Password  string    `db:"password" json:"-"`
+	Name      string    `db:"name" json:"name"`
+	ImageURL  string    `db:"image_url" json:"-"`
+	ImageURLs ImageURLs `db:"image_urls" json:"image_urls"`
+	Website   string    `db:"website" json:"website"`
+	Role      string    `db:"role" json:"role"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Version   int64     `db:"version" json:"-"`
+
+	ProfilePublic bool `db:"profile_public" json:"profile_public"`
+	ShowWebsite   bool `db:"show_website" json:"show_website"`
+	ShowAvatar    bool `db:"show_avatar" json:"show_avatar"`
+
+	Status          string     `db:"status" json:"status"`
+	StatusReason    string     `db:"status_reason" json:"status_reason,omitempty"`
+	StatusExpiresAt *time.Time `db:"status_expires_at" json:"status_expires_at,omitempty"`
+	StatusChangedBy *uuid.UUID `db:"status_changed_by" json:"status_changed_by,omitempty"`
+	StatusChangedAt *time.Time `db:"status_changed_at" json:"status_changed_at,omitempty"`
+}
+
+// IsAdmin 判断用户是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsActive 判断用户在给定时间是否可以正常使用
+// 到期的暂停视为已恢复
+func (u *User) IsActive(now time.Time) bool {
+	switch u.Status {
+	case StatusActive:
+		return true
+	case StatusSuspended:
+		return u.StatusExpiresAt != nil && !now.Before(*u.StatusExpiresAt)
+	default:
+		return false
+	}
+}
+
+// UserPatch 用户资料的部分更新，对应 RFC 7396 JSON Merge Patch
+// 只包含请求中出现的字段，值为 nil 表示清空该字段
+type UserPatch map[string]*string
+
+// StatusChange 管理员修改用户状态的请求
+type StatusChange struct {
+	Status    string
+	Reason    string
+	ExpiresAt *time.Time
+	ActorID   uuid.UUID
+}
+
+// UserListQuery 管理员查询用户列表的过滤和分页条件
+type UserListQuery struct {
+	EmailPrefix   string
+	Name          string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Status        string
+	Cursor        string
+	Limit         int
+}
+
+// UserPage 用户列表的一页数据，NextCursor 为空表示没有更多数据
+type UserPage struct {
+	Users      []*User `json:"users"`
+	NextCursor string  `json:"nextCursor,omitempty"`
 }
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// 投递状态
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// WebhookEventTypes 可订阅的事件类型
+var WebhookEventTypes = []string{
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventSessionCreated,
+	EventSessionRevoked,
+}
+
+// Webhook 管理员注册的外部回调地址
+type Webhook struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	URL         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"-"`
+	EventTypes  pq.StringArray `db:"event_types" json:"event_types"`
+	Description string         `db:"description" json:"description"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedBy   *uuid.UUID     `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// Subscribes 判断是否订阅了事件类型
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery 一次事件到 webhook 的投递记录
+type WebhookDelivery struct {
+	ID             int64           `db:"id" json:"id"`
+	WebhookID      uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	EventID        uuid.UUID       `db:"event_id" json:"event_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode int             `db:"last_status_code" json:"last_status_code"`
+	LastError      string          `db:"last_error" json:"last_error"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookDeliveryQuery 投递记录查询条件
+type WebhookDeliveryQuery struct {
+	WebhookID uuid.UUID
+	Status    string
+	Cursor    string
+	Limit     int
+}
+
+// WebhookDeliveryPage 投递记录的一页数据
+type WebhookDeliveryPage struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
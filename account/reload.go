@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"memrizr/config"
+	"memrizr/handler"
+	"memrizr/model"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadable 可以在运行中替换的配置项，其他配置项修改后需要重启
+var reloadable = map[string]bool{
+	"PRIV_KEY_FILE":     true,
+	"PUB_KEY_FILE":      true,
+	"REFRESH_SECRET":    true,
+	"ID_TOKEN_EXP":      true,
+	"REFRESH_TOKEN_EXP": true,
+	"HANDLER_TIMEOUT":   true,
+	"MAX_BODY_BYTES":    true,
+	"LOG_LEVEL":         true,
+}
+
+// reloader 重新读取配置和密钥文件，校验通过后替换到运行中的服务
+// 任何一步失败都保留当前配置
+type reloader struct {
+	mu           sync.Mutex
+	cfg          *config.Config
+	tokenService model.TokenService
+	limits       *handler.Limits
+	logger       *logrus.Logger
+}
+
+// Reload 重新加载配置
+func (r *reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	keys, err := loadTokenKeys(cfg.Tokens)
+	if err != nil {
+		return err
+	}
+
+	if err := r.tokenService.SetKeys(keys); err != nil {
+		return fmt.Errorf("invalid token keys: %w", err)
+	}
+	r.limits.Set(cfg.HTTP.HandlerTimeout, cfg.HTTP.MaxBodyBytes)
+
+	// 级别在加载配置时已校验
+	level, _ := logrus.ParseLevel(cfg.Log.Level)
+	r.logger.SetLevel(level)
+
+	for _, env := range r.cfg.Changed(cfg) {
+		if reloadable[env] {
+			r.logger.WithField("env", env).Info("Reloaded configuration")
+		} else {
+			r.logger.WithField("env", env).Warn("Change requires a restart, keeping the current value")
+		}
+	}
+
+	// 只记录已生效的配置项，需要重启的修改在下次重新加载时继续提示
+	copyReloadable(r.cfg, cfg)
+
+	return nil
+}
+
+// copyReloadable 将可替换的配置项复制到当前配置
+func copyReloadable(dst, src *config.Config) {
+	dst.Tokens = src.Tokens
+	dst.HTTP.HandlerTimeout = src.HTTP.HandlerTimeout
+	dst.HTTP.MaxBodyBytes = src.HTTP.MaxBodyBytes
+	dst.Log.Level = src.Log.Level
+}
+
+// watchedFiles 配置文件和密钥文件
+func (r *reloader) watchedFiles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files := []string{r.cfg.Tokens.PrivKeyFile, r.cfg.Tokens.PubKeyFile}
+	if f := config.File(); f != "" {
+		files = append(files, f)
+	}
+	return files
+}
+
+// configWatcher 定期检查配置文件和密钥文件，修改后重新加载
+type configWatcher struct {
+	reloader *reloader
+	interval time.Duration
+}
+
+// Run 实现 worker 接口，interval 为 0 时直接返回
+func (w *configWatcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		return
+	}
+
+	last := fileStamps(w.reloader.watchedFiles())
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := fileStamps(w.reloader.watchedFiles())
+			if current == last {
+				continue
+			}
+			last = current
+
+			w.reloader.logger.Info("Configuration files changed, reloading")
+			if err := w.reloader.Reload(); err != nil {
+				w.reloader.logger.WithError(err).Error("Reload failed, keeping the current configuration")
+			}
+		}
+	}
+}
+
+// fileStamps 文件的修改时间和大小，文件不存在时记录错误
+func fileStamps(files []string) string {
+	var stamps string
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			stamps += fmt.Sprintf("%s:%v;", f, err)
+			continue
+		}
+		stamps += fmt.Sprintf("%s:%d:%d;", f, info.ModTime().UnixNano(), info.Size())
+	}
+	return stamps
+}
+
+// loadTokenKeys 读取并解析 rsa 密钥
+func loadTokenKeys(c config.TokenConfig) (*model.TokenKeys, error) {
+	priv, err := ioutil.ReadFile(c.PrivKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read private key pem file: %w", err)
+	}
+
+	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(priv)
+	if err != nil {
+		return nil, fmt.Errorf("could not read private key: %w", err)
+	}
+
+	pub, err := ioutil.ReadFile(c.PubKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read public key pem file: %w", err)
+	}
+
+	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(pub)
+	if err != nil {
+		return nil, fmt.Errorf("could not read public key: %w", err)
+	}
+
+	return &model.TokenKeys{
+		PrivateKey:            privKey,
+		PublicKey:             pubKey,
+		RefreshSecret:         c.RefreshSecret,
+		IDExpirationSecs:      int64(c.IDTokenExp / time.Second),
+		RefreshExpirationSecs: int64(c.RefreshExp / time.Second),
+	}, nil
+}
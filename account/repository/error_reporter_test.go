@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"memrizr/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sentryStub 模拟 Sentry store API，记录收到的请求
+type sentryStub struct {
+	server *httptest.Server
+	paths  chan string
+	auths  chan string
+	events chan *sentryEvent
+}
+
+func newSentryStub(t *testing.T) *sentryStub {
+	s := &sentryStub{
+		paths:  make(chan string, 10),
+		auths:  make(chan string, 10),
+		events: make(chan *sentryEvent, 10),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e := &sentryEvent{}
+		if err := json.Unmarshal(body, e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.paths <- r.URL.Path
+		s.auths <- r.Header.Get("X-Sentry-Auth")
+		s.events <- e
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// dsn 指向 stub 的 DSN
+func (s *sentryStub) dsn(path string) string {
+	return strings.Replace(s.server.URL, "http://", "http://public:private@", 1) + path
+}
+
+func TestNewSentryReporter(t *testing.T) {
+	t.Run("Store URL from DSN", func(t *testing.T) {
+		s, err := NewSentryReporter("https://abc@sentry.example.com/prefix/42", "test", time.Second)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://sentry.example.com/prefix/api/42/store/", s.StoreURL)
+		assert.Contains(t, s.Auth, "sentry_key=abc")
+		assert.NotContains(t, s.Auth, "sentry_secret")
+	})
+
+	t.Run("Invalid DSN", func(t *testing.T) {
+		for _, dsn := range []string{"https://sentry.example.com/1", "https://abc@sentry.example.com/", "::"} {
+			_, err := NewSentryReporter(dsn, "test", time.Second)
+			assert.Error(t, err, dsn)
+		}
+	})
+}
+
+func TestSentryReporter(t *testing.T) {
+	t.Run("Reports panic with stack, request and user", func(t *testing.T) {
+		stub := newSentryStub(t)
+		reporter, err := NewSentryReporter(stub.dsn("/7"), "test", time.Second)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go reporter.Run(ctx)
+
+		uid, _ := uuid.NewRandom()
+		r := model.NewErrorReport(0, "boom for bob@bob.com", nil)
+		r.Panic = true
+		r.Request = &model.ReportRequest{
+			Method:    http.MethodGet,
+			Path:      "/boom/1",
+			Route:     "/boom/:id",
+			IP:        "192.0.2.1",
+			RequestID: "req-1",
+		}
+		r.User = &model.ReportUser{UID: uid, Role: model.RoleAdmin}
+
+		reporter.Report(context.Background(), r)
+
+		var e *sentryEvent
+		select {
+		case e = <-stub.events:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+		}
+
+		assert.Equal(t, "/api/7/store/", <-stub.paths)
+		auth := <-stub.auths
+		assert.Contains(t, auth, "sentry_key=public")
+		assert.Contains(t, auth, "sentry_secret=private")
+
+		assert.Equal(t, "fatal", e.Level)
+		assert.Equal(t, "test", e.Environment)
+		assert.Equal(t, strings.ReplaceAll(r.ID, "-", ""), e.EventID)
+		require.Len(t, e.Exception.Values, 1)
+		ex := e.Exception.Values[0]
+		assert.Equal(t, "panic", ex.Type)
+		assert.Equal(t, "boom for ***@bob.com", ex.Value)
+		assert.False(t, ex.Mechanism.Handled)
+
+		// Sentry 的最后一帧为最近的调用
+		frames := ex.Stacktrace.Frames
+		require.NotEmpty(t, frames)
+		assert.Contains(t, frames[len(frames)-1].Function, "TestSentryReporter")
+
+		assert.Equal(t, "/boom/1", e.Request.URL)
+		assert.Equal(t, http.MethodGet, e.Request.Method)
+		assert.Equal(t, "/boom/:id", e.Tags["route"])
+		assert.Equal(t, "req-1", e.Tags["request_id"])
+		assert.Equal(t, uid.String(), e.User.ID)
+		assert.Equal(t, model.RoleAdmin, e.User.Role)
+		assert.Equal(t, "192.0.2.1", e.User.IPAddress)
+	})
+
+	t.Run("Sends queued reports on shutdown", func(t *testing.T) {
+		stub := newSentryStub(t)
+		reporter, err := NewSentryReporter(stub.dsn("/1"), "test", time.Second)
+		require.NoError(t, err)
+
+		reporter.Report(context.Background(), model.NewErrorReport(0, "Failed to update user", io.ErrUnexpectedEOF))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		reporter.Run(ctx)
+
+		var e *sentryEvent
+		select {
+		case e = <-stub.events:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+		}
+
+		assert.Equal(t, "error", e.Level)
+		assert.Equal(t, "Failed to update user", e.Message)
+		assert.Equal(t, "unexpected EOF", e.Exception.Values[0].Value)
+		assert.True(t, e.Exception.Values[0].Mechanism.Handled)
+	})
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jmoiron/sqlx"
+)
+
+// pgHealthCheck 检查数据库连接
+type pgHealthCheck struct {
+	DB *sqlx.DB
+}
+
+// NewPGHealthCheck 创建数据库健康检查
+func NewPGHealthCheck(db *sqlx.DB) model.HealthCheck {
+	return &pgHealthCheck{DB: db}
+}
+
+// Name 实现 HealthCheck 接口
+func (c *pgHealthCheck) Name() string {
+	return "postgres"
+}
+
+// Check 实现 HealthCheck 接口
+func (c *pgHealthCheck) Check(ctx context.Context) error {
+	return c.DB.PingContext(ctx)
+}
+
+// redisHealthCheck 检查 Redis 连接
+type redisHealthCheck struct {
+	Redis *redis.Client
+}
+
+// NewRedisHealthCheck 创建 Redis 健康检查
+func NewRedisHealthCheck(rdb *redis.Client) model.HealthCheck {
+	return &redisHealthCheck{Redis: rdb}
+}
+
+// Name 实现 HealthCheck 接口
+func (c *redisHealthCheck) Name() string {
+	return "redis"
+}
+
+// Check 实现 HealthCheck 接口
+func (c *redisHealthCheck) Check(ctx context.Context) error {
+	return c.Redis.Ping(ctx).Err()
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"memrizr/model"
+	"strings"
+)
+
+// 头像为公开对象
+var avatarKeyPrefix = model.MediaKey(model.VisibilityPublic, "avatars/")
+
+// imageRepository 将用户图片保存到对象存储
+type imageRepository struct {
+	Storage model.ObjectStorage
+	Signer  model.URLSigner
+}
+
+// NewImageRepository 实例化 imageRepository
+func NewImageRepository(storage model.ObjectStorage, signer model.URLSigner) model.ImageRepository {
+	return &imageRepository{
+		Storage: storage,
+		Signer:  signer,
+	}
+}
+
+// UpdateProfile 保存头像并返回访问地址
+func (r *imageRepository) UpdateProfile(ctx context.Context, objName string, contentType string, src io.Reader, size int64) (string, error) {
+	key := avatarKeyPrefix + objName
+
+	if err := r.Storage.Put(ctx, key, src, size, contentType); err != nil {
+		return "", err
+	}
+
+	// 公开对象的地址不会过期
+	return r.Signer.URL(key, 0), nil
+}
+
+// DeleteProfile 删除头像，包括没有可见性前缀的旧头像，不属于该存储的地址直接忽略
+func (r *imageRepository) DeleteProfile(ctx context.Context, imageURL string) error {
+	key, ok := r.Signer.KeyFromURL(imageURL)
+	if !ok || !(strings.HasPrefix(key, avatarKeyPrefix) || model.IsLegacyAvatarKey(key)) {
+		return nil
+	}
+
+	return r.Storage.Delete(ctx, key)
+}
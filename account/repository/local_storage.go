@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// localStorage 将对象保存到本地目录，用于开发环境
+type localStorage struct {
+	Dir string
+}
+
+// NewLocalStorage 实例化 localStorage
+func NewLocalStorage(dir string) model.ObjectStorage {
+	return &localStorage{
+		Dir: dir,
+	}
+}
+
+// Put 保存对象
+// 先写入临时文件再重命名，避免读到不完整的对象
+func (s *localStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dst := s.filePath(key)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to create directory for object")
+		return apperrors.NewInternal()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to create temp file for object")
+		return apperrors.NewInternal()
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to write object")
+		return apperrors.NewInternal()
+	}
+
+	if err := tmp.Close(); err != nil {
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to write object")
+		return apperrors.NewInternal()
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to set permissions of object")
+		return apperrors.NewInternal()
+	}
+
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to move object")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Get 读取对象，内容类型由扩展名推断
+func (s *localStorage) Get(ctx context.Context, key string) (*model.Object, error) {
+	f, err := os.Open(s.filePath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, apperrors.NewNotFound("object", key)
+		}
+
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to open object")
+		return nil, apperrors.NewInternal()
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to stat object")
+		return nil, apperrors.NewInternal()
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &model.Object{
+		Body:        f,
+		ContentType: contentType,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+// Delete 删除对象
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.filePath(key)); err != nil && !os.IsNotExist(err) {
+		logging.Default().WithContext(ctx).WithField("key", key).WithError(err).Error("Unable to delete object")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// filePath 将 key 转换为 Dir 下的文件路径，清理 ".." 防止越出目录
+func (s *localStorage) filePath(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(path.Clean("/"+key)))
+}
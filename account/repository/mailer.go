@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// smtpMailer 通过 SMTP 发送邮件
+type smtpMailer struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+// NewSMTPMailer 实例化 smtpMailer
+// username 为空时不进行认证
+func NewSMTPMailer(addr, username, password, from string) model.Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		Addr: addr,
+		Auth: auth,
+		From: from,
+	}
+}
+
+// Send 发送纯文本邮件
+func (m *smtpMailer) Send(ctx context.Context, msg *model.EmailMessage) error {
+	if err := smtp.SendMail(m.Addr, m.Auth, m.From, []string{msg.To}, buildEmail(m.From, msg)); err != nil {
+		logging.Default().WithContext(ctx).WithField("to", msg.To).WithError(err).Error("Unable to send email")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// buildEmail 生成邮件内容，去掉头部中的换行防止注入
+func buildEmail(from string, msg *model.EmailMessage) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+// logMailer 不发送邮件的实现，只用于本地开发
+// 日志会脱敏邮件中的链接，因此完整的邮件写入单独的 Out，不经过日志
+type logMailer struct {
+	Out io.Writer
+	mu  sync.Mutex
+}
+
+// NewLogMailer 实例化 logMailer，邮件内容写入 out
+func NewLogMailer(out io.Writer) model.Mailer {
+	return &logMailer{
+		Out: out,
+	}
+}
+
+// Send 将完整的邮件写入 Out，日志中只记录收件人和主题
+func (m *logMailer) Send(ctx context.Context, msg *model.EmailMessage) error {
+	m.mu.Lock()
+	_, err := fmt.Fprintf(m.Out, "----- email -----\nTo: %s\nSubject: %s\n\n%s\n-----------------\n", msg.To, msg.Subject, msg.Body)
+	m.mu.Unlock()
+	if err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to write email")
+		return apperrors.NewInternal()
+	}
+
+	logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info("Email sent")
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"memrizr/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildEmail(t *testing.T) {
+	msg := buildEmail("no-reply@memrizr.local", &model.EmailMessage{
+		To:      "bob@bob.com",
+		Subject: "Hello\r\nBcc: eve@evil.com",
+		Body:    "line1\nline2",
+	})
+
+	s := string(msg)
+	assert.Contains(t, s, "To: bob@bob.com\r\n")
+	assert.Contains(t, s, "Subject: HelloBcc: eve@evil.com\r\n")
+	assert.NotContains(t, s, "\r\nBcc:")
+	assert.True(t, strings.HasSuffix(s, "\r\n\r\nline1\r\nline2"))
+}
+
+func TestLogMailer(t *testing.T) {
+	var out bytes.Buffer
+	m := NewLogMailer(&out)
+
+	link := "http://localhost:3000/confirm-email?token=abc.def.ghi"
+	err := m.Send(context.Background(), &model.EmailMessage{
+		To:      "bob@bob.com",
+		Subject: "Confirm your email",
+		Body:    "Open " + link,
+	})
+
+	// 开发时需要完整的链接，写入的内容不脱敏
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "To: bob@bob.com\n")
+	assert.Contains(t, out.String(), link)
+}
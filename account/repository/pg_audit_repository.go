@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pgAuditRepository 审计日志存储层实现
+type pgAuditRepository struct {
+	DB *sqlx.DB
+}
+
+// NewAuditRepository 实例化 pgAuditRepository
+func NewAuditRepository(db *sqlx.DB) model.AuditRepository {
+	return &pgAuditRepository{
+		DB: db,
+	}
+}
+
+// Create 写入审计事件
+func (r *pgAuditRepository) Create(ctx context.Context, e *model.AuditEvent) error {
+	query := `
+		INSERT INTO audit_log (actor_id, target_id, action, outcome, ip, user_agent, details)
+		VALUES (:actor_id, :target_id, :action, :outcome, :ip, :user_agent, :details)
+		RETURNING id, occurred_at;
+	`
+	nsmt, err := conn(ctx, r.DB).PrepareNamedContext(ctx, query)
+	if err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to prepare audit insert query")
+		return apperrors.NewInternal()
+	}
+	defer nsmt.Close()
+
+	if err := nsmt.GetContext(ctx, e, e); err != nil {
+		logging.Default().WithContext(ctx).WithField("action", e.Action).WithError(err).Error("Unable to insert audit event")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// List 按条件分页查询审计事件，按 id 倒序排列
+func (r *pgAuditRepository) List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	var conds []string
+	var args []interface{}
+
+	// 追加参数并返回占位符
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.UserID != nil {
+		p := arg(*q.UserID)
+		conds = append(conds, fmt.Sprintf("(actor_id = %s OR target_id = %s)", p, p))
+	}
+
+	if q.ActorID != nil {
+		conds = append(conds, "actor_id = "+arg(*q.ActorID))
+	}
+
+	if q.TargetID != nil {
+		conds = append(conds, "target_id = "+arg(*q.TargetID))
+	}
+
+	if q.Action != "" {
+		conds = append(conds, "action = "+arg(q.Action))
+	}
+
+	if q.Outcome != "" {
+		conds = append(conds, "outcome = "+arg(q.Outcome))
+	}
+
+	if q.Since != nil {
+		conds = append(conds, "occurred_at >= "+arg(*q.Since))
+	}
+
+	if q.Until != nil {
+		conds = append(conds, "occurred_at < "+arg(*q.Until))
+	}
+
+	if q.Cursor != "" {
+		beforeID, err := strconv.ParseInt(q.Cursor, 10, 64)
+		if err != nil {
+			return nil, apperrors.NewBadRequest("invalid cursor")
+		}
+		conds = append(conds, "id < "+arg(beforeID))
+	}
+
+	query := "SELECT * FROM audit_log"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// 多取一条用于判断是否还有下一页
+	query += " ORDER BY id DESC LIMIT " + arg(q.Limit+1) + ";"
+
+	events := []*model.AuditEvent{}
+	if err := conn(ctx, r.DB).SelectContext(ctx, &events, query, args...); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to list audit events")
+		return nil, apperrors.NewInternal()
+	}
+
+	page := &model.AuditPage{Events: events}
+	if len(events) > q.Limit {
+		page.Events = events[:q.Limit]
+		page.NextCursor = strconv.FormatInt(page.Events[q.Limit-1].ID, 10)
+	}
+
+	return page, nil
+}
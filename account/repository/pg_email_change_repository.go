@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pgEmailChangeRepository 待确认邮箱修改的 Postgres 存储实现
+type pgEmailChangeRepository struct {
+	DB *sqlx.DB
+}
+
+// NewEmailChangeRepository 实例化 pgEmailChangeRepository
+func NewEmailChangeRepository(db *sqlx.DB) model.EmailChangeRepository {
+	return &pgEmailChangeRepository{
+		DB: db,
+	}
+}
+
+// Save 保存待确认的邮箱修改，覆盖用户之前的请求
+func (r *pgEmailChangeRepository) Save(ctx context.Context, c *model.EmailChange) error {
+	query := `
+		INSERT INTO email_changes (uid, new_email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (uid) DO UPDATE
+		SET new_email=EXCLUDED.new_email, token_hash=EXCLUDED.token_hash, expires_at=EXCLUDED.expires_at, created_at=now()
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, c, query, c.UID, c.NewEmail, c.TokenHash, c.ExpiresAt); err != nil {
+		logging.Default().WithContext(ctx).WithField("uid", c.UID).WithError(err).Error("Unable to save email change")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Take 读取并删除 token 对应的邮箱修改，保证 token 只能使用一次
+func (r *pgEmailChangeRepository) Take(ctx context.Context, tokenHash string) (*model.EmailChange, error) {
+	c := &model.EmailChange{}
+
+	query := "DELETE FROM email_changes WHERE token_hash=$1 RETURNING *;"
+
+	if err := conn(ctx, r.DB).GetContext(ctx, c, query, tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("email change", "token")
+		}
+
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to take email change")
+		return nil, apperrors.NewInternal()
+	}
+
+	return c, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// pgEventRepository 领域事件 outbox 存储层实现
+type pgEventRepository struct {
+	DB *sqlx.DB
+}
+
+// NewEventRepository 实例化 pgEventRepository
+func NewEventRepository(db *sqlx.DB) model.EventRepository {
+	return &pgEventRepository{
+		DB: db,
+	}
+}
+
+// Append 写入 outbox，如果上下文中存在事务则与业务数据一起提交
+func (r *pgEventRepository) Append(ctx context.Context, e *model.Event) error {
+	query := `
+		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5);
+	`
+
+	// payload 以字符串传入，避免被编码为 bytea
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, e.ID, e.Type, e.AggregateID, string(e.Payload), e.OccurredAt); err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"event_type":   e.Type,
+			"aggregate_id": e.AggregateID,
+		}).WithError(err).Error("Unable to append event")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// LockUnpublished 按发生顺序锁定未发布的事件，需要在事务中调用
+// 已被其他 relay 锁定的行和 dead 状态的事件会被跳过
+func (r *pgEventRepository) LockUnpublished(ctx context.Context, limit int) ([]*model.Event, error) {
+	query := `
+		SELECT * FROM outbox_events
+		WHERE published_at IS NULL AND dead_at IS NULL
+		ORDER BY occurred_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED;
+	`
+
+	events := []*model.Event{}
+	if err := conn(ctx, r.DB).SelectContext(ctx, &events, query, limit); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to lock unpublished events")
+		return nil, apperrors.NewInternal()
+	}
+
+	return events, nil
+}
+
+// MarkPublished 标记事件已发布
+func (r *pgEventRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	query := "UPDATE outbox_events SET published_at=now(), attempts=attempts+1, last_error='' WHERE id=$1;"
+
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, id); err != nil {
+		logging.Default().WithContext(ctx).WithField("event_id", id).WithError(err).Error("Unable to mark event as published")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// MarkFailed 记录发布失败的原因，事件会在下次轮询时重试
+func (r *pgEventRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	query := "UPDATE outbox_events SET attempts=attempts+1, last_error=$2 WHERE id=$1;"
+
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, id, reason); err != nil {
+		logging.Default().WithContext(ctx).WithField("event_id", id).WithError(err).Error("Unable to mark event as failed")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// MarkDead 记录最后一次失败的原因并停止重试
+func (r *pgEventRepository) MarkDead(ctx context.Context, id uuid.UUID, reason string) error {
+	query := "UPDATE outbox_events SET attempts=attempts+1, last_error=$2, dead_at=now() WHERE id=$1;"
+
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, id, reason); err != nil {
+		logging.Default().WithContext(ctx).WithField("event_id", id).WithError(err).Error("Unable to mark event as dead")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pgHandleRedirectRepository 旧 handle 重定向记录的 Postgres 存储实现
+type pgHandleRedirectRepository struct {
+	DB *sqlx.DB
+}
+
+// NewHandleRedirectRepository 实例化 pgHandleRedirectRepository
+func NewHandleRedirectRepository(db *sqlx.DB) model.HandleRedirectRepository {
+	return &pgHandleRedirectRepository{
+		DB: db,
+	}
+}
+
+// Save 保存重定向记录，同一 handle 的旧记录被覆盖
+func (r *pgHandleRedirectRepository) Save(ctx context.Context, hr *model.HandleRedirect) error {
+	query := `
+		INSERT INTO handle_redirects (handle, uid, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (handle) DO UPDATE
+		SET uid=EXCLUDED.uid, expires_at=EXCLUDED.expires_at, created_at=now()
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, hr, query, hr.Handle, hr.UID, hr.ExpiresAt); err != nil {
+		logging.Default().WithContext(ctx).WithField("handle", hr.Handle).WithError(err).Error("Unable to save handle redirect")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Find 查找未过期的重定向记录
+func (r *pgHandleRedirectRepository) Find(ctx context.Context, handle string) (*model.HandleRedirect, error) {
+	hr := &model.HandleRedirect{}
+
+	query := "SELECT * FROM handle_redirects WHERE handle=$1 AND expires_at > now();"
+
+	if err := conn(ctx, r.DB).GetContext(ctx, hr, query, handle); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("handle", handle)
+		}
+
+		logging.Default().WithContext(ctx).WithField("handle", handle).WithError(err).Error("Unable to find handle redirect")
+		return nil, apperrors.NewInternal()
+	}
+
+	return hr, nil
+}
+
+// Delete 删除重定向记录，记录不存在时不返回错误
+func (r *pgHandleRedirectRepository) Delete(ctx context.Context, handle string) error {
+	query := "DELETE FROM handle_redirects WHERE handle=$1;"
+
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, handle); err != nil {
+		logging.Default().WithContext(ctx).WithField("handle", handle).WithError(err).Error("Unable to delete handle redirect")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
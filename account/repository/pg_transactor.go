@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// txKey 上下文中保存事务的 key
+type txKey struct{}
+
+// dbConn *sqlx.DB 和 *sqlx.Tx 的公共方法
+type dbConn interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
+}
+
+// conn 如果上下文中存在事务则使用事务，否则使用连接池
+func conn(ctx context.Context, db *sqlx.DB) dbConn {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// pgTransactor Postgres 事务管理实现
+type pgTransactor struct {
+	DB *sqlx.DB
+}
+
+// NewTransactor 实例化 pgTransactor
+func NewTransactor(db *sqlx.DB) model.Transactor {
+	return &pgTransactor{
+		DB: db,
+	}
+}
+
+// WithinTransaction 在同一个事务中执行 fn，fn 返回错误时回滚
+// 嵌套调用时在外层事务中使用 savepoint，fn 返回错误时只回滚到 savepoint，外层事务仍可继续使用
+func (t *pgTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return withinSavepoint(ctx, tx, fn)
+	}
+
+	tx, err := t.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to begin transaction")
+		return apperrors.NewInternal()
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logging.Default().WithContext(ctx).WithError(rbErr).Error("Unable to rollback transaction")
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to commit transaction")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// withinSavepoint 在 savepoint 中执行 fn
+// 同名 savepoint 可以嵌套，ROLLBACK TO 和 RELEASE 作用于最近的一个
+func withinSavepoint(ctx context.Context, tx *sqlx.Tx, fn func(ctx context.Context) error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT nested;"); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to create savepoint")
+		return apperrors.NewInternal()
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested;")
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested;"); rbErr != nil {
+			logging.Default().WithContext(ctx).WithError(rbErr).Error("Unable to rollback to savepoint")
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT nested;"); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to release savepoint")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
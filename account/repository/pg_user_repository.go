@@ -2,9 +2,15 @@ package repository
 
 import (
 	"context"
-	"log"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"memrizr/logging"
 	"memrizr/model"
 	"memrizr/model/apperrors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -29,22 +35,40 @@ func (r *pgUserRepository) FindByID(ctx context.Context, uid uuid.UUID) (*model.
 
 	query := "SELECT * FROM users WHERE uid=$1;"
 
-	if err := r.DB.GetContext(ctx, user, query, uid); err != nil {
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, uid); err != nil {
 		return user, apperrors.NewNotFound("uid", uid.String())
 	}
 
 	return user, nil
 }
 
-// FindByEmail 通过 Email 查找用户
+// FindByEmail 通过 Email 查找用户，不区分大小写
 func (r *pgUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	user := &model.User{}
 
-	query := "SELECT * FROM users WHERE email=$1;"
+	query := "SELECT * FROM users WHERE lower(email)=lower($1);"
 
-	if err := r.DB.GetContext(ctx, user, query, email); err != nil {
-		log.Printf("Unable to get user with email address: %v. Err: %v\n", email, err)
-		return user, apperrors.NewNotFound("email", email)
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, email); err != nil {
+		if err == sql.ErrNoRows {
+			logging.Default().WithContext(ctx).WithField("email", email).Debug("No user with email address")
+			return user, apperrors.NewNotFound("email", email)
+		}
+
+		logging.Default().WithContext(ctx).WithField("email", email).WithError(err).Error("Unable to get user with email address")
+		return user, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// FindByHandle 通过 handle 查找用户，handle 需要已经转为小写
+func (r *pgUserRepository) FindByHandle(ctx context.Context, handle string) (*model.User, error) {
+	user := &model.User{}
+
+	query := "SELECT * FROM users WHERE handle=$1 AND handle <> '';"
+
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, handle); err != nil {
+		return user, apperrors.NewNotFound("handle", handle)
 	}
 
 	return user, nil
@@ -54,14 +78,14 @@ func (r *pgUserRepository) FindByEmail(ctx context.Context, email string) (*mode
 func (r *pgUserRepository) Create(ctx context.Context, u *model.User) error {
 	query := "INSERT INTO users (email, password) VALUES ($1, $2) RETURNING *;"
 
-	if err := r.DB.GetContext(ctx, u, query, u.Email, u.Password); err != nil {
+	if err := conn(ctx, r.DB).GetContext(ctx, u, query, u.Email, u.Password); err != nil {
 		// 检验 唯一
 		if err, ok := err.(*pq.Error); ok && err.Code.Name() == "unique_violation" {
-			log.Printf("Could not create a user with email: %v. Reason: %v\n", u.Email, err.Code.Name())
+			logging.Default().WithContext(ctx).WithField("email", u.Email).WithField("reason", err.Code.Name()).Info("Could not create a user")
 			return apperrors.NewConflict("email", u.Email)
 		}
 
-		log.Printf("Could not create a user with email: %v. Reason: %v\n", u.Email, err)
+		logging.Default().WithContext(ctx).WithField("email", u.Email).WithError(err).Error("Could not create a user")
 		return apperrors.NewInternal()
 	}
 
@@ -69,23 +93,333 @@ func (r *pgUserRepository) Create(ctx context.Context, u *model.User) error {
 }
 
 // Update 更新用户
+// u.Version 大于 0 时只在版本号一致时更新
 func (r *pgUserRepository) Update(ctx context.Context, u *model.User) error {
 	query := `
 		UPDATE users
-		SET name=:name, email=:email, website=:website
+		SET name=:name, email=:email, website=:website, version=version+1, updated_at=now()
+		WHERE uid=:uid AND (:version = 0 OR version=:version)
+		RETURNING *;
+	`
+	nsmt, err := conn(ctx, r.DB).PrepareNamedContext(ctx, query)
+	if err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to prepare user update query")
+		return apperrors.NewInternal()
+	}
+
+	expected := u.Version
+	if err := nsmt.GetContext(ctx, u, u); err != nil {
+		if err == sql.ErrNoRows && expected > 0 {
+			return apperrors.NewPreconditionFailed("user has been modified by another request")
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", u.UID).WithError(err).Error("Unable to update details for user")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// userPatchColumns 允许部分更新的列，按固定顺序生成 SET 子句
+var userPatchColumns = []string{model.FieldName, model.FieldEmail, model.FieldWebsite}
+
+// Patch 只更新 p 中出现的字段，值为 nil 的字段恢复为列默认值
+// version 大于 0 时只在版本号一致时更新
+func (r *pgUserRepository) Patch(ctx context.Context, uid uuid.UUID, version int64, p model.UserPatch) (*model.User, error) {
+	var sets []string
+	var args []interface{}
+
+	// 追加参数并返回占位符
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, col := range userPatchColumns {
+		v, ok := p[col]
+		if !ok {
+			continue
+		}
+
+		if v == nil {
+			sets = append(sets, col+"=DEFAULT")
+			continue
+		}
+		sets = append(sets, col+"="+arg(*v))
+	}
+	sets = append(sets, "version=version+1", "updated_at=now()")
+
+	conds := []string{"uid=" + arg(uid)}
+	if version > 0 {
+		conds = append(conds, "version="+arg(version))
+	}
+
+	query := fmt.Sprintf("UPDATE users SET %s WHERE %s RETURNING *;", strings.Join(sets, ", "), strings.Join(conds, " AND "))
+
+	user := &model.User{}
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			if version > 0 {
+				return nil, apperrors.NewPreconditionFailed("user has been modified by another request")
+			}
+			return nil, apperrors.NewNotFound("uid", uid.String())
+		}
+
+		// 可修改的字段中只有 email 有唯一约束
+		if email, ok := p[model.FieldEmail]; ok && email != nil {
+			if err, ok := err.(*pq.Error); ok && err.Code.Name() == "unique_violation" {
+				logging.Default().WithContext(ctx).WithField("uid", uid).WithField("reason", err.Code.Name()).Info("Could not patch user")
+				return nil, apperrors.NewConflict("email", *email)
+			}
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to patch details for user")
+		return nil, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// List 按条件分页查询用户，按创建时间倒序排列
+func (r *pgUserRepository) List(ctx context.Context, q *model.UserListQuery) (*model.UserPage, error) {
+	var conds []string
+	var args []interface{}
+
+	// 追加参数并返回占位符
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.EmailPrefix != "" {
+		conds = append(conds, "lower(email) LIKE "+arg(escapeLike(strings.ToLower(q.EmailPrefix))+"%"))
+	}
+
+	if q.Name != "" {
+		conds = append(conds, "lower(name) LIKE "+arg("%"+escapeLike(strings.ToLower(q.Name))+"%"))
+	}
+
+	if q.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+arg(*q.CreatedAfter))
+	}
+
+	if q.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+arg(*q.CreatedBefore))
+	}
+
+	if q.Status != "" {
+		conds = append(conds, "status = "+arg(q.Status))
+	}
+
+	if q.Cursor != "" {
+		createdAt, uid, err := decodeUserCursor(q.Cursor)
+		if err != nil {
+			logging.Default().WithContext(ctx).WithField("cursor", q.Cursor).WithError(err).Info("Unable to decode user list cursor")
+			return nil, apperrors.NewBadRequest("invalid cursor")
+		}
+		conds = append(conds, fmt.Sprintf("(created_at, uid) < (%s, %s)", arg(createdAt), arg(uid)))
+	}
+
+	query := "SELECT * FROM users"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// 多取一条用于判断是否还有下一页
+	query += " ORDER BY created_at DESC, uid DESC LIMIT " + arg(q.Limit+1) + ";"
+
+	users := []*model.User{}
+	if err := conn(ctx, r.DB).SelectContext(ctx, &users, query, args...); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to list users")
+		return nil, apperrors.NewInternal()
+	}
+
+	page := &model.UserPage{Users: users}
+	if len(users) > q.Limit {
+		page.Users = users[:q.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[q.Limit-1])
+	}
+
+	return page, nil
+}
+
+// Save 更新用户的所有可编辑字段
+func (r *pgUserRepository) Save(ctx context.Context, u *model.User) error {
+	query := `
+		UPDATE users
+		SET name=:name, email=:email, website=:website, image_url=:image_url, image_urls=:image_urls, role=:role, version=version+1, updated_at=now()
 		WHERE uid=:uid
 		RETURNING *;
 	`
-	nsmt, err := r.DB.PrepareNamedContext(ctx, query)
+	nsmt, err := conn(ctx, r.DB).PrepareNamedContext(ctx, query)
 	if err != nil {
-		log.Printf("Unable to prepare user update query: %v\n", err)
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to prepare user save query")
 		return apperrors.NewInternal()
 	}
 
 	if err := nsmt.GetContext(ctx, u, u); err != nil {
-		log.Printf("Unable to update details for user: %v\n", u)
+		if err, ok := err.(*pq.Error); ok && err.Code.Name() == "unique_violation" {
+			return apperrors.NewConflict("email", u.Email)
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", u.UID).WithError(err).Error("Unable to save user")
 		return apperrors.NewInternal()
 	}
 
 	return nil
 }
+
+// UpdateStatus 更新用户状态并记录操作人
+func (r *pgUserRepository) UpdateStatus(ctx context.Context, uid uuid.UUID, c *model.StatusChange) (*model.User, error) {
+	user := &model.User{}
+
+	query := `
+		UPDATE users
+		SET status=$2, status_reason=$3, status_expires_at=$4, status_changed_by=$5, status_changed_at=now(), version=version+1, updated_at=now()
+		WHERE uid=$1
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, uid, c.Status, c.Reason, c.ExpiresAt, c.ActorID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("uid", uid.String())
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to update status for user")
+		return nil, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// UpdatePassword 更新密码哈希
+func (r *pgUserRepository) UpdatePassword(ctx context.Context, uid uuid.UUID, password string) (*model.User, error) {
+	user := &model.User{}
+
+	query := `
+		UPDATE users
+		SET password=$2, version=version+1, updated_at=now()
+		WHERE uid=$1
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, uid, password); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("uid", uid.String())
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to update password for user")
+		return nil, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// UpdateImage 更新用户各尺寸的头像地址
+func (r *pgUserRepository) UpdateImage(ctx context.Context, uid uuid.UUID, urls model.ImageURLs) (*model.User, error) {
+	user := &model.User{}
+
+	query := `
+		UPDATE users
+		SET image_url=$2, image_urls=$3, version=version+1, updated_at=now()
+		WHERE uid=$1
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, uid, urls.Largest(), urls); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("uid", uid.String())
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to update image for user")
+		return nil, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// UpdatePrivacy 更新用户公开资料的可见性设置
+func (r *pgUserRepository) UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *model.ProfilePrivacy) (*model.User, error) {
+	user := &model.User{}
+
+	query := `
+		UPDATE users
+		SET profile_public=$2, show_website=$3, show_avatar=$4, version=version+1, updated_at=now()
+		WHERE uid=$1
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, uid, p.Public, p.ShowWebsite, p.ShowAvatar); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("uid", uid.String())
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to update privacy for user")
+		return nil, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// UpdateHandle 更新用户 handle，空字符串表示清除
+func (r *pgUserRepository) UpdateHandle(ctx context.Context, uid uuid.UUID, handle string) (*model.User, error) {
+	user := &model.User{}
+
+	query := `
+		UPDATE users
+		SET handle=$2, version=version+1, updated_at=now()
+		WHERE uid=$1
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, user, query, uid, handle); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("uid", uid.String())
+		}
+
+		if err, ok := err.(*pq.Error); ok && err.Code.Name() == "unique_violation" {
+			logging.Default().WithContext(ctx).WithField("uid", uid).WithField("reason", err.Code.Name()).Info("Could not set handle for user")
+			return nil, apperrors.NewConflict("handle", handle)
+		}
+
+		logging.Default().WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to update handle for user")
+		return nil, apperrors.NewInternal()
+	}
+
+	return user, nil
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// encodeUserCursor 将最后一条记录的排序键编码为游标
+func encodeUserCursor(u *model.User) string {
+	raw := fmt.Sprintf("%d:%s", u.CreatedAt.UnixNano(), u.UID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeUserCursor 解析游标中的排序键
+func decodeUserCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor")
+	}
+
+	nsec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	uid, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	return time.Unix(0, nsec).UTC(), uid, nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// pgWebhookRepository webhook 存储层实现
+type pgWebhookRepository struct {
+	DB *sqlx.DB
+}
+
+// NewWebhookRepository 实例化 pgWebhookRepository
+func NewWebhookRepository(db *sqlx.DB) model.WebhookRepository {
+	return &pgWebhookRepository{
+		DB: db,
+	}
+}
+
+// Create 创建 webhook
+func (r *pgWebhookRepository) Create(ctx context.Context, w *model.Webhook) error {
+	query := `
+		INSERT INTO webhooks (url, secret, event_types, description, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, w, query, w.URL, w.Secret, w.EventTypes, w.Description, w.Active, w.CreatedBy); err != nil {
+		logging.Default().WithContext(ctx).WithField("url", w.URL).WithError(err).Error("Could not create webhook")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// FindByID 通过 ID 查找 webhook
+func (r *pgWebhookRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	w := &model.Webhook{}
+
+	query := "SELECT * FROM webhooks WHERE id=$1;"
+
+	if err := conn(ctx, r.DB).GetContext(ctx, w, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("webhook", id.String())
+		}
+
+		logging.Default().WithContext(ctx).WithField("webhook_id", id).WithError(err).Error("Unable to get webhook")
+		return nil, apperrors.NewInternal()
+	}
+
+	return w, nil
+}
+
+// List 获取所有 webhook
+func (r *pgWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	webhooks := []*model.Webhook{}
+
+	query := "SELECT * FROM webhooks ORDER BY created_at;"
+
+	if err := conn(ctx, r.DB).SelectContext(ctx, &webhooks, query); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to list webhooks")
+		return nil, apperrors.NewInternal()
+	}
+
+	return webhooks, nil
+}
+
+// Update 更新 webhook 的地址、订阅和状态
+func (r *pgWebhookRepository) Update(ctx context.Context, w *model.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url=$2, event_types=$3, description=$4, active=$5, updated_at=now()
+		WHERE id=$1
+		RETURNING *;
+	`
+
+	if err := conn(ctx, r.DB).GetContext(ctx, w, query, w.ID, w.URL, w.EventTypes, w.Description, w.Active); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NewNotFound("webhook", w.ID.String())
+		}
+
+		logging.Default().WithContext(ctx).WithField("webhook_id", w.ID).WithError(err).Error("Unable to update webhook")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Delete 删除 webhook 及其投递记录
+func (r *pgWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM webhooks WHERE id=$1;"
+
+	result, err := conn(ctx, r.DB).ExecContext(ctx, query, id)
+	if err != nil {
+		logging.Default().WithContext(ctx).WithField("webhook_id", id).WithError(err).Error("Unable to delete webhook")
+		return apperrors.NewInternal()
+	}
+
+	if n, _ := result.RowsAffected(); n < 1 {
+		return apperrors.NewNotFound("webhook", id.String())
+	}
+
+	return nil
+}
+
+// FindSubscribed 获取订阅了事件类型的启用中的 webhook
+func (r *pgWebhookRepository) FindSubscribed(ctx context.Context, eventType string) ([]*model.Webhook, error) {
+	webhooks := []*model.Webhook{}
+
+	query := "SELECT * FROM webhooks WHERE active AND event_types @> ARRAY[$1]::text[];"
+
+	if err := conn(ctx, r.DB).SelectContext(ctx, &webhooks, query, eventType); err != nil {
+		logging.Default().WithContext(ctx).WithField("event_type", eventType).WithError(err).Error("Unable to find webhooks for event type")
+		return nil, apperrors.NewInternal()
+	}
+
+	return webhooks, nil
+}
+
+// CreateDelivery 创建投递记录，同一 webhook 的重复事件会被忽略
+func (r *pgWebhookRepository) CreateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING;
+	`
+
+	// payload 以字符串传入，避免被编码为 bytea
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, d.WebhookID, d.EventID, d.EventType, string(d.Payload)); err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"event_id":   d.EventID,
+			"webhook_id": d.WebhookID,
+		}).WithError(err).Error("Unable to create delivery")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// FindDelivery 查找 webhook 的投递记录
+func (r *pgWebhookRepository) FindDelivery(ctx context.Context, webhookID uuid.UUID, id int64) (*model.WebhookDelivery, error) {
+	d := &model.WebhookDelivery{}
+
+	query := "SELECT * FROM webhook_deliveries WHERE webhook_id=$1 AND id=$2;"
+
+	if err := conn(ctx, r.DB).GetContext(ctx, d, query, webhookID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NewNotFound("delivery", strconv.FormatInt(id, 10))
+		}
+
+		logging.Default().WithContext(ctx).WithField("delivery_id", id).WithError(err).Error("Unable to get delivery")
+		return nil, apperrors.NewInternal()
+	}
+
+	return d, nil
+}
+
+// ClaimDueDeliveries 领取到期的投递记录
+// 领取时将下次尝试时间推迟 lease，避免其他实例重复投递，处理完成后由 UpdateDelivery 覆盖
+func (r *pgWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = now() + $2 * interval '1 millisecond', updated_at = now()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *;
+	`
+
+	deliveries := []*model.WebhookDelivery{}
+	if err := conn(ctx, r.DB).SelectContext(ctx, &deliveries, query, limit, lease.Milliseconds()); err != nil {
+		logging.Default().WithContext(ctx).WithError(err).Error("Unable to claim due deliveries")
+		return nil, apperrors.NewInternal()
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery 保存投递结果
+func (r *pgWebhookRepository) UpdateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status=$2, attempts=$3, next_attempt_at=$4, last_status_code=$5, last_error=$6, delivered_at=$7, updated_at=now()
+		WHERE id=$1;
+	`
+
+	if _, err := conn(ctx, r.DB).ExecContext(ctx, query, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt); err != nil {
+		logging.Default().WithContext(ctx).WithField("delivery_id", d.ID).WithError(err).Error("Unable to update delivery")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// ListDeliveries 分页查询 webhook 的投递记录，按 id 倒序排列
+func (r *pgWebhookRepository) ListDeliveries(ctx context.Context, q *model.WebhookDeliveryQuery) (*model.WebhookDeliveryPage, error) {
+	var conds []string
+	var args []interface{}
+
+	// 追加参数并返回占位符
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conds = append(conds, "webhook_id = "+arg(q.WebhookID))
+
+	if q.Status != "" {
+		conds = append(conds, "status = "+arg(q.Status))
+	}
+
+	if q.Cursor != "" {
+		beforeID, err := strconv.ParseInt(q.Cursor, 10, 64)
+		if err != nil {
+			return nil, apperrors.NewBadRequest("invalid cursor")
+		}
+		conds = append(conds, "id < "+arg(beforeID))
+	}
+
+	query := "SELECT * FROM webhook_deliveries WHERE " + strings.Join(conds, " AND ")
+	// 多取一条用于判断是否还有下一页
+	query += " ORDER BY id DESC LIMIT " + arg(q.Limit+1) + ";"
+
+	deliveries := []*model.WebhookDelivery{}
+	if err := conn(ctx, r.DB).SelectContext(ctx, &deliveries, query, args...); err != nil {
+		logging.Default().WithContext(ctx).WithField("webhook_id", q.WebhookID).WithError(err).Error("Unable to list deliveries for webhook")
+		return nil, apperrors.NewInternal()
+	}
+
+	page := &model.WebhookDeliveryPage{Deliveries: deliveries}
+	if len(deliveries) > q.Limit {
+		page.Deliveries = deliveries[:q.Limit]
+		page.NextCursor = strconv.FormatInt(page.Deliveries[q.Limit-1].ID, 10)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// redisEventBroker 使用 Redis Streams 发布领域事件
+type redisEventBroker struct {
+	Redis  *redis.Client
+	Stream string
+	MaxLen int64
+}
+
+// NewRedisEventBroker 实例化 redisEventBroker
+// maxLen 大于 0 时近似裁剪 stream 长度
+func NewRedisEventBroker(redisClient *redis.Client, stream string, maxLen int64) model.EventBroker {
+	return &redisEventBroker{
+		Redis:  redisClient,
+		Stream: stream,
+		MaxLen: maxLen,
+	}
+}
+
+// Publish 追加事件到 stream，消费者使用 id 字段去重
+func (b *redisEventBroker) Publish(ctx context.Context, e *model.Event) error {
+	args := &redis.XAddArgs{
+		Stream: b.Stream,
+		Values: map[string]interface{}{
+			"id":           e.ID.String(),
+			"type":         e.Type,
+			"aggregate_id": e.AggregateID.String(),
+			"occurred_at":  e.OccurredAt.UTC().Format(time.RFC3339Nano),
+			"payload":      string(e.Payload),
+		},
+	}
+
+	if b.MaxLen > 0 {
+		args.MaxLen = b.MaxLen
+		args.Approx = true
+	}
+
+	if err := b.Redis.XAdd(ctx, args).Err(); err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"event_id": e.ID,
+			"stream":   b.Stream,
+		}).WithError(err).Error("Could not XADD event")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// logEventBroker 只记录日志的事件发布实现，用于本地开发
+type logEventBroker struct{}
+
+// NewLogEventBroker 实例化 logEventBroker
+func NewLogEventBroker() model.EventBroker {
+	return &logEventBroker{}
+}
+
+// Publish 将事件写入日志
+func (b *logEventBroker) Publish(ctx context.Context, e *model.Event) error {
+	logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+		"event_id":     e.ID,
+		"event_type":   e.Type,
+		"aggregate_id": e.AggregateID,
+		"payload":      string(e.Payload),
+	}).Info("Event published")
+	return nil
+}
@@ -3,12 +3,13 @@ package repository
 import (
 	"context"
 	"fmt"
-	"log"
+	"memrizr/logging"
 	"memrizr/model"
 	"memrizr/model/apperrors"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
 )
 
 type redisTokenRepository struct {
@@ -25,7 +26,10 @@ func NewTokenRepository(redisClient *redis.Client) model.TokenRepository {
 func (r *redisTokenRepository) SetRefreshToken(ctx context.Context, userID string, tokenID string, expiresIn time.Duration) error {
 	key := fmt.Sprintf("%s:%s", userID, tokenID)
 	if err := r.Redis.Set(ctx, key, 0, expiresIn).Err(); err != nil {
-		log.Printf("Could not SET refresh token to redis for userID/tokenID: %s/%s: %v\n", userID, tokenID, err)
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"uid":      userID,
+			"token_id": tokenID,
+		}).WithError(err).Error("Could not SET refresh token to redis")
 		return apperrors.NewInternal()
 	}
 
@@ -39,12 +43,18 @@ func (r *redisTokenRepository) DeleteRefreshToken(ctx context.Context, userID st
 	result := r.Redis.Del(ctx, key)
 
 	if err := result.Err(); err != nil {
-		log.Printf("Could not delete refresh token to redis for userID/tokenID: %s/%s: %v\n", userID, prevTokenID, err)
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"uid":      userID,
+			"token_id": prevTokenID,
+		}).WithError(err).Error("Could not delete refresh token from redis")
 		return apperrors.NewInternal()
 	}
 
 	if result.Val() < 1 {
-		log.Printf("Refresh token to redis for userID/TokenID: %s/%s does not exists\n", userID, prevTokenID)
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"uid":      userID,
+			"token_id": prevTokenID,
+		}).Info("Refresh token does not exist in redis")
 		return apperrors.NewAuthorization("Invalid refresh token")
 	}
 
@@ -60,14 +70,14 @@ func (r *redisTokenRepository) DeleteUserRefreshTokens(ctx context.Context, user
 
 	for iter.Next(ctx) {
 		if err := r.Redis.Del(ctx, iter.Val()).Err(); err != nil {
-			log.Printf("Failed to delete refresh token: %s", iter.Val())
+			logging.Default().WithContext(ctx).WithField("key", iter.Val()).WithError(err).Error("Failed to delete refresh token")
 			failCount++
 		}
 	}
 
 	// 检验最后的值
 	if err := iter.Err(); err != nil {
-		log.Printf("Failed to delete refresh token: %s\n", iter.Val())
+		logging.Default().WithContext(ctx).WithField("key", iter.Val()).WithError(err).Error("Failed to delete refresh token")
 	}
 
 	if failCount > 0 {
@@ -76,3 +86,22 @@ func (r *redisTokenRepository) DeleteUserRefreshTokens(ctx context.Context, user
 
 	return nil
 }
+
+// CountUserRefreshTokens 统计用户有效的 refresh token 数量，即活跃会话数
+func (r *redisTokenRepository) CountUserRefreshTokens(ctx context.Context, userID string) (int, error) {
+	pattern := fmt.Sprintf("%s*", userID)
+
+	iter := r.Redis.Scan(ctx, 0, pattern, 5).Iterator()
+	count := 0
+
+	for iter.Next(ctx) {
+		count++
+	}
+
+	if err := iter.Err(); err != nil {
+		logging.Default().WithContext(ctx).WithField("uid", userID).WithError(err).Error("Failed to count refresh tokens")
+		return 0, apperrors.NewInternal()
+	}
+
+	return count, nil
+}
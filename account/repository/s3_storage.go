@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/sirupsen/logrus"
+)
+
+// s3Storage 基于 S3 兼容接口的对象存储，适用于 AWS S3、MinIO 等
+type s3Storage struct {
+	Client *minio.Client
+	Bucket string
+	Prefix string
+}
+
+// NewS3Storage 实例化 s3Storage
+// prefix 为所有 key 的公共前缀，便于多个服务共用一个 bucket
+func NewS3Storage(client *minio.Client, bucket string, prefix string) model.ObjectStorage {
+	return &s3Storage{
+		Client: client,
+		Bucket: bucket,
+		Prefix: prefix,
+	}
+}
+
+// Put 上传对象
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	opts := minio.PutObjectOptions{
+		ContentType: contentType,
+	}
+
+	if _, err := s.Client.PutObject(ctx, s.Bucket, s.objectName(key), r, size, opts); err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"key":    key,
+			"bucket": s.Bucket,
+		}).WithError(err).Error("Unable to put object")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// Get 读取对象
+func (s *s3Storage) Get(ctx context.Context, key string) (*model.Object, error) {
+	obj, err := s.Client.GetObject(ctx, s.Bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"key":    key,
+			"bucket": s.Bucket,
+		}).WithError(err).Error("Unable to get object")
+		return nil, apperrors.NewInternal()
+	}
+
+	// GetObject 不发送请求，Stat 时才能知道对象是否存在
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, apperrors.NewNotFound("object", key)
+		}
+
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"key":    key,
+			"bucket": s.Bucket,
+		}).WithError(err).Error("Unable to stat object")
+		return nil, apperrors.NewInternal()
+	}
+
+	return &model.Object{
+		Body:        obj,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+// Delete 删除对象，S3 删除不存在的对象不会报错
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	if err := s.Client.RemoveObject(ctx, s.Bucket, s.objectName(key), minio.RemoveObjectOptions{}); err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"key":    key,
+			"bucket": s.Bucket,
+		}).WithError(err).Error("Unable to delete object")
+		return apperrors.NewInternal()
+	}
+
+	return nil
+}
+
+// objectName 拼接 key 前缀
+func (s *s3Storage) objectName(key string) string {
+	if s.Prefix == "" {
+		return key
+	}
+	return path.Join(s.Prefix, key)
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 进程内的 S3 替身，只实现对象的增删查
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeS3Object
+}
+
+type fakeS3Object struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeS3Object{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// testStorageContract 所有存储实现都需要满足的行为
+func testStorageContract(t *testing.T, s model.ObjectStorage) {
+	ctx := context.TODO()
+	content := []byte("\x89PNG\r\n\x1a\nfake image")
+
+	t.Run("Put and Get", func(t *testing.T) {
+		err := s.Put(ctx, "avatars/u1/a.png", bytes.NewReader(content), int64(len(content)), "image/png")
+		assert.NoError(t, err)
+
+		obj, err := s.Get(ctx, "avatars/u1/a.png")
+		assert.NoError(t, err)
+		defer obj.Body.Close()
+
+		data, err := io.ReadAll(obj.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, content, data)
+		assert.Equal(t, "image/png", obj.ContentType)
+		assert.Equal(t, int64(len(content)), obj.Size)
+	})
+
+	t.Run("Get missing object", func(t *testing.T) {
+		_, err := s.Get(ctx, "avatars/u1/missing.png")
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+
+	t.Run("Delete is idempotent", func(t *testing.T) {
+		assert.NoError(t, s.Delete(ctx, "avatars/u1/a.png"))
+		assert.NoError(t, s.Delete(ctx, "avatars/u1/a.png"))
+
+		_, err := s.Get(ctx, "avatars/u1/a.png")
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+}
+
+func TestLocalStorage(t *testing.T) {
+	testStorageContract(t, NewLocalStorage(t.TempDir()))
+
+	t.Run("Keys cannot escape the directory", func(t *testing.T) {
+		s := &localStorage{Dir: "/srv/media"}
+		assert.Equal(t, "/srv/media/etc/passwd", s.filePath("../../etc/passwd"))
+	})
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := &fakeS3{objects: map[string]fakeS3Object{}}
+	server := httptest.NewTLSServer(fake)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:     credentials.NewStaticV4("access", "secret", ""),
+		Secure:    true,
+		Region:    "us-east-1",
+		Transport: server.Client().Transport,
+	})
+	assert.NoError(t, err)
+
+	s := NewS3Storage(client, "memrizr", "account")
+	testStorageContract(t, s)
+
+	t.Run("Object names include bucket and prefix", func(t *testing.T) {
+		err := s.Put(context.TODO(), "avatars/u2/b.png", strings.NewReader("x"), 1, "image/png")
+		assert.NoError(t, err)
+
+		_, ok := fake.objects["/memrizr/account/avatars/u2/b.png"]
+		assert.True(t, ok)
+	})
+}
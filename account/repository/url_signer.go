@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// urlSigner 使用 HMAC-SHA256 签发对象访问地址
+// 公开对象的地址不带签名，私有对象的地址为 privateURL/key?expires=<unix>&signature=<hex>
+type urlSigner struct {
+	Secret     []byte
+	PublicURL  string
+	PrivateURL string
+	now        func() time.Time
+}
+
+// NewURLSigner 实例化 urlSigner
+// publicURL 可以指向 CDN 或公开的 bucket 路径，privateURL 为服务自身的 media 路由
+func NewURLSigner(secret string, publicURL string, privateURL string) model.URLSigner {
+	return &urlSigner{
+		Secret:     []byte(secret),
+		PublicURL:  strings.TrimSuffix(publicURL, "/"),
+		PrivateURL: strings.TrimSuffix(privateURL, "/"),
+		now:        time.Now,
+	}
+}
+
+// URL 获取对象的访问地址，ttl 只对私有对象有效
+func (s *urlSigner) URL(key string, ttl time.Duration) string {
+	if model.MediaVisibility(key) == model.VisibilityPublic {
+		return s.PublicURL + "/" + key
+	}
+
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	return s.PrivateURL + "/" + key + "?expires=" + expires + "&signature=" + s.sign(key, expires)
+}
+
+// Verify 校验私有对象地址的签名和过期时间
+func (s *urlSigner) Verify(key string, expires string, signature string) error {
+	if model.MediaVisibility(key) == model.VisibilityPublic {
+		return nil
+	}
+
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || signature == "" {
+		return apperrors.NewForbidden("A valid signature is required")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return apperrors.NewForbidden("Invalid signature")
+	}
+
+	// 先校验签名，避免泄露过期时间是否被篡改
+	if s.now().Unix() > exp {
+		return apperrors.NewForbidden("URL has expired")
+	}
+
+	return nil
+}
+
+// KeyFromURL 从本服务签发的地址中解析 key
+func (s *urlSigner) KeyFromURL(url string) (string, bool) {
+	if i := strings.IndexByte(url, '?'); i >= 0 {
+		url = url[:i]
+	}
+
+	for _, base := range []string{s.PublicURL, s.PrivateURL} {
+		if strings.HasPrefix(url, base+"/") {
+			return strings.TrimPrefix(url, base+"/"), true
+		}
+	}
+
+	return "", false
+}
+
+// sign 计算 key 和过期时间的签名
+func (s *urlSigner) sign(key string, expires string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"memrizr/model/apperrors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := &urlSigner{
+		Secret:     []byte("secret"),
+		PublicURL:  "https://cdn.example.com/media",
+		PrivateURL: "/api/account/media",
+		now:        func() time.Time { return now },
+	}
+
+	// parse 解析签名地址中的参数
+	parse := func(raw string) (string, string, string) {
+		u, err := url.Parse(raw)
+		assert.NoError(t, err)
+		return strings.TrimPrefix(u.Path, "/api/account/media/"), u.Query().Get("expires"), u.Query().Get("signature")
+	}
+
+	t.Run("Public objects are not signed", func(t *testing.T) {
+		u := s.URL("public/avatars/a/64.jpg", time.Minute)
+
+		assert.Equal(t, "https://cdn.example.com/media/public/avatars/a/64.jpg", u)
+		assert.NoError(t, s.Verify("public/avatars/a/64.jpg", "", ""))
+
+		key, ok := s.KeyFromURL(u)
+		assert.True(t, ok)
+		assert.Equal(t, "public/avatars/a/64.jpg", key)
+	})
+
+	t.Run("Legacy avatars stay public", func(t *testing.T) {
+		legacy := "avatars/0b7c8a4e-3f1d-4c2a-9e5b-6d8f7a1c2b3e/5f9e2d1c-8b7a-4e6d-a5c4-3b2a1f0e9d8c/64.jpg"
+		u := s.URL(legacy, time.Minute)
+
+		assert.Equal(t, "https://cdn.example.com/media/"+legacy, u)
+		assert.NoError(t, s.Verify(legacy, "", ""))
+		assert.NoError(t, s.Verify("avatars/0b7c8a4e-3f1d-4c2a-9e5b-6d8f7a1c2b3e/5f9e2d1c-8b7a-4e6d-a5c4-3b2a1f0e9d8c.webp", "", ""))
+	})
+
+	t.Run("Other avatars/ keys are private", func(t *testing.T) {
+		for _, key := range []string{
+			"avatars/a/64.jpg",
+			"avatars/export.csv",
+			"avatars/0b7c8a4e-3f1d-4c2a-9e5b-6d8f7a1c2b3e/cards/c1.pdf",
+		} {
+			assert.Error(t, s.Verify(key, "", ""), key)
+		}
+	})
+
+	t.Run("Private objects verify until expiry", func(t *testing.T) {
+		key, expires, signature := parse(s.URL("private/cards/c1.pdf", time.Minute))
+
+		assert.Equal(t, "private/cards/c1.pdf", key)
+		assert.NoError(t, s.Verify(key, expires, signature))
+
+		now = now.Add(2 * time.Minute)
+		defer func() { now = now.Add(-2 * time.Minute) }()
+
+		err := s.Verify(key, expires, signature)
+		assert.Equal(t, http.StatusForbidden, apperrors.Status(err))
+	})
+
+	t.Run("Tampered URLs are rejected", func(t *testing.T) {
+		key, expires, signature := parse(s.URL("private/cards/c1.pdf", time.Minute))
+
+		assert.Error(t, s.Verify("private/cards/c2.pdf", expires, signature))
+		assert.Error(t, s.Verify(key, expires+"0", signature))
+		assert.Error(t, s.Verify(key, expires, ""))
+		assert.Error(t, s.Verify(key, "", signature))
+	})
+
+	t.Run("Foreign URLs are not ours", func(t *testing.T) {
+		_, ok := s.KeyFromURL("https://gravatar.com/avatar/abc")
+		assert.False(t, ok)
+	})
+}
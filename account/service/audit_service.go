@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"memrizr/logging"
+	"memrizr/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 审计日志分页大小
+const (
+	defaultAuditListLimit = 50
+	maxAuditListLimit     = 200
+)
+
+// auditService 审计日志服务层结构体
+type auditService struct {
+	AuditRepository model.AuditRepository
+	Logger          *logrus.Logger
+}
+
+// ASConfig 审计日志服务层配置结构体
+// Logger 可选，为空时使用 logging.Default
+type ASConfig struct {
+	AuditRepository model.AuditRepository
+	Logger          *logrus.Logger
+}
+
+// NewAuditService 创建实例
+func NewAuditService(c *ASConfig) model.AuditService {
+	return &auditService{
+		AuditRepository: c.AuditRepository,
+		Logger:          logging.OrDefault(c.Logger),
+	}
+}
+
+// Record 记录审计事件，客户端信息从上下文中获取
+// 写入失败只记录日志，不影响业务流程
+func (s *auditService) Record(ctx context.Context, e *model.AuditEvent) {
+	if m := model.RequestMetaFromContext(ctx); m != nil {
+		e.IP = m.IP
+		e.UserAgent = m.UserAgent
+	}
+
+	if e.Outcome == "" {
+		e.Outcome = model.OutcomeSuccess
+	}
+
+	if err := s.AuditRepository.Create(ctx, e); err != nil {
+		s.Logger.WithContext(ctx).WithFields(logrus.Fields{
+			"action":  e.Action,
+			"outcome": e.Outcome,
+		}).WithError(err).Error("Failed to record audit event")
+	}
+}
+
+// List 分页查询审计事件
+func (s *auditService) List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultAuditListLimit
+	}
+
+	if q.Limit > maxAuditListLimit {
+		q.Limit = maxAuditListLimit
+	}
+
+	return s.AuditRepository.List(ctx, q)
+}
+
+// recordAudit 记录审计事件，未配置审计服务时忽略
+func recordAudit(ctx context.Context, s model.AuditService, e *model.AuditEvent) {
+	if s == nil {
+		return
+	}
+	s.Record(ctx, e)
+}
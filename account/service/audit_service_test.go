@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecord(t *testing.T) {
+	t.Run("Fills request meta and default outcome", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockAuditRepository := new(mocks.MockAuditRepository)
+		as := NewAuditService(&ASConfig{
+			AuditRepository: mockAuditRepository,
+		})
+
+		expected := &model.AuditEvent{
+			ActorID:   &uid,
+			TargetID:  &uid,
+			Action:    model.AuditSignout,
+			Outcome:   model.OutcomeSuccess,
+			IP:        "10.0.0.1",
+			UserAgent: "curl/7.79.1",
+		}
+		mockAuditRepository.On("Create", mock.Anything, expected).Return(nil)
+
+		ctx := model.WithRequestMeta(context.TODO(), &model.RequestMeta{
+			IP:        "10.0.0.1",
+			UserAgent: "curl/7.79.1",
+		})
+		as.Record(ctx, &model.AuditEvent{
+			ActorID:  &uid,
+			TargetID: &uid,
+			Action:   model.AuditSignout,
+		})
+
+		mockAuditRepository.AssertExpectations(t)
+	})
+
+	t.Run("Swallows repository errors", func(t *testing.T) {
+		mockAuditRepository := new(mocks.MockAuditRepository)
+		as := NewAuditService(&ASConfig{
+			AuditRepository: mockAuditRepository,
+		})
+
+		mockAuditRepository.On("Create", mock.Anything, mock.AnythingOfType("*model.AuditEvent")).
+			Return(fmt.Errorf("Some error down the call chain"))
+
+		assert.NotPanics(t, func() {
+			as.Record(context.TODO(), &model.AuditEvent{Action: model.AuditSignin})
+		})
+		mockAuditRepository.AssertExpectations(t)
+	})
+}
+
+func TestSigninAudit(t *testing.T) {
+	t.Run("Records failed signin for unknown email", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockAuditService := new(mocks.MockAuditService)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			AuditService:   mockAuditService,
+		})
+
+		mockUserRepository.On("FindByEmail", mock.Anything, "nobody@bob.com").
+			Return(nil, apperrors.NewNotFound("email", "nobody@bob.com"))
+		mockAuditService.On("Record", mock.Anything, &model.AuditEvent{
+			Action:  model.AuditSignin,
+			Outcome: model.OutcomeFailure,
+			Details: model.AuditDetails{"email": "nobody@bob.com", "reason": "unknown email"},
+		}).Return()
+
+		err := us.Signin(context.TODO(), &model.User{
+			Email:    "nobody@bob.com",
+			Password: "howdyhoneighbor!",
+		})
+
+		assert.Error(t, err)
+		mockAuditService.AssertExpectations(t)
+	})
+
+	t.Run("Lookup failure is not an unknown email", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockAuditService := new(mocks.MockAuditService)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			AuditService:   mockAuditService,
+		})
+
+		mockUserRepository.On("FindByEmail", mock.Anything, "bob@bob.com").Return(nil, apperrors.NewInternal())
+
+		err := us.Signin(context.TODO(), &model.User{
+			Email:    "bob@bob.com",
+			Password: "howdyhoneighbor!",
+		})
+
+		assert.Equal(t, apperrors.NewInternal(), err)
+		mockAuditService.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"memrizr/logging"
+	"memrizr/model/apperrors"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册 webp 解码器
+)
+
+// 头像处理设置
+const (
+	// 解码前检查尺寸，防止解压炸弹
+	maxAvatarPixels   = 40 * 1000 * 1000
+	avatarJPEGQuality = 85
+)
+
+// avatarVariant 处理后的某个尺寸的头像
+type avatarVariant struct {
+	Size        int
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// processAvatar 解码上传的图片，修正 EXIF 方向，居中裁剪为正方形并缩放为各个尺寸
+// 重新编码的图片不包含 EXIF 等元数据
+func processAvatar(data []byte, sizes []int) ([]*avatarVariant, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperrors.NewBadRequest("Unable to decode image")
+	}
+
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, apperrors.NewBadRequest("Image dimensions are too large")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperrors.NewBadRequest("Unable to decode image")
+	}
+
+	if format == "jpeg" {
+		src = applyOrientation(src, jpegOrientation(data))
+	}
+
+	crop := centerSquare(src.Bounds())
+	opaque := isOpaque(src)
+
+	variants := make([]*avatarVariant, 0, len(sizes))
+	for _, size := range sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+		v, err := encodeAvatar(dst, size, opaque)
+		if err != nil {
+			logging.Default().WithField("size", size).WithError(err).Error("Unable to encode avatar")
+			return nil, apperrors.NewInternal()
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, nil
+}
+
+// encodeAvatar 不透明的图片编码为 JPEG，否则为 PNG 以保留透明度
+func encodeAvatar(img image.Image, size int, opaque bool) (*avatarVariant, error) {
+	var buf bytes.Buffer
+
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+			return nil, err
+		}
+		return &avatarVariant{Size: size, Data: buf.Bytes(), ContentType: "image/jpeg", Ext: ".jpg"}, nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return &avatarVariant{Size: size, Data: buf.Bytes(), ContentType: "image/png", Ext: ".png"}, nil
+}
+
+// centerSquare 返回居中的最大正方形区域
+func centerSquare(b image.Rectangle) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if w > h {
+		x := b.Min.X + (w-h)/2
+		return image.Rect(x, b.Min.Y, x+h, b.Max.Y)
+	}
+
+	y := b.Min.Y + (h-w)/2
+	return image.Rect(b.Min.X, y, b.Max.X, y+w)
+}
+
+// isOpaque 判断图片是否完全不透明
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// applyOrientation 按 EXIF 方向值旋转或翻转图片，使其正向显示
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// 5 到 8 需要交换宽高
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿左上-右下对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转 90°
+				dx, dy = h-1-y, x
+			case 7: // 沿右上-左下对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针旋转 90°
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)))
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation 读取 JPEG 中 EXIF 的方向值，不存在或无法解析时返回 1
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// 遍历 SOS 之前的段，查找 APP1 中的 EXIF
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+
+		marker := data[i+1]
+		if marker == 0xDA {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// tiffOrientation 从 TIFF 结构的 IFD0 中读取方向标签 (0x0112)
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 默认头像缓存的最大数量，超过后清空重新缓存
+const maxDefaultAvatarCache = 1024
+
+// avatarService 默认头像服务，头像由 uid 确定生成，可以长期缓存
+type avatarService struct {
+	BaseURL string
+	Logger  *logrus.Logger
+
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+// AVSConfig 默认头像服务配置结构体
+// BaseURL 为默认头像路由的地址前缀
+// Logger 可选，为空时使用 logging.Default
+type AVSConfig struct {
+	BaseURL string
+	Logger  *logrus.Logger
+}
+
+// NewAvatarService 创建实例
+func NewAvatarService(c *AVSConfig) model.AvatarService {
+	return &avatarService{
+		BaseURL: strings.TrimSuffix(c.BaseURL, "/"),
+		Logger:  logging.OrDefault(c.Logger),
+		cache:   make(map[string][]byte),
+	}
+}
+
+// Default 实现 AvatarService 接口 Default 方法
+func (s *avatarService) Default(ctx context.Context, uid uuid.UUID, size int) ([]byte, error) {
+	if !isAvatarSize(size) {
+		return nil, apperrors.NewNotFound("avatar size", fmt.Sprint(size))
+	}
+
+	key := fmt.Sprintf("%s/%d", uid, size)
+
+	s.mu.Lock()
+	data, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	data, err := generateIdenticon(uid, size)
+	if err != nil {
+		s.Logger.WithContext(ctx).WithFields(logrus.Fields{
+			"uid":  uid,
+			"size": size,
+		}).WithError(err).Error("Unable to generate default avatar")
+		return nil, apperrors.NewInternal()
+	}
+
+	s.mu.Lock()
+	if len(s.cache) >= maxDefaultAvatarCache {
+		s.cache = make(map[string][]byte)
+	}
+	s.cache[key] = data
+	s.mu.Unlock()
+
+	return data, nil
+}
+
+// DefaultURLs 实现 AvatarService 接口 DefaultURLs 方法
+func (s *avatarService) DefaultURLs(uid uuid.UUID) model.ImageURLs {
+	urls := make(model.ImageURLs, len(model.AvatarSizes))
+	for _, size := range model.AvatarSizes {
+		urls.Set(size, fmt.Sprintf("%s/%s/%d.png", s.BaseURL, uid, size))
+	}
+	return urls
+}
+
+// isAvatarSize 判断是否为支持的头像尺寸
+func isAvatarSize(size int) bool {
+	for _, s := range model.AvatarSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
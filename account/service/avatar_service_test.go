@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAvatarService(t *testing.T) {
+	as := NewAvatarService(&AVSConfig{BaseURL: "http://api/avatars/default/"})
+
+	t.Run("Identicon is deterministic", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		a, err := as.Default(context.TODO(), uid, 64)
+		assert.NoError(t, err)
+
+		b, err := generateIdenticon(uid, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+
+		img, err := png.Decode(bytes.NewReader(a))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 64), img.Bounds())
+	})
+
+	t.Run("Different users get different avatars", func(t *testing.T) {
+		uid1, _ := uuid.NewRandom()
+		uid2, _ := uuid.NewRandom()
+
+		a, err := as.Default(context.TODO(), uid1, 256)
+		assert.NoError(t, err)
+		b, err := as.Default(context.TODO(), uid2, 256)
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("Unsupported size", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		data, err := as.Default(context.TODO(), uid, 100)
+
+		assert.Nil(t, data)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+
+	t.Run("Default URLs", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		urls := as.DefaultURLs(uid)
+
+		assert.Len(t, urls, len(model.AvatarSizes))
+		assert.Equal(t, "http://api/avatars/default/"+uid.String()+"/512.png", urls.Largest())
+	})
+}
+
+func TestGetDefaultAvatar(t *testing.T) {
+	t.Run("Fills default avatar for user without image", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		defaults := model.NewImageURLs("http://api/avatars/default/" + uid.String() + "/512.png")
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+		mockAvatarService := new(mocks.MockAvatarService)
+		mockAvatarService.On("DefaultURLs", uid).Return(defaults)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			AvatarService:  mockAvatarService,
+		})
+
+		u, err := us.Get(context.TODO(), uid)
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaults, u.ImageURLs)
+		mockAvatarService.AssertExpectations(t)
+	})
+
+	t.Run("Keeps uploaded image", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		uploaded := model.NewImageURLs("http://api/media/public/avatars/a.jpg")
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, ImageURLs: uploaded}, nil)
+		mockAvatarService := new(mocks.MockAvatarService)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			AvatarService:  mockAvatarService,
+		})
+
+		u, err := us.Get(context.TODO(), uid)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uploaded, u.ImageURLs)
+		mockAvatarService.AssertNotCalled(t, "DefaultURLs", mock.Anything)
+	})
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withEXIFOrientation 在 JPEG 的 SOI 之后插入只包含方向标签的 EXIF 段
+func withEXIFOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := &bytes.Buffer{}
+	tiff.WriteString("MM")
+	binary.Write(tiff, binary.BigEndian, uint16(42))
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(1))
+	binary.Write(tiff, binary.BigEndian, uint16(0x0112))
+	binary.Write(tiff, binary.BigEndian, uint16(3))
+	binary.Write(tiff, binary.BigEndian, uint32(1))
+	binary.Write(tiff, binary.BigEndian, orientation)
+	binary.Write(tiff, binary.BigEndian, uint16(0))
+	binary.Write(tiff, binary.BigEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	out := &bytes.Buffer{}
+	out.Write(jpg[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(jpg[2:])
+	return out.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 4)), nil))
+
+	assert.Equal(t, 1, jpegOrientation(buf.Bytes()))
+	assert.Equal(t, 6, jpegOrientation(withEXIFOrientation(buf.Bytes(), 6)))
+	assert.Equal(t, 1, jpegOrientation([]byte("not a jpeg")))
+	assert.Equal(t, 1, jpegOrientation(withEXIFOrientation(buf.Bytes(), 6)[:20]))
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1 图片，左红右蓝
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	// 顺时针旋转 90° 后为 1x2，上红下蓝
+	rotated := applyOrientation(src, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), rotated.Bounds())
+	assert.Equal(t, red, rotated.At(0, 0))
+	assert.Equal(t, blue, rotated.At(0, 1))
+
+	// 逆时针旋转 90° 后为 1x2，上蓝下红
+	rotated = applyOrientation(src, 8)
+	assert.Equal(t, blue, rotated.At(0, 0))
+	assert.Equal(t, red, rotated.At(0, 1))
+
+	// 水平翻转
+	flipped := applyOrientation(src, 2)
+	assert.Equal(t, blue, flipped.At(0, 0))
+	assert.Equal(t, red, flipped.At(1, 0))
+
+	assert.Equal(t, src, applyOrientation(src, 1))
+}
+
+func TestProcessAvatar(t *testing.T) {
+	t.Run("Crops, resizes and strips metadata", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 120, 80)), nil))
+		src := withEXIFOrientation(buf.Bytes(), 6)
+
+		variants, err := processAvatar(src, []int{16, 32})
+
+		assert.NoError(t, err)
+		assert.Len(t, variants, 2)
+		for i, size := range []int{16, 32} {
+			v := variants[i]
+			assert.Equal(t, size, v.Size)
+			assert.Equal(t, "image/jpeg", v.ContentType)
+			assert.False(t, bytes.Contains(v.Data, []byte("Exif")))
+
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(v.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, size, cfg.Width)
+			assert.Equal(t, size, cfg.Height)
+		}
+	})
+
+	t.Run("Keeps transparency as PNG", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 10, 10))))
+
+		variants, err := processAvatar(buf.Bytes(), []int{8})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", variants[0].ContentType)
+		assert.Equal(t, ".png", variants[0].Ext)
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"strings"
+)
+
+// emailProvider 邮件服务商的地址规则
+// stripDots 本地部分忽略 "."，stripTag 忽略 "+" 之后的标签
+type emailProvider struct {
+	domain    string
+	stripDots bool
+	stripTag  bool
+}
+
+// emailProviders 已知服务商的规则，key 为小写域名
+var emailProviders = map[string]emailProvider{
+	"gmail.com":      {domain: "gmail.com", stripDots: true, stripTag: true},
+	"googlemail.com": {domain: "gmail.com", stripDots: true, stripTag: true},
+	"outlook.com":    {domain: "outlook.com", stripTag: true},
+	"hotmail.com":    {domain: "hotmail.com", stripTag: true},
+	"live.com":       {domain: "live.com", stripTag: true},
+	"icloud.com":     {domain: "icloud.com", stripTag: true},
+	"me.com":         {domain: "me.com", stripTag: true},
+	"fastmail.com":   {domain: "fastmail.com", stripTag: true},
+}
+
+// normalizeEmail 去掉首尾空白并将域名转为小写，本地部分保持原样
+// 数据库按 lower(email) 保证唯一，查询同样不区分大小写
+// providerRules 为 true 时按服务商规则去掉别名，例如 b.o.b+news@googlemail.com 变为 bob@gmail.com
+func normalizeEmail(email string, providerRules bool) string {
+	email = strings.TrimSpace(email)
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+
+	if p, ok := emailProviders[domain]; ok && providerRules {
+		if p.stripTag {
+			if i := strings.Index(local, "+"); i > 0 {
+				local = local[:i]
+			}
+		}
+		if p.stripDots {
+			local = strings.ReplaceAll(local, ".", "")
+		}
+		domain = p.domain
+	}
+
+	return local + "@" + domain
+}
+
+// normalizeEmail 按服务配置规范化邮箱
+func (s *userService) normalizeEmail(email string) string {
+	return normalizeEmail(email, s.EmailProviderRules)
+}
+
+// findByEmail 查找已有账号，先按输入的邮箱查找，不应用服务商规则
+// 开启规则前保存的 first.last@gmail.com 等地址仍然可以找到，没有找到时再按去掉别名后的邮箱查找
+func (s *userService) findByEmail(ctx context.Context, email string) (*model.User, error) {
+	exact := normalizeEmail(email, false)
+	u, err := s.UserRepository.FindByEmail(ctx, exact)
+	if apperrors.Status(err) != http.StatusNotFound || !s.EmailProviderRules {
+		return u, err
+	}
+
+	canonical := normalizeEmail(email, true)
+	if sameEmail(canonical, exact) {
+		return u, err
+	}
+
+	return s.UserRepository.FindByEmail(ctx, canonical)
+}
+
+// sameEmail 邮箱不区分大小写比较
+func sameEmail(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
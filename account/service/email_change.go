@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestEmailChange 实现 UserService 接口 RequestEmailChange 方法
+// 新邮箱在确认后才会生效
+func (s *userService) RequestEmailChange(ctx context.Context, uid uuid.UUID, email string) error {
+	u, err := s.UserRepository.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	email = s.normalizeEmail(email)
+	if sameEmail(email, u.Email) {
+		return apperrors.NewBadRequest("new email must be different from the current email")
+	}
+
+	return s.requestEmailChange(ctx, u, email)
+}
+
+// ConfirmEmailChange 实现 UserService 接口 ConfirmEmailChange 方法
+// token 只能使用一次，确认时检查新邮箱是否已被其他用户使用
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (*model.User, error) {
+	invalid := apperrors.NewBadRequest("invalid or expired email change token")
+
+	var c *model.EmailChange
+	var u *model.User
+	err := runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		var err error
+		if c, err = s.EmailChangeRepository.Take(ctx, hashEmailChangeToken(token)); err != nil {
+			if apperrors.Status(err) == http.StatusNotFound {
+				return invalid
+			}
+			return err
+		}
+
+		if !time.Now().Before(c.ExpiresAt) {
+			return invalid
+		}
+
+		existing, err := s.UserRepository.FindByEmail(ctx, c.NewEmail)
+		if err == nil && existing.UID != c.UID {
+			return apperrors.NewConflict("email", c.NewEmail)
+		}
+		if err != nil && apperrors.Status(err) != http.StatusNotFound {
+			return err
+		}
+
+		email := c.NewEmail
+		if u, err = s.UserRepository.Patch(ctx, c.UID, 0, model.UserPatch{model.FieldEmail: &email}); err != nil {
+			return err
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, u.UID, u)
+	})
+	if err != nil {
+		if c != nil {
+			recordAudit(ctx, s.AuditService, &model.AuditEvent{
+				ActorID:  &c.UID,
+				TargetID: &c.UID,
+				Action:   model.AuditEmailChange,
+				Outcome:  model.OutcomeFailure,
+				Details:  model.AuditDetails{"email": c.NewEmail, "error": err.Error()},
+			})
+		}
+		return nil, err
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &u.UID,
+		TargetID: &u.UID,
+		Action:   model.AuditEmailChange,
+		Details:  model.AuditDetails{"email": u.Email},
+	})
+
+	s.withDefaultAvatar(u)
+
+	return u, nil
+}
+
+// requestEmailChange 保存待确认的修改，向新邮箱发送确认链接并通知原邮箱
+func (s *userService) requestEmailChange(ctx context.Context, u *model.User, email string) error {
+	r, err := s.saveEmailChange(ctx, u, email)
+	if err != nil {
+		return err
+	}
+	return s.sendEmailChange(ctx, r)
+}
+
+// emailChangeRequest 已保存、等待发送确认邮件的邮箱修改
+type emailChangeRequest struct {
+	UID        uuid.UUID
+	OldEmail   string
+	NewEmail   string
+	ExpiresAt  time.Time
+	ConfirmURL string
+}
+
+// saveEmailChange 保存待确认的修改，在事务中调用时随事务提交
+// 邮件由 sendEmailChange 在提交后发送
+func (s *userService) saveEmailChange(ctx context.Context, u *model.User, email string) (*emailChangeRequest, error) {
+	token, err := generateEmailChangeToken()
+	if err != nil {
+		s.Logger.WithContext(ctx).WithField("uid", u.UID).WithError(err).Error("Unable to generate email change token")
+		return nil, apperrors.NewInternal()
+	}
+
+	confirmURL, err := url.Parse(s.EmailConfirmURL)
+	if err != nil {
+		s.Logger.WithContext(ctx).WithField("url", s.EmailConfirmURL).WithError(err).Error("Invalid email confirm url")
+		return nil, apperrors.NewInternal()
+	}
+	q := confirmURL.Query()
+	q.Set("token", token)
+	confirmURL.RawQuery = q.Encode()
+
+	c := &model.EmailChange{
+		UID:       u.UID,
+		NewEmail:  email,
+		TokenHash: hashEmailChangeToken(token),
+		ExpiresAt: time.Now().Add(s.EmailChangeTTL),
+	}
+	if err := s.EmailChangeRepository.Save(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return &emailChangeRequest{
+		UID:        u.UID,
+		OldEmail:   u.Email,
+		NewEmail:   email,
+		ExpiresAt:  c.ExpiresAt,
+		ConfirmURL: confirmURL.String(),
+	}, nil
+}
+
+// sendEmailChange 向新邮箱发送确认链接并通知原邮箱
+func (s *userService) sendEmailChange(ctx context.Context, r *emailChangeRequest) error {
+	if err := s.Mailer.Send(ctx, &model.EmailMessage{
+		To:      r.NewEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Open the link below to confirm your new email address. The link expires at %s.\n\n%s\n",
+			r.ExpiresAt.UTC().Format(time.RFC1123), r.ConfirmURL),
+	}); err != nil {
+		return err
+	}
+
+	// 通知原邮箱，账号被盗用时用户可以及时发现
+	if err := s.Mailer.Send(ctx, &model.EmailMessage{
+		To:      r.OldEmail,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("A request was made to change the email address of your account to %s.\n"+
+			"If this was not you, sign in and change your password immediately.\n", r.NewEmail),
+	}); err != nil {
+		s.Logger.WithContext(ctx).WithField("uid", r.UID).WithError(err).Warn("Unable to notify previous email")
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &r.UID,
+		TargetID: &r.UID,
+		Action:   model.AuditEmailRequest,
+		Details:  model.AuditDetails{"email": r.NewEmail},
+	})
+
+	return nil
+}
+
+// generateEmailChangeToken 生成邮箱确认 token
+func generateEmailChangeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashEmailChangeToken 数据库只保存 token 的 hash
+func hashEmailChangeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestEmailChange(t *testing.T) {
+	t.Run("Sends confirmation and notification", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		user := &model.User{UID: uid, Email: "old@bob.com"}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(user, nil)
+
+		var saved *model.EmailChange
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Save", mock.Anything, mock.AnythingOfType("*model.EmailChange")).
+			Run(func(args mock.Arguments) {
+				saved = args.Get(1).(*model.EmailChange)
+			}).
+			Return(nil)
+
+		var confirmation *model.EmailMessage
+		mockMailer := new(mocks.MockMailer)
+		mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(m *model.EmailMessage) bool {
+			return m.To == "new@bob.com"
+		})).
+			Run(func(args mock.Arguments) {
+				confirmation = args.Get(1).(*model.EmailMessage)
+			}).
+			Return(nil)
+		mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(m *model.EmailMessage) bool {
+			return m.To == "old@bob.com"
+		})).Return(nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+			Mailer:                mockMailer,
+			EmailConfirmURL:       "http://app/confirm-email",
+			EmailChangeTTL:        time.Hour,
+		})
+
+		err := us.RequestEmailChange(context.TODO(), uid, "new@bob.com")
+
+		assert.NoError(t, err)
+		mockEmailChangeRepository.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+
+		// 确认链接中的 token 与保存的 hash 对应
+		assert.Equal(t, "new@bob.com", saved.NewEmail)
+		assert.True(t, saved.ExpiresAt.After(time.Now()))
+
+		i := strings.Index(confirmation.Body, "http://app/confirm-email?")
+		assert.GreaterOrEqual(t, i, 0)
+		link, err := url.Parse(strings.Fields(confirmation.Body[i:])[0])
+		assert.NoError(t, err)
+		assert.Equal(t, saved.TokenHash, hashEmailChangeToken(link.Query().Get("token")))
+	})
+
+	t.Run("Same email", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "bob@bob.com"}, nil)
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		err := us.RequestEmailChange(context.TODO(), uid, "bob@bob.com")
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockEmailChangeRepository.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Confirmation email fails", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "old@bob.com"}, nil)
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockMailer := new(mocks.MockMailer)
+		mockMailer.On("Send", mock.Anything, mock.Anything).Return(apperrors.NewInternal())
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+			Mailer:                mockMailer,
+			EmailConfirmURL:       "http://app/confirm-email",
+			EmailChangeTTL:        time.Hour,
+		})
+
+		err := us.RequestEmailChange(context.TODO(), uid, "new@bob.com")
+
+		assert.Error(t, err)
+		// 确认邮件发送失败时不通知原邮箱
+		mockMailer.AssertNumberOfCalls(t, "Send", 1)
+	})
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	token := "confirm-token"
+
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		email := "new@bob.com"
+
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Take", mock.Anything, hashEmailChangeToken(token)).
+			Return(&model.EmailChange{UID: uid, NewEmail: email, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByEmail", mock.Anything, email).Return(nil, apperrors.NewNotFound("email", email))
+		mockUserRepository.On("Patch", mock.Anything, uid, int64(0), model.UserPatch{model.FieldEmail: &email}).
+			Return(&model.User{UID: uid, Email: email}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		u, err := us.ConfirmEmailChange(context.TODO(), token)
+
+		assert.NoError(t, err)
+		assert.Equal(t, email, u.Email)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Take", mock.Anything, hashEmailChangeToken(token)).
+			Return(nil, apperrors.NewNotFound("email change", "token"))
+
+		us := NewUserService(&USConfig{
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		u, err := us.ConfirmEmailChange(context.TODO(), token)
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Take", mock.Anything, hashEmailChangeToken(token)).
+			Return(&model.EmailChange{UID: uid, NewEmail: "new@bob.com", ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+		mockUserRepository := new(mocks.MockUserRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		u, err := us.ConfirmEmailChange(context.TODO(), token)
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Email taken at confirm time", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		otherUID, _ := uuid.NewRandom()
+		email := "taken@bob.com"
+
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Take", mock.Anything, hashEmailChangeToken(token)).
+			Return(&model.EmailChange{UID: uid, NewEmail: email, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByEmail", mock.Anything, email).Return(&model.User{UID: otherUID, Email: email}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		u, err := us.ConfirmEmailChange(context.TODO(), token)
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusConflict, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPatchDetailsEmail(t *testing.T) {
+	t.Run("Email change becomes pending", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		name := "Bobby"
+		email := "new@bob.com"
+		user := &model.User{UID: uid, Email: "old@bob.com"}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(user, nil)
+		mockUserRepository.On("Patch", mock.Anything, uid, int64(0), model.UserPatch{model.FieldName: &name}).
+			Return(&model.User{UID: uid, Email: "old@bob.com", Name: name}, nil)
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockEmailChangeRepository.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockMailer := new(mocks.MockMailer)
+		mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+			Mailer:                mockMailer,
+			EmailConfirmURL:       "http://app/confirm-email",
+			EmailChangeTTL:        time.Hour,
+		})
+
+		p := model.UserPatch{model.FieldName: &name, model.FieldEmail: &email}
+		u, pending, err := us.PatchDetails(context.TODO(), uid, 0, p)
+
+		assert.NoError(t, err)
+		assert.True(t, pending)
+		assert.Equal(t, "old@bob.com", u.Email)
+		assert.Len(t, p, 2)
+		mockUserRepository.AssertExpectations(t)
+		mockEmailChangeRepository.AssertExpectations(t)
+	})
+
+	t.Run("Unchanged email is ignored", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		email := "bob@bob.com"
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: email}, nil)
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		u, pending, err := us.PatchDetails(context.TODO(), uid, 0, model.UserPatch{model.FieldEmail: &email})
+
+		assert.NoError(t, err)
+		assert.False(t, pending)
+		assert.Equal(t, email, u.Email)
+		mockEmailChangeRepository.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		mockUserRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed patch does not request email change", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		name := "Bobby"
+		email := "new@bob.com"
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "old@bob.com", Version: 3}, nil)
+		mockUserRepository.On("Patch", mock.Anything, uid, int64(3), model.UserPatch{model.FieldName: &name}).
+			Return(nil, apperrors.NewPreconditionFailed("user has been modified by another request"))
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockMailer := new(mocks.MockMailer)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+			Mailer:                mockMailer,
+			EmailConfirmURL:       "http://app/confirm-email",
+			EmailChangeTTL:        time.Hour,
+		})
+
+		u, pending, err := us.PatchDetails(context.TODO(), uid, 3, model.UserPatch{model.FieldName: &name, model.FieldEmail: &email})
+
+		assert.Nil(t, u)
+		assert.False(t, pending)
+		assert.Equal(t, http.StatusPreconditionFailed, apperrors.Status(err))
+		mockEmailChangeRepository.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed update does not request email change", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "old@bob.com", Version: 3}, nil)
+		mockUserRepository.On("Update", mock.Anything, mock.Anything).
+			Return(apperrors.NewPreconditionFailed("user has been modified by another request"))
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+		mockMailer := new(mocks.MockMailer)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+			Mailer:                mockMailer,
+			EmailConfirmURL:       "http://app/confirm-email",
+			EmailChangeTTL:        time.Hour,
+		})
+
+		pending, err := us.UpdateDetails(context.TODO(), &model.User{UID: uid, Email: "new@bob.com", Version: 3})
+
+		assert.False(t, pending)
+		assert.Equal(t, http.StatusPreconditionFailed, apperrors.Status(err))
+		mockEmailChangeRepository.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	cases := []struct {
+		email         string
+		providerRules bool
+		want          string
+	}{
+		{" Bob@Example.COM ", false, "Bob@example.com"},
+		{"bob", false, "bob"},
+		{"b.o.b+news@GoogleMail.com", false, "b.o.b+news@googlemail.com"},
+		{"b.o.b+news@GoogleMail.com", true, "bob@gmail.com"},
+		{"bob.smith+work@outlook.com", true, "bob.smith@outlook.com"},
+		{"bob.smith+work@example.com", true, "bob.smith+work@example.com"},
+		{"+tag@gmail.com", true, "+tag@gmail.com"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, normalizeEmail(c.email, c.providerRules), c.email)
+	}
+}
+
+func TestEmailIdentity(t *testing.T) {
+	t.Run("Signup stores normalized email", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("Create", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.Email == "Bob@example.com"
+		})).Return(nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		err := us.Signup(context.TODO(), &model.User{Email: " Bob@EXAMPLE.com", Password: "howdyhoneighbor!"})
+
+		assert.NoError(t, err)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Signin finds accounts stored before provider rules", func(t *testing.T) {
+		pw, _ := hashPassword("howdyhoneighbor!")
+		stored := &model.User{UID: uuid.New(), Email: "first.last+tag@gmail.com", Password: pw, Status: model.StatusActive}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByEmail", mock.Anything, "first.last+tag@gmail.com").Return(stored, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:     mockUserRepository,
+			EmailProviderRules: true,
+		})
+
+		u := &model.User{Email: "first.last+tag@GMAIL.com", Password: "howdyhoneighbor!"}
+		err := us.Signin(context.TODO(), u)
+
+		assert.NoError(t, err)
+		assert.Equal(t, stored.UID, u.UID)
+		mockUserRepository.AssertNotCalled(t, "FindByEmail", mock.Anything, "firstlast@gmail.com")
+	})
+
+	t.Run("Signin falls back to canonical email", func(t *testing.T) {
+		pw, _ := hashPassword("howdyhoneighbor!")
+		stored := &model.User{UID: uuid.New(), Email: "firstlast@gmail.com", Password: pw, Status: model.StatusActive}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByEmail", mock.Anything, "first.last@googlemail.com").Return(nil, apperrors.NewNotFound("email", "first.last@googlemail.com"))
+		mockUserRepository.On("FindByEmail", mock.Anything, "firstlast@gmail.com").Return(stored, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:     mockUserRepository,
+			EmailProviderRules: true,
+		})
+
+		u := &model.User{Email: "first.last@googlemail.com", Password: "howdyhoneighbor!"}
+		err := us.Signin(context.TODO(), u)
+
+		assert.NoError(t, err)
+		assert.Equal(t, stored.UID, u.UID)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Case change is not an email change", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "bob@example.com", Version: 1}, nil)
+		mockEmailChangeRepository := new(mocks.MockEmailChangeRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository:        mockUserRepository,
+			EmailChangeRepository: mockEmailChangeRepository,
+		})
+
+		email := "BOB@example.com"
+		u, pending, err := us.PatchDetails(context.TODO(), uid, 0, model.UserPatch{model.FieldEmail: &email})
+
+		assert.NoError(t, err)
+		assert.False(t, pending)
+		assert.Equal(t, "bob@example.com", u.Email)
+		mockEmailChangeRepository.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"memrizr/logging"
+	"memrizr/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventRelay 未配置时的默认值
+const (
+	defaultRelayPollInterval = time.Second
+	defaultRelayBatchSize    = 100
+	defaultRelayMaxAttempts  = 10
+)
+
+// EventRelay 轮询 outbox 表并将事件发布到 broker
+// 发布成功后才标记已发布，因此投递语义为至少一次
+// 发布失败达到 MaxAttempts 次的事件标记为 dead，不再阻塞后面的事件
+type EventRelay struct {
+	Transactor      model.Transactor
+	EventRepository model.EventRepository
+	Broker          model.EventBroker
+	PollInterval    time.Duration
+	BatchSize       int
+	MaxAttempts     int
+	Logger          *logrus.Logger
+}
+
+// ERConfig EventRelay 配置结构体
+// PollInterval、BatchSize 和 MaxAttempts 不大于 0 时使用默认值
+// Logger 可选，为空时使用 logging.Default
+type ERConfig struct {
+	Transactor      model.Transactor
+	EventRepository model.EventRepository
+	Broker          model.EventBroker
+	PollInterval    time.Duration
+	BatchSize       int
+	MaxAttempts     int
+	Logger          *logrus.Logger
+}
+
+// NewEventRelay 创建实例
+func NewEventRelay(c *ERConfig) *EventRelay {
+	r := &EventRelay{
+		Transactor:      c.Transactor,
+		EventRepository: c.EventRepository,
+		Broker:          c.Broker,
+		PollInterval:    c.PollInterval,
+		BatchSize:       c.BatchSize,
+		MaxAttempts:     c.MaxAttempts,
+		Logger:          logging.OrDefault(c.Logger),
+	}
+
+	// time.NewTicker 不接受不大于 0 的间隔
+	if r.PollInterval <= 0 {
+		r.PollInterval = defaultRelayPollInterval
+	}
+	if r.BatchSize <= 0 {
+		r.BatchSize = defaultRelayBatchSize
+	}
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = defaultRelayMaxAttempts
+	}
+
+	return r
+}
+
+// Run 持续发布事件，直到 ctx 被取消
+func (r *EventRelay) Run(ctx context.Context) {
+	r.Logger.WithField("interval", r.PollInterval).Info("Starting event relay")
+
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// 一直处理到积压的事件不足一批
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil || n < r.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.Logger.Info("Stopping event relay")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch 在一个事务中锁定并发布一批事件，返回成功发布的数量
+// 遇到发布失败时停止，保证同一批事件按顺序投递；达到最大次数的事件标记为 dead 后继续
+// 每次发布在嵌套事务中执行，broker 写库失败时只回滚本次发布，失败次数仍能记录
+func (r *EventRelay) RelayBatch(ctx context.Context) (int, error) {
+	published := 0
+
+	err := r.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		events, err := r.EventRepository.LockUnpublished(ctx, r.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			err := r.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				return r.Broker.Publish(ctx, e)
+			})
+			if err != nil {
+				logger := r.Logger.WithContext(ctx).WithFields(logrus.Fields{
+					"event_id":   e.ID,
+					"event_type": e.Type,
+					"attempts":   e.Attempts + 1,
+				}).WithError(err)
+
+				if e.Attempts+1 >= r.MaxAttempts {
+					logger.Error("Giving up on event, marking it dead")
+					if err := r.EventRepository.MarkDead(ctx, e.ID, err.Error()); err != nil {
+						return err
+					}
+					continue
+				}
+
+				logger.Warn("Failed to publish event")
+				return r.EventRepository.MarkFailed(ctx, e.ID, err.Error())
+			}
+
+			if err := r.EventRepository.MarkPublished(ctx, e.ID); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+
+	return published, err
+}
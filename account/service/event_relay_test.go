@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRelayBatch(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	e1, _ := model.NewEvent(model.EventUserCreated, uid, map[string]string{"email": "bob@bob.com"})
+	e2, _ := model.NewEvent(model.EventUserUpdated, uid, map[string]string{"name": "Bobby"})
+
+	t.Run("Publishes and marks events", func(t *testing.T) {
+		mockTransactor := new(mocks.MockTransactor)
+		mockEventRepository := new(mocks.MockEventRepository)
+		mockBroker := new(mocks.MockEventBroker)
+
+		relay := NewEventRelay(&ERConfig{
+			Transactor:      mockTransactor,
+			EventRepository: mockEventRepository,
+			Broker:          mockBroker,
+			BatchSize:       10,
+		})
+
+		mockTransactor.On("WithinTransaction", mock.Anything)
+		mockEventRepository.On("LockUnpublished", mock.Anything, 10).Return([]*model.Event{e1, e2}, nil)
+		mockBroker.On("Publish", mock.Anything, e1).Return(nil)
+		mockBroker.On("Publish", mock.Anything, e2).Return(nil)
+		mockEventRepository.On("MarkPublished", mock.Anything, e1.ID).Return(nil)
+		mockEventRepository.On("MarkPublished", mock.Anything, e2.ID).Return(nil)
+
+		n, err := relay.RelayBatch(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		mockEventRepository.AssertExpectations(t)
+		mockBroker.AssertExpectations(t)
+	})
+
+	t.Run("Stops at first publish failure", func(t *testing.T) {
+		mockTransactor := new(mocks.MockTransactor)
+		mockEventRepository := new(mocks.MockEventRepository)
+		mockBroker := new(mocks.MockEventBroker)
+
+		relay := NewEventRelay(&ERConfig{
+			Transactor:      mockTransactor,
+			EventRepository: mockEventRepository,
+			Broker:          mockBroker,
+			BatchSize:       10,
+		})
+
+		publishErr := fmt.Errorf("broker unavailable")
+
+		mockTransactor.On("WithinTransaction", mock.Anything)
+		mockEventRepository.On("LockUnpublished", mock.Anything, 10).Return([]*model.Event{e1, e2}, nil)
+		mockBroker.On("Publish", mock.Anything, e1).Return(publishErr)
+		mockEventRepository.On("MarkFailed", mock.Anything, e1.ID, publishErr.Error()).Return(nil)
+
+		n, err := relay.RelayBatch(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		mockBroker.AssertNotCalled(t, "Publish", mock.Anything, e2)
+		mockEventRepository.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything)
+		mockEventRepository.AssertExpectations(t)
+	})
+
+	t.Run("Marks event dead after max attempts", func(t *testing.T) {
+		mockTransactor := new(mocks.MockTransactor)
+		mockEventRepository := new(mocks.MockEventRepository)
+		mockBroker := new(mocks.MockEventBroker)
+
+		relay := NewEventRelay(&ERConfig{
+			Transactor:      mockTransactor,
+			EventRepository: mockEventRepository,
+			Broker:          mockBroker,
+			BatchSize:       10,
+			MaxAttempts:     3,
+		})
+
+		publishErr := fmt.Errorf("payload rejected")
+		failing := *e1
+		failing.Attempts = 2
+
+		mockTransactor.On("WithinTransaction", mock.Anything)
+		mockEventRepository.On("LockUnpublished", mock.Anything, 10).Return([]*model.Event{&failing, e2}, nil)
+		mockBroker.On("Publish", mock.Anything, &failing).Return(publishErr)
+		mockBroker.On("Publish", mock.Anything, e2).Return(nil)
+		mockEventRepository.On("MarkDead", mock.Anything, e1.ID, publishErr.Error()).Return(nil)
+		mockEventRepository.On("MarkPublished", mock.Anything, e2.ID).Return(nil)
+
+		n, err := relay.RelayBatch(context.TODO())
+
+		// dead 的事件不阻塞后面的事件
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		mockEventRepository.AssertNotCalled(t, "MarkFailed", mock.Anything, mock.Anything, mock.Anything)
+		mockEventRepository.AssertExpectations(t)
+		mockBroker.AssertExpectations(t)
+	})
+
+	t.Run("Records attempt when broker fails with a database error", func(t *testing.T) {
+		tx := &abortingTransactor{}
+		mockEventRepository := new(mocks.MockEventRepository)
+		mockBroker := new(mocks.MockEventBroker)
+
+		relay := NewEventRelay(&ERConfig{
+			Transactor:      tx,
+			EventRepository: mockEventRepository,
+			Broker:          mockBroker,
+			BatchSize:       10,
+		})
+
+		publishErr := apperrors.NewInternal()
+
+		mockEventRepository.On("LockUnpublished", mock.Anything, 10).Return([]*model.Event{e1, e2}, nil)
+		// webhook broker 写入投递记录失败，Postgres 中止当前事务
+		mockBroker.On("Publish", mock.Anything, e1).Run(func(mock.Arguments) {
+			tx.aborted = true
+		}).Return(publishErr)
+		mockEventRepository.On("MarkFailed", mock.Anything, e1.ID, publishErr.Error()).Run(func(mock.Arguments) {
+			assert.False(t, tx.aborted, "attempt recorded in an aborted transaction")
+		}).Return(nil)
+
+		n, err := relay.RelayBatch(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		mockEventRepository.AssertExpectations(t)
+	})
+}
+
+// abortingTransactor 模拟 Postgres 事务，语句失败后事务中止，回滚到 savepoint 后恢复
+type abortingTransactor struct {
+	depth   int
+	aborted bool
+}
+
+func (t *abortingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.depth++
+	defer func() { t.depth-- }()
+
+	err := fn(ctx)
+	if err != nil && t.depth > 1 {
+		t.aborted = false
+	}
+	return err
+}
+
+func TestNewEventRelay(t *testing.T) {
+	t.Run("Defaults for unset values", func(t *testing.T) {
+		relay := NewEventRelay(&ERConfig{})
+
+		assert.Equal(t, defaultRelayPollInterval, relay.PollInterval)
+		assert.Equal(t, defaultRelayBatchSize, relay.BatchSize)
+		assert.Equal(t, defaultRelayMaxAttempts, relay.MaxAttempts)
+	})
+
+	t.Run("Keeps configured values", func(t *testing.T) {
+		relay := NewEventRelay(&ERConfig{PollInterval: 5 * time.Second, BatchSize: 10, MaxAttempts: 3})
+
+		assert.Equal(t, 5*time.Second, relay.PollInterval)
+		assert.Equal(t, 10, relay.BatchSize)
+		assert.Equal(t, 3, relay.MaxAttempts)
+	})
+}
+
+func TestSignupEvent(t *testing.T) {
+	t.Run("Appends user created event in the signup transaction", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockEventRepository := new(mocks.MockEventRepository)
+		mockTransactor := new(mocks.MockTransactor)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			EventRepository: mockEventRepository,
+			Transactor:      mockTransactor,
+		})
+
+		mockTransactor.On("WithinTransaction", mock.Anything)
+		mockUserRepository.
+			On("Create", mock.Anything, mock.AnythingOfType("*model.User")).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*model.User).UID = uid
+			}).Return(nil)
+		mockEventRepository.
+			On("Append", mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+				return e.Type == model.EventUserCreated && e.AggregateID == uid
+			})).Return(nil)
+
+		err := us.Signup(context.TODO(), &model.User{
+			Email:    "bob@bob.com",
+			Password: "howdyhoneighbor!",
+		})
+
+		assert.NoError(t, err)
+		mockTransactor.AssertExpectations(t)
+		mockEventRepository.AssertExpectations(t)
+	})
+}
+
+func TestSessionCreatedEvent(t *testing.T) {
+	t.Run("Revokes the session when the event cannot be appended", func(t *testing.T) {
+		privKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		uid, _ := uuid.NewRandom()
+		u := &model.User{UID: uid, Email: "bob@bob.com", Status: model.StatusActive}
+
+		mockTokenRepository := new(mocks.MockTokenRepository)
+		mockEventRepository := new(mocks.MockEventRepository)
+		ts := NewTokenService(&TSConfig{
+			TokenRepository:       mockTokenRepository,
+			EventRepository:       mockEventRepository,
+			PrivateKey:            privKey,
+			PublicKey:             &privKey.PublicKey,
+			RefreshSecret:         "anotsorandomtestsecret",
+			IDExpirationSecs:      15 * 60,
+			RefreshExpirationSecs: 3 * 24 * 3600,
+		})
+
+		var tokenID string
+		mockTokenRepository.
+			On("SetRefreshToken", mock.Anything, uid.String(), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration")).
+			Run(func(args mock.Arguments) {
+				tokenID = args.String(2)
+			}).Return(nil)
+		mockEventRepository.On("Append", mock.Anything, mock.AnythingOfType("*model.Event")).Return(apperrors.NewInternal())
+		mockTokenRepository.On("DeleteRefreshToken", mock.Anything, uid.String(), mock.AnythingOfType("string")).Return(nil)
+
+		pair, err := ts.NewTokenPairFromUser(context.TODO(), u, "")
+
+		assert.Error(t, err)
+		assert.Nil(t, pair)
+		mockTokenRepository.AssertCalled(t, "DeleteRefreshToken", mock.Anything, uid.String(), tokenID)
+	})
+}
+
+func TestMultiBroker(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	e, _ := model.NewEvent(model.EventUserUpdated, uid, map[string]string{"name": "Bobby"})
+
+	t.Run("Retries republish to every broker", func(t *testing.T) {
+		first := new(mocks.MockEventBroker)
+		second := new(mocks.MockEventBroker)
+		b := NewMultiBroker(first, second)
+
+		brokerErr := fmt.Errorf("redis unavailable")
+		first.On("Publish", mock.Anything, e).Return(nil)
+		second.On("Publish", mock.Anything, e).Return(brokerErr).Once()
+		second.On("Publish", mock.Anything, e).Return(nil).Once()
+
+		assert.Equal(t, brokerErr, b.Publish(context.TODO(), e))
+		assert.NoError(t, b.Publish(context.TODO(), e))
+
+		// 前面的 broker 会收到重复的事件
+		first.AssertNumberOfCalls(t, "Publish", 2)
+		second.AssertNumberOfCalls(t, "Publish", 2)
+	})
+
+	t.Run("Stops at first failure", func(t *testing.T) {
+		first := new(mocks.MockEventBroker)
+		second := new(mocks.MockEventBroker)
+		b := NewMultiBroker(first, second)
+
+		first.On("Publish", mock.Anything, e).Return(fmt.Errorf("database unavailable"))
+
+		assert.Error(t, b.Publish(context.TODO(), e))
+		second.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// runInTx 在事务中执行 fn，未配置事务管理时直接执行
+func runInTx(ctx context.Context, t model.Transactor, fn func(ctx context.Context) error) error {
+	if t == nil {
+		return fn(ctx)
+	}
+	return t.WithinTransaction(ctx, fn)
+}
+
+// appendEvent 创建领域事件并写入 outbox，未配置 outbox 时忽略
+func appendEvent(ctx context.Context, r model.EventRepository, eventType string, aggregateID uuid.UUID, payload interface{}) error {
+	if r == nil {
+		return nil
+	}
+
+	e, err := model.NewEvent(eventType, aggregateID, payload)
+	if err != nil {
+		logging.Default().WithContext(ctx).WithFields(logrus.Fields{
+			"event_type":   eventType,
+			"aggregate_id": aggregateID,
+		}).WithError(err).Error("Unable to create event")
+		return apperrors.NewInternal()
+	}
+
+	return r.Append(ctx, e)
+}
+
+// multiBroker 按顺序将事件发布到多个 broker
+type multiBroker struct {
+	Brokers []model.EventBroker
+}
+
+// NewMultiBroker 创建实例，任意一个 broker 失败即返回错误
+// 失败后事件会重新发布到所有 broker，因此失败 broker 之前的 broker 需要幂等
+func NewMultiBroker(brokers ...model.EventBroker) model.EventBroker {
+	return &multiBroker{
+		Brokers: brokers,
+	}
+}
+
+// Publish 发布到所有 broker
+func (b *multiBroker) Publish(ctx context.Context, e *model.Event) error {
+	for _, broker := range b.Brokers {
+		if err := broker.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
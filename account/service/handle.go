@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// handlePattern 3-30 位小写字母、数字或下划线，不能与 uuid 混淆
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// reservedHandles 保留的 handle，避免与路由或系统账号混淆
+var reservedHandles = map[string]bool{
+	"about":     true,
+	"account":   true,
+	"admin":     true,
+	"api":       true,
+	"auth":      true,
+	"avatars":   true,
+	"help":      true,
+	"login":     true,
+	"logout":    true,
+	"me":        true,
+	"media":     true,
+	"memrizr":   true,
+	"null":      true,
+	"root":      true,
+	"security":  true,
+	"settings":  true,
+	"signin":    true,
+	"signout":   true,
+	"signup":    true,
+	"support":   true,
+	"system":    true,
+	"undefined": true,
+	"users":     true,
+	"www":       true,
+}
+
+// normalizeHandle handle 不区分大小写，统一转为小写，允许带 @ 前缀
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// validateHandle 检查 handle 格式和保留字
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return apperrors.NewBadRequest("handle must be 3-30 characters of letters, digits or underscores")
+	}
+
+	if reservedHandles[handle] {
+		return apperrors.NewBadRequest(fmt.Sprintf("handle is reserved: %s", handle))
+	}
+
+	return nil
+}
+
+// GetPublicProfileByHandle 实现 UserService 接口 GetPublicProfileByHandle 方法
+// 旧 handle 在重定向过期前解析到原用户，返回资料中的 handle 为当前 handle
+func (s *userService) GetPublicProfileByHandle(ctx context.Context, handle string) (*model.PublicProfile, error) {
+	handle = normalizeHandle(handle)
+
+	var uid uuid.UUID
+	u, err := s.UserRepository.FindByHandle(ctx, handle)
+	switch {
+	case err == nil:
+		uid = u.UID
+	case apperrors.Status(err) != http.StatusNotFound:
+		return nil, err
+	default:
+		r, err := s.HandleRedirectRepository.Find(ctx, handle)
+		if err != nil {
+			if apperrors.Status(err) == http.StatusNotFound {
+				return nil, apperrors.NewNotFound("user", handle)
+			}
+			return nil, err
+		}
+		uid = r.UID
+	}
+
+	// 不在错误信息中暴露未公开用户的 uid
+	p, err := s.GetPublicProfile(ctx, uid)
+	if err != nil {
+		if apperrors.Status(err) == http.StatusNotFound {
+			return nil, apperrors.NewNotFound("user", handle)
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// CheckHandle 实现 UserService 接口 CheckHandle 方法
+// 返回规范化后的 handle 以及 uid 对应的用户能否使用
+func (s *userService) CheckHandle(ctx context.Context, uid uuid.UUID, handle string) (string, bool, error) {
+	handle = normalizeHandle(handle)
+	if err := validateHandle(handle); err != nil {
+		return handle, false, err
+	}
+
+	available, err := s.handleAvailable(ctx, uid, handle)
+	return handle, available, err
+}
+
+// SetHandle 实现 UserService 接口 SetHandle 方法
+// 空字符串清除 handle，旧 handle 保留一段时间的重定向
+func (s *userService) SetHandle(ctx context.Context, uid uuid.UUID, handle string) (*model.User, error) {
+	handle = normalizeHandle(handle)
+	if handle != "" {
+		if err := validateHandle(handle); err != nil {
+			return nil, err
+		}
+	}
+
+	var u *model.User
+	var prev string
+	err := runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		current, err := s.UserRepository.FindByID(ctx, uid)
+		if err != nil {
+			return err
+		}
+
+		prev = current.Handle
+		if prev == handle {
+			u = current
+			return nil
+		}
+
+		if handle != "" {
+			available, err := s.handleAvailable(ctx, uid, handle)
+			if err != nil {
+				return err
+			}
+			if !available {
+				return apperrors.NewConflict("handle", handle)
+			}
+
+			// 用户改回自己的旧 handle 时不再需要重定向
+			if err := s.HandleRedirectRepository.Delete(ctx, handle); err != nil {
+				return err
+			}
+		}
+
+		if u, err = s.UserRepository.UpdateHandle(ctx, uid, handle); err != nil {
+			return err
+		}
+
+		if prev != "" {
+			if err := s.HandleRedirectRepository.Save(ctx, &model.HandleRedirect{
+				Handle:    prev,
+				UID:       uid,
+				ExpiresAt: time.Now().Add(s.HandleRedirectTTL),
+			}); err != nil {
+				return err
+			}
+		}
+
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, u.UID, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if prev != handle {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			ActorID:  &uid,
+			TargetID: &uid,
+			Action:   model.AuditHandleChange,
+			Details:  model.AuditDetails{"handle": handle, "prev_handle": prev},
+		})
+	}
+
+	s.withDefaultAvatar(u)
+
+	return u, nil
+}
+
+// handleAvailable handle 未被其他用户使用，且不是其他用户未过期的旧 handle
+func (s *userService) handleAvailable(ctx context.Context, uid uuid.UUID, handle string) (bool, error) {
+	if u, err := s.UserRepository.FindByHandle(ctx, handle); err == nil && u.UID != uid {
+		return false, nil
+	}
+
+	r, err := s.HandleRedirectRepository.Find(ctx, handle)
+	if err != nil {
+		if apperrors.Status(err) == http.StatusNotFound {
+			return true, nil
+		}
+		s.Logger.WithContext(ctx).WithField("handle", handle).WithError(err).Error("Unable to check handle redirect")
+		return false, err
+	}
+
+	return r.UID == uid, nil
+}
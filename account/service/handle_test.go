@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateHandle(t *testing.T) {
+	assert.NoError(t, validateHandle("bobby_99"))
+	assert.Error(t, validateHandle("bo"))
+	assert.Error(t, validateHandle("bob-by"))
+	assert.Error(t, validateHandle("admin"))
+	assert.Equal(t, "bobby", normalizeHandle(" @Bobby "))
+}
+
+func TestCheckHandle(t *testing.T) {
+	t.Run("Old handle of another user is unavailable", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		otherUID, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByHandle", mock.Anything, "bobby").Return(nil, apperrors.NewNotFound("handle", "bobby"))
+		mockHandleRedirectRepository := new(mocks.MockHandleRedirectRepository)
+		mockHandleRedirectRepository.On("Find", mock.Anything, "bobby").
+			Return(&model.HandleRedirect{Handle: "bobby", UID: otherUID}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:           mockUserRepository,
+			HandleRedirectRepository: mockHandleRedirectRepository,
+		})
+
+		handle, available, err := us.CheckHandle(context.TODO(), uid, "Bobby")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bobby", handle)
+		assert.False(t, available)
+	})
+
+	t.Run("Own handle is available", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByHandle", mock.Anything, "bobby").Return(&model.User{UID: uid, Handle: "bobby"}, nil)
+		mockHandleRedirectRepository := new(mocks.MockHandleRedirectRepository)
+		mockHandleRedirectRepository.On("Find", mock.Anything, "bobby").Return(nil, apperrors.NewNotFound("handle", "bobby"))
+
+		us := NewUserService(&USConfig{
+			UserRepository:           mockUserRepository,
+			HandleRedirectRepository: mockHandleRedirectRepository,
+		})
+
+		_, available, err := us.CheckHandle(context.TODO(), uid, "bobby")
+
+		assert.NoError(t, err)
+		assert.True(t, available)
+	})
+}
+
+func TestSetHandle(t *testing.T) {
+	t.Run("Rename keeps redirect for old handle", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Handle: "bob"}, nil)
+		mockUserRepository.On("FindByHandle", mock.Anything, "bobby").Return(nil, apperrors.NewNotFound("handle", "bobby"))
+		mockUserRepository.On("UpdateHandle", mock.Anything, uid, "bobby").Return(&model.User{UID: uid, Handle: "bobby"}, nil)
+		mockHandleRedirectRepository := new(mocks.MockHandleRedirectRepository)
+		mockHandleRedirectRepository.On("Find", mock.Anything, "bobby").Return(nil, apperrors.NewNotFound("handle", "bobby"))
+		mockHandleRedirectRepository.On("Delete", mock.Anything, "bobby").Return(nil)
+		mockHandleRedirectRepository.On("Save", mock.Anything, mock.MatchedBy(func(r *model.HandleRedirect) bool {
+			return r.Handle == "bob" && r.UID == uid && r.ExpiresAt.After(time.Now())
+		})).Return(nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:           mockUserRepository,
+			HandleRedirectRepository: mockHandleRedirectRepository,
+			HandleRedirectTTL:        time.Hour,
+		})
+
+		u, err := us.SetHandle(context.TODO(), uid, "Bobby")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bobby", u.Handle)
+		mockUserRepository.AssertExpectations(t)
+		mockHandleRedirectRepository.AssertExpectations(t)
+	})
+
+	t.Run("Handle taken", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		otherUID, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+		mockUserRepository.On("FindByHandle", mock.Anything, "bobby").Return(&model.User{UID: otherUID, Handle: "bobby"}, nil)
+		mockHandleRedirectRepository := new(mocks.MockHandleRedirectRepository)
+
+		us := NewUserService(&USConfig{
+			UserRepository:           mockUserRepository,
+			HandleRedirectRepository: mockHandleRedirectRepository,
+		})
+
+		u, err := us.SetHandle(context.TODO(), uid, "bobby")
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusConflict, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "UpdateHandle", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reserved handle", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		u, err := us.SetHandle(context.TODO(), uid, "Admin")
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetPublicProfileByHandle(t *testing.T) {
+	t.Run("Resolves old handle", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByHandle", mock.Anything, "bob").Return(nil, apperrors.NewNotFound("handle", "bob"))
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{
+			UID:           uid,
+			Handle:        "bobby",
+			Status:        model.StatusActive,
+			ProfilePublic: true,
+		}, nil)
+		mockHandleRedirectRepository := new(mocks.MockHandleRedirectRepository)
+		mockHandleRedirectRepository.On("Find", mock.Anything, "bob").Return(&model.HandleRedirect{Handle: "bob", UID: uid}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository:           mockUserRepository,
+			HandleRedirectRepository: mockHandleRedirectRepository,
+		})
+
+		p, err := us.GetPublicProfileByHandle(context.TODO(), "@Bob")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bobby", p.Handle)
+	})
+
+	t.Run("Private profile does not leak uid", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByHandle", mock.Anything, "bobby").Return(&model.User{UID: uid, Handle: "bobby"}, nil)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Handle: "bobby", Status: model.StatusActive}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		p, err := us.GetPublicProfileByHandle(context.TODO(), "bobby")
+
+		assert.Nil(t, p)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+		assert.NotContains(t, err.Error(), uid.String())
+	})
+}
+
+func TestSigninWithHandle(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	hashedPassword, _ := hashPassword("validpassword")
+
+	mockUserRepository := new(mocks.MockUserRepository)
+	mockUserRepository.On("FindByHandle", mock.Anything, "bobby").Return(&model.User{
+		UID:      uid,
+		Handle:   "bobby",
+		Password: hashedPassword,
+		Status:   model.StatusActive,
+	}, nil)
+
+	us := NewUserService(&USConfig{
+		UserRepository: mockUserRepository,
+	})
+
+	u := &model.User{Handle: "Bobby", Password: "validpassword"}
+	err := us.Signin(context.TODO(), u)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uid, u.UID)
+	mockUserRepository.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+}
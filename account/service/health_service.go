@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// healthService 并发检查所有依赖，开始关闭后直接返回 draining
+type healthService struct {
+	Checks   []model.HealthCheck
+	Timeout  time.Duration
+	draining int32
+}
+
+// HSConfig 就绪检查服务配置结构体
+// Timeout 为所有依赖检查的总超时时间
+type HSConfig struct {
+	Checks  []model.HealthCheck
+	Timeout time.Duration
+}
+
+// NewHealthService 创建实例
+func NewHealthService(c *HSConfig) model.HealthService {
+	return &healthService{
+		Checks:  c.Checks,
+		Timeout: c.Timeout,
+	}
+}
+
+// Ready 实现 HealthService 接口 Ready 方法
+func (s *healthService) Ready(ctx context.Context) *model.HealthReport {
+	if atomic.LoadInt32(&s.draining) == 1 {
+		return &model.HealthReport{Status: model.HealthDraining}
+	}
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	results := make([]*model.CheckResult, len(s.Checks))
+	var wg sync.WaitGroup
+	for i, check := range s.Checks {
+		wg.Add(1)
+		go func(i int, check model.HealthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := &model.HealthReport{
+		Status: model.HealthOK,
+		Checks: make(map[string]*model.CheckResult, len(s.Checks)),
+	}
+	for i, check := range s.Checks {
+		report.Checks[check.Name()] = results[i]
+		if results[i].Status != model.HealthOK {
+			report.Status = model.HealthFailing
+		}
+	}
+
+	return report
+}
+
+// Drain 实现 HealthService 接口 Drain 方法，之后的就绪检查都会失败
+func (s *healthService) Drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+// runCheck 检查单个依赖，check 没有响应 ctx 时按超时处理
+func runCheck(ctx context.Context, check model.HealthCheck) *model.CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &model.CheckResult{
+		Status:    model.HealthOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = model.HealthFailing
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"memrizr/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCheck 返回固定结果，delay 大于 0 时忽略 ctx 阻塞
+type fakeCheck struct {
+	name  string
+	err   error
+	delay time.Duration
+}
+
+func (c *fakeCheck) Name() string { return c.name }
+
+func (c *fakeCheck) Check(ctx context.Context) error {
+	time.Sleep(c.delay)
+	return c.err
+}
+
+func TestHealthService(t *testing.T) {
+	t.Run("All dependencies ok", func(t *testing.T) {
+		hs := NewHealthService(&HSConfig{
+			Checks:  []model.HealthCheck{&fakeCheck{name: "postgres"}, &fakeCheck{name: "redis"}},
+			Timeout: time.Second,
+		})
+
+		report := hs.Ready(context.TODO())
+
+		assert.True(t, report.Ready())
+		assert.Equal(t, model.HealthOK, report.Checks["postgres"].Status)
+		assert.Equal(t, model.HealthOK, report.Checks["redis"].Status)
+	})
+
+	t.Run("One dependency failing", func(t *testing.T) {
+		hs := NewHealthService(&HSConfig{
+			Checks:  []model.HealthCheck{&fakeCheck{name: "postgres"}, &fakeCheck{name: "redis", err: errors.New("connection refused")}},
+			Timeout: time.Second,
+		})
+
+		report := hs.Ready(context.TODO())
+
+		assert.False(t, report.Ready())
+		assert.Equal(t, model.HealthFailing, report.Status)
+		assert.Equal(t, model.HealthOK, report.Checks["postgres"].Status)
+		assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+	})
+
+	t.Run("Slow dependency times out", func(t *testing.T) {
+		hs := NewHealthService(&HSConfig{
+			Checks:  []model.HealthCheck{&fakeCheck{name: "postgres", delay: time.Second}},
+			Timeout: 10 * time.Millisecond,
+		})
+
+		start := time.Now()
+		report := hs.Ready(context.TODO())
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, model.HealthFailing, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["postgres"].Error)
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		hs := NewHealthService(&HSConfig{
+			Checks:  []model.HealthCheck{&fakeCheck{name: "postgres"}},
+			Timeout: time.Second,
+		})
+
+		hs.Drain()
+		report := hs.Ready(context.TODO())
+
+		assert.False(t, report.Ready())
+		assert.Equal(t, model.HealthDraining, report.Status)
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// identicon 网格大小，左右对称，只需要生成左侧 3 列
+const identiconGrid = 5
+
+// identiconBackground 背景色
+var identiconBackground = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+// generateIdenticon 根据 uid 生成确定的对称图案头像，编码为 PNG
+func generateIdenticon(uid uuid.UUID, size int) ([]byte, error) {
+	sum := sha256.Sum256(uid[:])
+
+	// 前两个字节决定色相
+	hue := float64(uint16(sum[0])<<8|uint16(sum[1])) / 65535 * 360
+	fg := hslToRGB(hue, 0.55, 0.55)
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{identiconBackground}, image.Point{}, draw.Src)
+
+	// 四周留出半个格子的边距
+	cell := size / (identiconGrid + 1)
+	margin := (size - cell*identiconGrid) / 2
+
+	half := (identiconGrid + 1) / 2
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < half; col++ {
+			if sum[2+row*half+col]&1 == 0 {
+				continue
+			}
+
+			for _, c := range []int{col, identiconGrid - 1 - col} {
+				x := margin + c*cell
+				y := margin + row*cell
+				draw.Draw(img, image.Rect(x, y, x+cell, y+cell), &image.Uniform{fg}, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// hslToRGB 将 HSL 颜色转换为 RGB，h 的范围为 [0, 360)
+func hslToRGB(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"io"
+	"memrizr/model/apperrors"
+	"net/http"
+)
+
+// allowedImageTypes 允许上传的图片类型
+var allowedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+}
+
+// detectImageType 根据文件内容判断图片类型，不信任客户端提供的 Content-Type
+// 读取后会将文件偏移重置到开头
+func detectImageType(f io.ReadSeeker) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", apperrors.NewBadRequest("Unable to read image file")
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", apperrors.NewInternal()
+	}
+
+	contentType := http.DetectContentType(buf[:n])
+	if !allowedImageTypes[contentType] {
+		return "", apperrors.NewUnsupportedMediaType("imageFile must be 'image/png', 'image/jpeg' or 'image/webp'")
+	}
+
+	return contentType, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testPNG 生成 w x h 的不透明 PNG 图片
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{200, 40, 40, 255}}, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// newFileHeader 通过 multipart 表单构造 FileHeader
+func newFileHeader(t *testing.T, content []byte) *multipart.FileHeader {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("imageFile", "avatar")
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(1 << 20)
+	assert.NoError(t, err)
+
+	return form.File["imageFile"][0]
+}
+
+func TestSetProfileImage(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+
+	t.Run("Uploads all sizes and deletes previous ones", func(t *testing.T) {
+		old := model.NewImageURLs("/media/avatars/" + uid.String() + "/old/512.jpg")
+		old.Set(64, "/media/avatars/"+uid.String()+"/old/64.jpg")
+		newURL := "/media/avatars/" + uid.String() + "/new.jpg"
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockImageRepository := new(mocks.MockImageRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			ImageRepository: mockImageRepository,
+		})
+
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, ImageURL: old.Largest(), ImageURLs: old}, nil)
+		// 不透明的图片重新编码为 JPEG
+		mockImageRepository.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(objName string) bool {
+			return strings.HasPrefix(objName, uid.String()+"/") && strings.HasSuffix(objName, ".jpg")
+		}), "image/jpeg", mock.Anything, mock.Anything).Return(newURL, nil)
+		mockUserRepository.On("UpdateImage", mock.Anything, uid, mock.MatchedBy(func(urls model.ImageURLs) bool {
+			return len(urls) == len(model.AvatarSizes) && urls.Largest() == newURL
+		})).Return(&model.User{UID: uid}, nil)
+		mockImageRepository.On("DeleteProfile", mock.Anything, mock.Anything).Return(nil)
+
+		_, err := us.SetProfileImage(context.TODO(), uid, newFileHeader(t, testPNG(t, 300, 200)))
+
+		assert.NoError(t, err)
+		mockImageRepository.AssertNumberOfCalls(t, "UpdateProfile", len(model.AvatarSizes))
+		mockImageRepository.AssertCalled(t, "DeleteProfile", mock.Anything, old["64"])
+		mockImageRepository.AssertCalled(t, "DeleteProfile", mock.Anything, old["512"])
+		mockImageRepository.AssertNumberOfCalls(t, "DeleteProfile", 2)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Rejects unsupported content", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockImageRepository := new(mocks.MockImageRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			ImageRepository: mockImageRepository,
+		})
+
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+
+		_, err := us.SetProfileImage(context.TODO(), uid, newFileHeader(t, []byte("GIF89a not allowed")))
+
+		assert.Equal(t, apperrors.UnsupportedMediaType, err.(*apperrors.Error).Type)
+		mockImageRepository.AssertNotCalled(t, "UpdateProfile", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects corrupt image", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockImageRepository := new(mocks.MockImageRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			ImageRepository: mockImageRepository,
+		})
+
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+
+		_, err := us.SetProfileImage(context.TODO(), uid, newFileHeader(t, testPNG(t, 10, 10)[:40]))
+
+		assert.Equal(t, apperrors.BadRequest, err.(*apperrors.Error).Type)
+		mockImageRepository.AssertNotCalled(t, "UpdateProfile", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Removes uploaded images when user update fails", func(t *testing.T) {
+		newURL := "/media/avatars/" + uid.String() + "/new.jpg"
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockImageRepository := new(mocks.MockImageRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			ImageRepository: mockImageRepository,
+		})
+
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+		mockImageRepository.On("UpdateProfile", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(newURL, nil)
+		mockUserRepository.On("UpdateImage", mock.Anything, uid, mock.Anything).Return(nil, apperrors.NewInternal())
+		mockImageRepository.On("DeleteProfile", mock.Anything, newURL).Return(nil)
+
+		_, err := us.SetProfileImage(context.TODO(), uid, newFileHeader(t, testPNG(t, 64, 64)))
+
+		assert.Error(t, err)
+		mockImageRepository.AssertCalled(t, "DeleteProfile", mock.Anything, newURL)
+	})
+}
+
+func TestClearProfileImage(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	oldURL := "/media/avatars/" + uid.String() + "/old/512.jpg"
+
+	mockUserRepository := new(mocks.MockUserRepository)
+	mockImageRepository := new(mocks.MockImageRepository)
+	us := NewUserService(&USConfig{
+		UserRepository:  mockUserRepository,
+		ImageRepository: mockImageRepository,
+	})
+
+	// 所有尺寸相同的地址只删除一次
+	mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, ImageURL: oldURL, ImageURLs: model.NewImageURLs(oldURL)}, nil)
+	mockUserRepository.On("UpdateImage", mock.Anything, uid, model.ImageURLs{}).Return(&model.User{UID: uid}, nil)
+	mockImageRepository.On("DeleteProfile", mock.Anything, oldURL).Return(nil).Once()
+
+	err := us.ClearProfileImage(context.TODO(), uid)
+
+	assert.NoError(t, err)
+	mockUserRepository.AssertExpectations(t)
+	mockImageRepository.AssertExpectations(t)
+}
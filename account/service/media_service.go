@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"path"
+)
+
+// mediaService 对象存储文件访问服务
+type mediaService struct {
+	Storage model.ObjectStorage
+	Signer  model.URLSigner
+}
+
+// MSConfig 对象存储文件访问服务配置结构体
+type MSConfig struct {
+	Storage model.ObjectStorage
+	Signer  model.URLSigner
+}
+
+// NewMediaService 创建实例
+func NewMediaService(c *MSConfig) model.MediaService {
+	return &mediaService{
+		Storage: c.Storage,
+		Signer:  c.Signer,
+	}
+}
+
+// Open 实现 MediaService 接口 Open 方法
+func (s *mediaService) Open(ctx context.Context, key string, expires string, signature string) (*model.Object, error) {
+	// 只允许访问带可见性前缀的规范化 key
+	if key == "" || path.Clean("/"+key) != "/"+key {
+		return nil, apperrors.NewNotFound("object", key)
+	}
+
+	if err := s.Signer.Verify(key, expires, signature); err != nil {
+		return nil, err
+	}
+
+	return s.Storage.Get(ctx, key)
+}
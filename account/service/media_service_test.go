@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"io"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMediaOpen(t *testing.T) {
+	t.Run("Opens verified object", func(t *testing.T) {
+		mockStorage := new(mocks.MockObjectStorage)
+		mockSigner := new(mocks.MockURLSigner)
+		ms := NewMediaService(&MSConfig{
+			Storage: mockStorage,
+			Signer:  mockSigner,
+		})
+
+		obj := &model.Object{Body: io.NopCloser(strings.NewReader("data")), ContentType: "application/pdf"}
+
+		mockSigner.On("Verify", "private/cards/c1.pdf", "123", "sig").Return(nil)
+		mockStorage.On("Get", mock.Anything, "private/cards/c1.pdf").Return(obj, nil)
+
+		got, err := ms.Open(context.TODO(), "private/cards/c1.pdf", "123", "sig")
+
+		assert.NoError(t, err)
+		assert.Equal(t, obj, got)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		mockStorage := new(mocks.MockObjectStorage)
+		mockSigner := new(mocks.MockURLSigner)
+		ms := NewMediaService(&MSConfig{
+			Storage: mockStorage,
+			Signer:  mockSigner,
+		})
+
+		mockSigner.On("Verify", "private/cards/c1.pdf", "123", "bad").Return(apperrors.NewForbidden("Invalid signature"))
+
+		_, err := ms.Open(context.TODO(), "private/cards/c1.pdf", "123", "bad")
+
+		assert.Equal(t, http.StatusForbidden, apperrors.Status(err))
+		mockStorage.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects non-canonical keys", func(t *testing.T) {
+		mockStorage := new(mocks.MockObjectStorage)
+		mockSigner := new(mocks.MockURLSigner)
+		ms := NewMediaService(&MSConfig{
+			Storage: mockStorage,
+			Signer:  mockSigner,
+		})
+
+		for _, key := range []string{"", "public/../private/cards/c1.pdf", "public//a.png", "public/a/"} {
+			_, err := ms.Open(context.TODO(), key, "", "")
+			assert.Equal(t, http.StatusNotFound, apperrors.Status(err), key)
+		}
+		mockSigner.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
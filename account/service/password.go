@@ -9,6 +9,12 @@ import (
 	"golang.org/x/crypto/scrypt"
 )
 
+// 密码长度，与注册时的校验一致
+const (
+	minPasswordLength = 6
+	maxPasswordLength = 30
+)
+
 // 密码 hash
 func hashPassword(password string) (string, error) {
 	salt := make([]byte, 32)
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetPublicProfile 实现 UserService 接口 GetPublicProfile 方法
+// 未公开或不可用的账号视为不存在，避免泄露账号是否存在，其他错误原样返回
+func (s *userService) GetPublicProfile(ctx context.Context, uid uuid.UUID) (*model.PublicProfile, error) {
+	u, err := s.UserRepository.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !u.ProfilePublic || !u.IsActive(time.Now()) {
+		return nil, apperrors.NewNotFound("user", uid.String())
+	}
+
+	p := u.PublicProfile()
+
+	// 隐藏或没有上传头像时显示生成的默认头像
+	if len(p.ImageURLs) == 0 && s.AvatarService != nil {
+		p.ImageURLs = s.AvatarService.DefaultURLs(u.UID)
+	}
+
+	return p, nil
+}
+
+// UpdatePrivacy 实现 UserService 接口 UpdatePrivacy 方法
+func (s *userService) UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *model.ProfilePrivacy) (*model.User, error) {
+	var u *model.User
+	err := runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		var err error
+		if u, err = s.UserRepository.UpdatePrivacy(ctx, uid, p); err != nil {
+			return err
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, u.UID, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &uid,
+		TargetID: &uid,
+		Action:   model.AuditPrivacyUpdate,
+		Details: model.AuditDetails{
+			"public":       strconv.FormatBool(p.Public),
+			"show_website": strconv.FormatBool(p.ShowWebsite),
+			"show_avatar":  strconv.FormatBool(p.ShowAvatar),
+		},
+	})
+
+	s.withDefaultAvatar(u)
+
+	return u, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetPublicProfile(t *testing.T) {
+	t.Run("Applies visibility settings", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		defaults := model.NewImageURLs("http://api/avatars/default/" + uid.String() + "/512.png")
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{
+			UID:           uid,
+			Name:          "Bobby",
+			Email:         "bob@bob.com",
+			Website:       "https://bob.com",
+			ImageURLs:     model.NewImageURLs("http://api/media/public/avatars/a.jpg"),
+			Status:        model.StatusActive,
+			ProfilePublic: true,
+			ShowWebsite:   false,
+			ShowAvatar:    false,
+		}, nil)
+		mockAvatarService := new(mocks.MockAvatarService)
+		mockAvatarService.On("DefaultURLs", uid).Return(defaults)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+			AvatarService:  mockAvatarService,
+		})
+
+		p, err := us.GetPublicProfile(context.TODO(), uid)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bobby", p.Name)
+		assert.Empty(t, p.Website)
+		assert.Equal(t, defaults, p.ImageURLs)
+	})
+
+	t.Run("Private profile is not found", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{
+			UID:    uid,
+			Status: model.StatusActive,
+		}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		p, err := us.GetPublicProfile(context.TODO(), uid)
+
+		assert.Nil(t, p)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+
+	t.Run("Disabled user is not found", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{
+			UID:           uid,
+			Status:        model.StatusDisabled,
+			ProfilePublic: true,
+		}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		p, err := us.GetPublicProfile(context.TODO(), uid)
+
+		assert.Nil(t, p)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+
+	t.Run("Repository failure is not hidden", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(nil, apperrors.NewInternal())
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		p, err := us.GetPublicProfile(context.TODO(), uid)
+
+		assert.Nil(t, p)
+		assert.Equal(t, http.StatusInternalServerError, apperrors.Status(err))
+	})
+}
+
+func TestUpdatePrivacy(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	p := &model.ProfilePrivacy{Public: true, ShowWebsite: true}
+
+	mockUserRepository := new(mocks.MockUserRepository)
+	mockUserRepository.On("UpdatePrivacy", mock.Anything, uid, p).
+		Return(&model.User{UID: uid, ProfilePublic: true, ShowWebsite: true}, nil)
+	mockAuditService := new(mocks.MockAuditService)
+	mockAuditService.On("Record", mock.Anything, mock.MatchedBy(func(e *model.AuditEvent) bool {
+		return e.Action == model.AuditPrivacyUpdate && e.Details["public"] == "true" && e.Details["show_avatar"] == "false"
+	}))
+
+	us := NewUserService(&USConfig{
+		UserRepository: mockUserRepository,
+		AuditService:   mockAuditService,
+	})
+
+	u, err := us.UpdatePrivacy(context.TODO(), uid, p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, p, u.Privacy())
+	mockUserRepository.AssertExpectations(t)
+	mockAuditService.AssertExpectations(t)
+}
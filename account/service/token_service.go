@@ -3,21 +3,39 @@ package service
 import (
 	"context"
 	"crypto/rsa"
-	"log"
+	"fmt"
+	"memrizr/logging"
 	"memrizr/model"
 	"memrizr/model/apperrors"
+	"memrizr/tracing"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // TokenService Token服务层
+// 密钥和有效期保存在 keys 中，SetKeys 整体替换，正在处理的请求使用替换前的值
 type tokenService struct {
-	TokenRepository       model.TokenRepository
-	PrivateKey            *rsa.PrivateKey
-	PublicKey             *rsa.PublicKey
-	RefreshSecret         string
-	IDExpirationSecs      int64
-	RefreshExpirationSecs int64
+	TokenRepository model.TokenRepository
+	EventRepository model.EventRepository
+	AuditService    model.AuditService
+	Logger          *logrus.Logger
+	keys            atomic.Value // *tokenKeySet
+	setKeysMu       sync.Mutex
+	now             func() time.Time
+}
+
+// tokenKeySet 当前密钥和替换前的密钥
+// 替换前的密钥只用于验证，在 previousUntil 之前签发的 token 仍然有效
+type tokenKeySet struct {
+	current       *model.TokenKeys
+	previous      *model.TokenKeys
+	previousUntil time.Time
 }
 
 // TSConfig Token服务层配置结构体
@@ -28,58 +46,189 @@ type TSConfig struct {
 	RefreshSecret         string
 	IDExpirationSecs      int64
 	RefreshExpirationSecs int64
+	EventRepository       model.EventRepository
+	AuditService          model.AuditService
+	// Logger 可选，为空时使用 logging.Default
+	Logger *logrus.Logger
 }
 
 // NewTokenService 实例化TokenService
 func NewTokenService(c *TSConfig) model.TokenService {
-	return &tokenService{
-		TokenRepository:       c.TokenRepository,
+	s := &tokenService{
+		TokenRepository: c.TokenRepository,
+		EventRepository: c.EventRepository,
+		AuditService:    c.AuditService,
+		Logger:          logging.OrDefault(c.Logger),
+		now:             time.Now,
+	}
+	s.keys.Store(&tokenKeySet{current: &model.TokenKeys{
 		PrivateKey:            c.PrivateKey,
 		PublicKey:             c.PublicKey,
 		RefreshSecret:         c.RefreshSecret,
 		IDExpirationSecs:      c.IDExpirationSecs,
 		RefreshExpirationSecs: c.RefreshExpirationSecs,
+	}})
+	return s
+}
+
+// SetKeys 校验后替换签名密钥和有效期
+// 旧的公钥和 refresh secret 在旧 refresh token 的有效期内继续用于验证，已登录的用户不会被登出
+func (s *tokenService) SetKeys(k *model.TokenKeys) error {
+	if k.PrivateKey == nil || k.PublicKey == nil {
+		return fmt.Errorf("private and public keys are required")
+	}
+	if !k.PrivateKey.PublicKey.Equal(k.PublicKey) {
+		return fmt.Errorf("public key does not match private key")
+	}
+	if k.RefreshSecret == "" {
+		return fmt.Errorf("refresh secret is required")
+	}
+	if k.IDExpirationSecs <= 0 || k.RefreshExpirationSecs <= 0 {
+		return fmt.Errorf("token expirations must be positive")
+	}
+
+	s.setKeysMu.Lock()
+	defer s.setKeysMu.Unlock()
+
+	old := s.keySet()
+	keys := *k
+	next := &tokenKeySet{current: &keys}
+
+	if old.current.PublicKey.Equal(keys.PublicKey) && old.current.RefreshSecret == keys.RefreshSecret {
+		// 只修改了有效期，保留之前的宽限期
+		next.previous, next.previousUntil = old.previous, old.previousUntil
+	} else {
+		next.previous = old.current
+		next.previousUntil = s.now().Add(time.Duration(old.current.RefreshExpirationSecs) * time.Second)
+	}
+
+	s.keys.Store(next)
+	return nil
+}
+
+// keySet 当前的密钥集合
+func (s *tokenService) keySet() *tokenKeySet {
+	return s.keys.Load().(*tokenKeySet)
+}
+
+// currentKeys 当前用于签发的密钥
+func (s *tokenService) currentKeys() *model.TokenKeys {
+	return s.keySet().current
+}
+
+// verificationKeys 用于验证的密钥，当前密钥在前，宽限期内包括替换前的密钥
+func (s *tokenService) verificationKeys() []*model.TokenKeys {
+	ks := s.keySet()
+	if ks.previous == nil || !s.now().Before(ks.previousUntil) {
+		return []*model.TokenKeys{ks.current}
 	}
+	return []*model.TokenKeys{ks.current, ks.previous}
 }
 
 // NewTokenPairFromUser 实现方法
 func (s *tokenService) NewTokenPairFromUser(ctx context.Context, u *model.User, prevIDToken string) (*model.TokenPair, error) {
+	logger := s.Logger.WithContext(ctx).WithField("uid", u.UID)
+
 	if prevIDToken != "" {
-		if err := s.TokenRepository.DeleteRefreshToken(ctx, u.UID.String(), prevIDToken); err != nil {
-			log.Printf("could not delete previous refreshToken for uid: %v, tokenID: %v\n", u.UID.String(), prevIDToken)
+		// 记录刷新失败
+		refreshFailed := func(reason string) {
+			recordAudit(ctx, s.AuditService, &model.AuditEvent{
+				ActorID:  &u.UID,
+				TargetID: &u.UID,
+				Action:   model.AuditTokenRefresh,
+				Outcome:  model.OutcomeFailure,
+				Details:  model.AuditDetails{"token_id": prevIDToken, "reason": reason},
+			})
+		}
+
+		// 刷新 token 时拒绝被暂停或禁用的用户
+		if err := checkUserActive(u); err != nil {
+			logger.WithField("status", u.Status).Warn("Refusing to refresh tokens for inactive user")
+			refreshFailed(u.Status)
 			return nil, err
 		}
-	}
 
-	idToken, err := generateIDToken(u, s.PrivateKey, s.IDExpirationSecs)
-	if err != nil {
-		log.Printf("Error generateing idToken for uid: %v. Error: %v\n", u.UID, err.Error())
-		return nil, apperrors.NewInternal()
+		if err := s.TokenRepository.DeleteRefreshToken(ctx, u.UID.String(), prevIDToken); err != nil {
+			logger.WithField("token_id", prevIDToken).WithError(err).Warn("Could not delete previous refresh token")
+			refreshFailed(err.Error())
+			return nil, err
+		}
 	}
 
-	refreshTokenData, err := generateRefreshToken(u.UID, s.RefreshSecret, s.RefreshExpirationSecs)
+	idToken, refreshTokenData, err := s.signTokens(ctx, u)
 	if err != nil {
-		log.Printf("Error generateing refreshToken for uid: %v. Error: %v\n", u.UID, err.Error())
-		return nil, apperrors.NewInternal()
+		return nil, err
 	}
 
 	// 保存 refresh token
 	if err := s.TokenRepository.SetRefreshToken(ctx, u.UID.String(), refreshTokenData.ID.String(), refreshTokenData.ExpiresIn); err != nil {
-		log.Printf("Error storing tokenID for uid: %v. Error: %v\n", u.UID, err.Error())
+		logger.WithError(err).Error("Error storing refresh token ID")
 		return nil, apperrors.NewInternal()
 	}
 
+	if prevIDToken != "" {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			ActorID:  &u.UID,
+			TargetID: &u.UID,
+			Action:   model.AuditTokenRefresh,
+			Details:  model.AuditDetails{"token_id": refreshTokenData.ID.String(), "prev_token_id": prevIDToken},
+		})
+	} else {
+		// 新会话，刷新 token 只是轮换同一个会话
+		// refresh token 保存在 Redis，无法与 outbox 在同一事务中写入，事件写入失败时撤销该会话
+		payload := &model.SessionEventPayload{UID: u.UID, TokenID: refreshTokenData.ID.String()}
+		if err := appendEvent(ctx, s.EventRepository, model.EventSessionCreated, u.UID, payload); err != nil {
+			logger.WithError(err).Error("Unable to append session created event")
+			if err := s.TokenRepository.DeleteRefreshToken(ctx, u.UID.String(), refreshTokenData.ID.String()); err != nil {
+				logger.WithError(err).Warn("Could not delete refresh token of unrecorded session")
+			}
+			return nil, err
+		}
+	}
+
 	return &model.TokenPair{
 		IDToken:      model.IDToken{SS: idToken},
 		RefreshToken: model.RefreshToken{SS: refreshTokenData.SS, ID: refreshTokenData.ID, UID: u.UID},
 	}, nil
 }
 
+// signTokens 签发 id token 和 refresh token，签名耗时记录在单独的 span 中
+func (s *tokenService) signTokens(ctx context.Context, u *model.User) (string, *refreshTokenData, error) {
+	logger := s.Logger.WithContext(ctx).WithField("uid", u.UID)
+
+	_, span := otel.Tracer(tracing.InstrumentationName).Start(ctx, "jwt.Sign")
+	defer span.End()
+
+	keys := s.currentKeys()
+
+	idToken, err := generateIDToken(u, keys.PrivateKey, keys.IDExpirationSecs)
+	if err != nil {
+		logger.WithError(err).Error("Error generating id token")
+		span.SetStatus(codes.Error, err.Error())
+		return "", nil, apperrors.NewInternal()
+	}
+
+	refreshTokenData, err := generateRefreshToken(u.UID, keys.RefreshSecret, keys.RefreshExpirationSecs)
+	if err != nil {
+		logger.WithError(err).Error("Error generating refresh token")
+		span.SetStatus(codes.Error, err.Error())
+		return "", nil, apperrors.NewInternal()
+	}
+
+	return idToken, refreshTokenData, nil
+}
+
 // ValidateIDToken 验证 token
 func (s *tokenService) ValidateIDToken(tokenString string) (*model.User, error) {
-	claims, err := validateIDToken(tokenString, s.PublicKey)
+	var claims *idTokenCustomClaims
+	var err error
+	for _, keys := range s.verificationKeys() {
+		if claims, err = validateIDToken(tokenString, keys.PublicKey); err == nil {
+			break
+		}
+	}
 	if err != nil {
-		log.Printf("Unable to validate or parse idToken - Error: %v\n", err)
+		s.Logger.WithError(err).Debug("Unable to validate or parse id token")
 		return nil, apperrors.NewAuthorization("Unable to verify user from idToken")
 	}
 	return claims.User, nil
@@ -87,20 +236,55 @@ func (s *tokenService) ValidateIDToken(tokenString string) (*model.User, error)
 
 // ValidateRefreshToken 验证 refreshToken
 func (s *tokenService) ValidateRefreshToken(tokenString string) (*model.RefreshToken, error) {
-	claims, err := validateRefreshToken(tokenString, s.RefreshSecret)
+	var claims *refreshTokenCustomClaims
+	var err error
+	for _, keys := range s.verificationKeys() {
+		if claims, err = validateRefreshToken(tokenString, keys.RefreshSecret); err == nil {
+			break
+		}
+	}
 	if err != nil {
-		log.Printf("Unable to validate or parse refreshToken for token string: %s\n%v\n", tokenString, err)
+		// 不记录 token 本身，持有者可以用它换取新的 token
+		s.Logger.WithError(err).Info("Unable to validate or parse refresh token")
 		return nil, apperrors.NewAuthorization("Unable to verify user from refresh token")
 	}
 
 	tokenUUID, err := uuid.Parse(claims.Id)
 	if err != nil {
-		log.Printf("Claims ID could not be parsed as UUID: %s\n%v\n", claims.Id, err)
+		s.Logger.WithField("token_id", claims.Id).WithError(err).Info("Refresh token ID could not be parsed as UUID")
 		return nil, apperrors.NewAuthorization("Unable to verify user from refresh token")
 	}
 	return &model.RefreshToken{ID: tokenUUID, UID: claims.UID, SS: tokenString}, nil
 }
 
 func (s *tokenService) Signout(ctx context.Context, uid uuid.UUID) error {
-	return s.TokenRepository.DeleteUserRefreshTokens(ctx, uid.String())
+	if err := s.TokenRepository.DeleteUserRefreshTokens(ctx, uid.String()); err != nil {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			ActorID:  &uid,
+			TargetID: &uid,
+			Action:   model.AuditSignout,
+			Outcome:  model.OutcomeFailure,
+			Details:  model.AuditDetails{"error": err.Error()},
+		})
+		return err
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &uid,
+		TargetID: &uid,
+		Action:   model.AuditSignout,
+	})
+
+	payload := &model.SessionEventPayload{UID: uid, Reason: "signout"}
+	if err := appendEvent(ctx, s.EventRepository, model.EventSessionRevoked, uid, payload); err != nil {
+		s.Logger.WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to append session revoked event")
+		return err
+	}
+
+	return nil
+}
+
+// CountSessions 获取用户当前的活跃会话数
+func (s *tokenService) CountSessions(ctx context.Context, uid uuid.UUID) (int, error) {
+	return s.TokenRepository.CountUserRefreshTokens(ctx, uid.String())
 }
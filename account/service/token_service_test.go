@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"fmt"
-	"io/ioutil"
 	"memrizr/model"
+	"memrizr/model/apperrors"
 	"memrizr/model/mocks"
+	"net/http"
 	"testing"
 	"time"
 
@@ -19,10 +22,9 @@ func TestNewTokenPairFromUser(t *testing.T) {
 	var idExp int64 = 15 * 60
 	var refreshExp int64 = 3 * 24 * 2600
 
-	priv, _ := ioutil.ReadFile("../rsa_private_test.pem")
-	privKey, _ := jwt.ParseRSAPrivateKeyFromPEM(priv)
-	pub, _ := ioutil.ReadFile("../rsa_public_test.pem")
-	pubKey, _ := jwt.ParseRSAPublicKeyFromPEM(pub)
+	// 仓库中没有测试用的密钥文件，直接生成
+	privKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubKey := &privKey.PublicKey
 	secret := "anotsorandomtestsecret"
 
 	// token 存储服务
@@ -44,6 +46,7 @@ func TestNewTokenPairFromUser(t *testing.T) {
 		UID:      uid,
 		Email:    "bob@bob.com",
 		Password: "blarghedymcblarghface",
+		Status:   model.StatusActive,
 	}
 
 	// token 存储测试数据
@@ -52,26 +55,27 @@ func TestNewTokenPairFromUser(t *testing.T) {
 		UID:      uidErrorCase,
 		Email:    "failure@failure.com",
 		Password: "blarghedymcblarghface",
+		Status:   model.StatusActive,
 	}
 
 	prevID := "a_previous_tokenID"
 
 	setSuccessArguments := mock.Arguments{
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		u.UID.String(),
 		mock.AnythingOfType("string"),
 		mock.AnythingOfType("time.Duration"),
 	}
 
 	setErrorArguments := mock.Arguments{
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		uErrorCase.UID.String(),
 		mock.AnythingOfType("string"),
 		mock.AnythingOfType("time.Duration"),
 	}
 
 	deleteWithPrevIDArguments := mock.Arguments{
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		u.UID.String(),
 		prevID,
 	}
@@ -159,4 +163,107 @@ func TestNewTokenPairFromUser(t *testing.T) {
 		mockTokenRepository.AssertNotCalled(t, "DeleteRefreshToken")
 
 	})
+
+	t.Run("Refuses refresh for inactive users", func(t *testing.T) {
+		until := time.Now().Add(time.Hour)
+		inactive := map[string]*model.User{
+			"Suspended": {UID: uuid.New(), Status: model.StatusSuspended, StatusExpiresAt: &until},
+			"Disabled":  {UID: uuid.New(), Status: model.StatusDisabled},
+		}
+
+		for name, iu := range inactive {
+			t.Run(name, func(t *testing.T) {
+				tokenPair, err := tokenService.NewTokenPairFromUser(context.Background(), iu, prevID)
+
+				assert.Nil(t, tokenPair)
+				assert.Equal(t, http.StatusForbidden, apperrors.Status(err))
+				mockTokenRepository.AssertNotCalled(t, "DeleteRefreshToken", mock.Anything, iu.UID.String(), prevID)
+				mockTokenRepository.AssertNotCalled(t, "SetRefreshToken", mock.Anything, iu.UID.String(), mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestSetKeys(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+
+	mockTokenRepository := new(mocks.MockTokenRepository)
+	mockTokenRepository.On("SetRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ts := NewTokenService(&TSConfig{
+		TokenRepository:       mockTokenRepository,
+		PrivateKey:            oldKey,
+		PublicKey:             &oldKey.PublicKey,
+		RefreshSecret:         "oldsecret",
+		IDExpirationSecs:      60,
+		RefreshExpirationSecs: 120,
+	})
+
+	uid, _ := uuid.NewRandom()
+	u := &model.User{UID: uid}
+
+	oldPair, err := ts.NewTokenPairFromUser(context.TODO(), u, "")
+	assert.NoError(t, err)
+
+	t.Run("Rejects invalid keys", func(t *testing.T) {
+		err := ts.SetKeys(&model.TokenKeys{
+			PrivateKey:            newKey,
+			PublicKey:             &oldKey.PublicKey,
+			RefreshSecret:         "newsecret",
+			IDExpirationSecs:      60,
+			RefreshExpirationSecs: 120,
+		})
+		assert.Error(t, err)
+
+		err = ts.SetKeys(&model.TokenKeys{
+			PrivateKey:       newKey,
+			PublicKey:        &newKey.PublicKey,
+			IDExpirationSecs: 60,
+		})
+		assert.Error(t, err)
+
+		// 保留原来的密钥
+		_, err = ts.ValidateIDToken(oldPair.IDToken.SS)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Swaps keys", func(t *testing.T) {
+		err := ts.SetKeys(&model.TokenKeys{
+			PrivateKey:            newKey,
+			PublicKey:             &newKey.PublicKey,
+			RefreshSecret:         "newsecret",
+			IDExpirationSecs:      60,
+			RefreshExpirationSecs: 120,
+		})
+		assert.NoError(t, err)
+
+		// 宽限期内旧密钥签发的 token 仍然有效
+		_, err = ts.ValidateIDToken(oldPair.IDToken.SS)
+		assert.NoError(t, err)
+		_, err = ts.ValidateRefreshToken(oldPair.RefreshToken.SS)
+		assert.NoError(t, err)
+
+		newPair, err := ts.NewTokenPairFromUser(context.TODO(), u, "")
+		assert.NoError(t, err)
+		_, err = ts.ValidateIDToken(newPair.IDToken.SS)
+		assert.NoError(t, err)
+		_, err = ts.ValidateRefreshToken(newPair.RefreshToken.SS)
+		assert.NoError(t, err)
+
+		// 新 token 不能用旧密钥验证
+		_, err = validateIDToken(newPair.IDToken.SS, &oldKey.PublicKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("Previous keys expire after the refresh expiration", func(t *testing.T) {
+		s := ts.(*tokenService)
+		s.now = func() time.Time { return time.Now().Add(121 * time.Second) }
+		defer func() { s.now = time.Now }()
+
+		_, err := ts.ValidateIDToken(oldPair.IDToken.SS)
+		assert.Error(t, err)
+		_, err = ts.ValidateRefreshToken(oldPair.RefreshToken.SS)
+		assert.Error(t, err)
+	})
 }
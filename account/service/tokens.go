@@ -3,7 +3,6 @@ package service
 import (
 	"crypto/rsa"
 	"fmt"
-	"log"
 	"memrizr/model"
 	"time"
 
@@ -33,8 +32,7 @@ func generateIDToken(u *model.User, key *rsa.PrivateKey, exp int64) (string, err
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
 	ss, err := token.SignedString(key)
 	if err != nil {
-		log.Println("Faild to sign id token string")
-		return "", err
+		return "", fmt.Errorf("sign id token: %w", err)
 	}
 
 	return ss, nil
@@ -60,8 +58,7 @@ func generateRefreshToken(uid uuid.UUID, key string, exp int64) (*refreshTokenDa
 	tokenID, err := uuid.NewRandom()
 
 	if err != nil {
-		log.Println("Faild to generate refresh token ID")
-		return nil, err
+		return nil, fmt.Errorf("generate refresh token ID: %w", err)
 	}
 
 	claims := refreshTokenCustomClaims{
@@ -76,8 +73,7 @@ func generateRefreshToken(uid uuid.UUID, key string, exp int64) (*refreshTokenDa
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	ss, err := token.SignedString([]byte(key))
 	if err != nil {
-		log.Println("Faild to sign refresh token string")
-		return nil, err
+		return nil, fmt.Errorf("sign refresh token: %w", err)
 	}
 
 	return &refreshTokenData{
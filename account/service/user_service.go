@@ -1,57 +1,174 @@
 package service
 
 import (
+	"bytes"
 	"context"
-	"log"
+	"fmt"
+	"io"
+	"memrizr/logging"
 	"memrizr/model"
 	"memrizr/model/apperrors"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 管理员用户列表分页大小
+const (
+	defaultUserListLimit = 20
+	maxUserListLimit     = 100
 )
 
 // 用户服务层结构体
 type userService struct {
-	UserRepository model.UserRepository
+	UserRepository  model.UserRepository
+	TokenRepository model.TokenRepository
+	EventRepository model.EventRepository
+	Transactor      model.Transactor
+	AuditService    model.AuditService
+	ImageRepository model.ImageRepository
+	AvatarService   model.AvatarService
+
+	EmailChangeRepository model.EmailChangeRepository
+	Mailer                model.Mailer
+	EmailConfirmURL       string
+	EmailChangeTTL        time.Duration
+	EmailProviderRules    bool
+
+	HandleRedirectRepository model.HandleRedirectRepository
+	HandleRedirectTTL        time.Duration
+
+	Logger *logrus.Logger
 }
 
 // 用户服务层配置结构体
+// EmailConfirmURL 为邮箱确认页面地址，确认 token 作为 token 参数附加
+// EmailProviderRules 为 true 时按服务商规则去掉邮箱别名，见 normalizeEmail
+// Logger 可选，为空时使用 logging.Default
 type USConfig struct {
-	UserRepository model.UserRepository
+	UserRepository  model.UserRepository
+	TokenRepository model.TokenRepository
+	EventRepository model.EventRepository
+	Transactor      model.Transactor
+	AuditService    model.AuditService
+	ImageRepository model.ImageRepository
+	AvatarService   model.AvatarService
+
+	EmailChangeRepository model.EmailChangeRepository
+	Mailer                model.Mailer
+	EmailConfirmURL       string
+	EmailChangeTTL        time.Duration
+	EmailProviderRules    bool
+
+	HandleRedirectRepository model.HandleRedirectRepository
+	HandleRedirectTTL        time.Duration
+
+	Logger *logrus.Logger
 }
 
 // NewUserService 创建实例
 func NewUserService(c *USConfig) model.UserService {
 	return &userService{
-		UserRepository: c.UserRepository,
+		UserRepository:  c.UserRepository,
+		TokenRepository: c.TokenRepository,
+		EventRepository: c.EventRepository,
+		Transactor:      c.Transactor,
+		AuditService:    c.AuditService,
+		ImageRepository: c.ImageRepository,
+		AvatarService:   c.AvatarService,
+
+		EmailChangeRepository: c.EmailChangeRepository,
+		Mailer:                c.Mailer,
+		EmailConfirmURL:       c.EmailConfirmURL,
+		EmailChangeTTL:        c.EmailChangeTTL,
+		EmailProviderRules:    c.EmailProviderRules,
+
+		HandleRedirectRepository: c.HandleRedirectRepository,
+		HandleRedirectTTL:        c.HandleRedirectTTL,
+
+		Logger: logging.OrDefault(c.Logger),
 	}
 }
 
 // Get 实现 UserService 接口 Get 方法
 func (s *userService) Get(ctx context.Context, uid uuid.UUID) (*model.User, error) {
-	return s.UserRepository.FindByID(ctx, uid)
+	u, err := s.UserRepository.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	s.withDefaultAvatar(u)
+
+	return u, nil
 }
 
 // Signup 实现 UserService 接口 Signup 方法
 func (s *userService) Signup(ctx context.Context, u *model.User) error {
+	u.Email = s.normalizeEmail(u.Email)
+
 	pw, err := hashPassword(u.Password)
 	if err != nil {
-		log.Printf("Unable to signup user for email: %v\n", u.Email)
+		s.Logger.WithContext(ctx).WithError(err).Error("Unable to hash password for signup")
 		return apperrors.NewInternal()
 	}
 
 	u.Password = pw
-	if err := s.UserRepository.Create(ctx, u); err != nil {
+	err = runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		if err := s.UserRepository.Create(ctx, u); err != nil {
+			return err
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserCreated, u.UID, u)
+	})
+	if err != nil {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			Action:  model.AuditSignup,
+			Outcome: model.OutcomeFailure,
+			Details: model.AuditDetails{"email": u.Email, "error": err.Error()},
+		})
 		return err
 	}
 
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &u.UID,
+		TargetID: &u.UID,
+		Action:   model.AuditSignup,
+	})
+
 	return nil
 }
 
 // Signin 实现 UserService 接口 Signin 方法
 func (s *userService) Signin(ctx context.Context, u *model.User) error {
-	uFetched, err := s.UserRepository.FindByEmail(ctx, u.Email)
+	// 没有邮箱时使用 handle 登录
+	login, find, invalid := "email", s.findByEmail, "Invalid email and password combination"
+	identifier := normalizeEmail(u.Email, false)
+	if u.Email == "" && u.Handle != "" {
+		login, find, invalid = "handle", s.UserRepository.FindByHandle, "Invalid handle and password combination"
+		identifier = normalizeHandle(u.Handle)
+	}
+
+	// 记录登录失败，target 为空表示邮箱或 handle 不存在
+	signinFailed := func(target *uuid.UUID, reason string) {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			TargetID: target,
+			Action:   model.AuditSignin,
+			Outcome:  model.OutcomeFailure,
+			Details:  model.AuditDetails{login: identifier, "reason": reason},
+		})
+	}
+
+	uFetched, err := find(ctx, identifier)
+	if apperrors.Status(err) == http.StatusNotFound {
+		signinFailed(nil, "unknown "+login)
+		return apperrors.NewAuthorization(invalid)
+	}
 	if err != nil {
-		return apperrors.NewAuthorization("Invalid email and password combination")
+		return err
 	}
 	// 验证密码
 	match, err := comparePasswords(uFetched.Password, u.Password)
@@ -60,19 +177,589 @@ func (s *userService) Signin(ctx context.Context, u *model.User) error {
 	}
 
 	if !match {
-		return apperrors.NewAuthorization("Invalid email and password combination")
+		signinFailed(&uFetched.UID, "invalid password")
+		return apperrors.NewAuthorization(invalid)
+	}
+
+	if err := checkUserActive(uFetched); err != nil {
+		signinFailed(&uFetched.UID, uFetched.Status)
+		return err
 	}
 
 	*u = *uFetched
+	s.withDefaultAvatar(u)
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &u.UID,
+		TargetID: &u.UID,
+		Action:   model.AuditSignin,
+	})
+
 	return nil
 }
 
 // UpdateDetails 实现 UserService 接口 UpdateDetails 方法
-func (s *userService) UpdateDetails(ctx context.Context, u *model.User) error {
-	err := s.UserRepository.Update(ctx, u)
+// u.Version 大于 0 时要求与当前版本一致
+// 修改邮箱需要确认，u.Email 保持原邮箱直到确认，返回是否请求了邮箱修改
+// 待确认的修改与资料在同一事务中保存，确认邮件在提交后发送
+func (s *userService) UpdateDetails(ctx context.Context, u *model.User) (bool, error) {
+	current, err := s.UserRepository.FindByID(ctx, u.UID)
+	if err != nil {
+		return false, err
+	}
+
+	if u.Version > 0 && u.Version != current.Version {
+		return false, errUserModified()
+	}
+
+	newEmail := s.normalizeEmail(u.Email)
+	u.Email = current.Email
+	changeEmail := newEmail != "" && !sameEmail(newEmail, current.Email)
+
+	var r *emailChangeRequest
+	err = runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		if err := s.UserRepository.Update(ctx, u); err != nil {
+			return err
+		}
+		if changeEmail {
+			var err error
+			if r, err = s.saveEmailChange(ctx, current, newEmail); err != nil {
+				return err
+			}
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, u.UID, u)
+	})
+	if err != nil {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			ActorID:  &u.UID,
+			TargetID: &u.UID,
+			Action:   model.AuditDetailsUpdate,
+			Outcome:  model.OutcomeFailure,
+			Details:  model.AuditDetails{"error": err.Error()},
+		})
+		return false, err
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &u.UID,
+		TargetID: &u.UID,
+		Action:   model.AuditDetailsUpdate,
+		Details:  model.AuditDetails{"email": u.Email, "name": u.Name, "website": u.Website},
+	})
+
+	s.withDefaultAvatar(u)
+
+	if r != nil {
+		if err := s.sendEmailChange(ctx, r); err != nil {
+			return false, err
+		}
+	}
+
+	return r != nil, nil
+}
+
+// PatchDetails 实现 UserService 接口 PatchDetails 方法
+// version 大于 0 时要求与当前版本一致
+// 修改邮箱需要确认，其他字段直接更新；没有其他字段时直接返回当前用户
+// 返回是否请求了邮箱修改，待确认的修改与其他字段在同一事务中保存，确认邮件在提交后发送
+func (s *userService) PatchDetails(ctx context.Context, uid uuid.UUID, version int64, p model.UserPatch) (*model.User, bool, error) {
+	if email, ok := p[model.FieldEmail]; ok && email == nil {
+		return nil, false, apperrors.NewBadRequest("email cannot be cleared")
+	}
+
+	current, err := s.UserRepository.FindByID(ctx, uid)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if version > 0 && version != current.Version {
+		return nil, false, errUserModified()
+	}
+
+	// 不修改调用方的 patch
+	var newEmail string
+	rest := make(model.UserPatch, len(p))
+	for k, v := range p {
+		if k != model.FieldEmail {
+			rest[k] = v
+			continue
+		}
+
+		if email := s.normalizeEmail(*v); !sameEmail(email, current.Email) {
+			newEmail = email
+		}
+	}
+	p = rest
+
+	if len(p) == 0 {
+		if newEmail != "" {
+			if err := s.requestEmailChange(ctx, current, newEmail); err != nil {
+				return nil, false, err
+			}
+		}
+		s.withDefaultAvatar(current)
+		return current, newEmail != "", nil
+	}
+
+	var u *model.User
+	var r *emailChangeRequest
+	err = runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		var err error
+		if u, err = s.UserRepository.Patch(ctx, uid, version, p); err != nil {
+			return err
+		}
+		if newEmail != "" {
+			if r, err = s.saveEmailChange(ctx, current, newEmail); err != nil {
+				return err
+			}
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, u.UID, u)
+	})
+	if err != nil {
+		recordAudit(ctx, s.AuditService, &model.AuditEvent{
+			ActorID:  &uid,
+			TargetID: &uid,
+			Action:   model.AuditDetailsUpdate,
+			Outcome:  model.OutcomeFailure,
+			Details:  model.AuditDetails{"error": err.Error()},
+		})
+		return nil, false, err
+	}
+
+	// 只记录本次修改的字段，清空的字段记录为空字符串
+	details := model.AuditDetails{}
+	for k, v := range p {
+		details[k] = ""
+		if v != nil {
+			details[k] = *v
+		}
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &uid,
+		TargetID: &uid,
+		Action:   model.AuditDetailsUpdate,
+		Details:  details,
+	})
+
+	s.withDefaultAvatar(u)
+
+	if r != nil {
+		if err := s.sendEmailChange(ctx, r); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return u, r != nil, nil
+}
+
+// List 实现 UserService 接口 List 方法
+func (s *userService) List(ctx context.Context, q *model.UserListQuery) (*model.UserPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultUserListLimit
+	}
+
+	if q.Limit > maxUserListLimit {
+		q.Limit = maxUserListLimit
+	}
+
+	page, err := s.UserRepository.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range page.Users {
+		s.withDefaultAvatar(u)
+	}
+
+	return page, nil
+}
+
+// AdminCreate 实现 UserService 接口 AdminCreate 方法
+// 管理员创建用户，姓名和角色与用户在同一事务中保存，u.Role 为空时为普通用户
+func (s *userService) AdminCreate(ctx context.Context, actorID uuid.UUID, u *model.User) error {
+	if u.Role == "" {
+		u.Role = model.RoleUser
+	}
+	if u.Role != model.RoleUser && u.Role != model.RoleAdmin {
+		return apperrors.NewBadRequest(fmt.Sprintf("unknown role: %v", u.Role))
+	}
+	if n := utf8.RuneCountInString(u.Password); n < minPasswordLength || n > maxPasswordLength {
+		return apperrors.NewBadRequest(fmt.Sprintf("password must be %d to %d characters", minPasswordLength, maxPasswordLength))
+	}
+
+	u.Email = s.normalizeEmail(u.Email)
+
+	pw, err := hashPassword(u.Password)
+	if err != nil {
+		s.Logger.WithContext(ctx).WithError(err).Error("Unable to hash password for admin create")
+		return apperrors.NewInternal()
+	}
+
+	u.Password = pw
+	name, role := u.Name, u.Role
+	err = runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		// Create 只保存邮箱和密码，返回的行覆盖 u
+		if err := s.UserRepository.Create(ctx, u); err != nil {
+			return err
+		}
+		if name != u.Name || role != u.Role {
+			u.Name, u.Role = name, role
+			if err := s.UserRepository.Save(ctx, u); err != nil {
+				return err
+			}
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserCreated, u.UID, u)
+	})
 	if err != nil {
 		return err
 	}
 
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &actorID,
+		TargetID: &u.UID,
+		Action:   model.AuditAdminCreate,
+		Details:  model.AuditDetails{"email": u.Email, "name": u.Name, "role": u.Role},
+	})
+
+	s.withDefaultAvatar(u)
+
 	return nil
 }
+
+// AdminUpdate 实现 UserService 接口 AdminUpdate 方法
+func (s *userService) AdminUpdate(ctx context.Context, actorID uuid.UUID, u *model.User) error {
+	if u.Role != model.RoleUser && u.Role != model.RoleAdmin {
+		return apperrors.NewBadRequest(fmt.Sprintf("unknown role: %v", u.Role))
+	}
+
+	u.Email = s.normalizeEmail(u.Email)
+
+	// 默认头像只在读取时生成，不保存到数据库
+	if s.isDefaultAvatar(u) {
+		u.ImageURLs = model.ImageURLs{}
+	}
+
+	err := runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		if err := s.UserRepository.Save(ctx, u); err != nil {
+			return err
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, u.UID, u)
+	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &actorID,
+		TargetID: &u.UID,
+		Action:   model.AuditAdminUpdate,
+		Details:  model.AuditDetails{"email": u.Email, "name": u.Name, "role": u.Role},
+	})
+
+	s.withDefaultAvatar(u)
+
+	return nil
+}
+
+// SetStatus 实现 UserService 接口 SetStatus 方法
+// 暂停或禁用用户时会撤销其所有会话
+func (s *userService) SetStatus(ctx context.Context, uid uuid.UUID, c *model.StatusChange) (*model.User, error) {
+	switch c.Status {
+	case model.StatusActive:
+		c.Reason = ""
+		c.ExpiresAt = nil
+	case model.StatusSuspended:
+		if c.ExpiresAt != nil && !c.ExpiresAt.After(time.Now()) {
+			return nil, apperrors.NewBadRequest("suspension expiry must be in the future")
+		}
+	case model.StatusDisabled:
+		c.ExpiresAt = nil
+	default:
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("unknown status: %v", c.Status))
+	}
+
+	if c.ActorID == uid {
+		return nil, apperrors.NewBadRequest("cannot change the status of your own account")
+	}
+
+	var u *model.User
+	err := runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		var err error
+		if u, err = s.UserRepository.UpdateStatus(ctx, uid, c); err != nil {
+			return err
+		}
+		if err := appendEvent(ctx, s.EventRepository, model.EventUserUpdated, uid, u); err != nil {
+			return err
+		}
+		if c.Status == model.StatusActive {
+			return nil
+		}
+		// 非活跃状态的用户不能刷新 token，会话撤销与状态变更一起提交
+		payload := &model.SessionEventPayload{UID: uid, Reason: c.Status}
+		return appendEvent(ctx, s.EventRepository, model.EventSessionRevoked, uid, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.Logger.WithContext(ctx).WithField("uid", uid)
+	logger.WithFields(logrus.Fields{
+		"status":   c.Status,
+		"actor_id": c.ActorID,
+		"reason":   c.Reason,
+	}).Info("User status changed")
+
+	details := model.AuditDetails{"reason": c.Reason}
+	if c.ExpiresAt != nil {
+		details["expires_at"] = c.ExpiresAt.Format(time.RFC3339)
+	}
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &c.ActorID,
+		TargetID: &uid,
+		Action:   statusAuditActions[c.Status],
+		Details:  details,
+	})
+
+	if c.Status != model.StatusActive {
+		if err := s.TokenRepository.DeleteUserRefreshTokens(ctx, uid.String()); err != nil {
+			logger.WithError(err).Error("Unable to revoke sessions")
+			return nil, err
+		}
+	}
+
+	s.withDefaultAvatar(u)
+
+	return u, nil
+}
+
+// Lookup 实现 UserService 接口 Lookup 方法
+// login 可以是 UID、邮箱或 handle，供命令行等运维工具查找用户
+func (s *userService) Lookup(ctx context.Context, login string) (*model.User, error) {
+	login = strings.TrimSpace(login)
+	if uid, err := uuid.Parse(login); err == nil {
+		return s.Get(ctx, uid)
+	}
+
+	find, identifier := s.UserRepository.FindByHandle, normalizeHandle(login)
+	if strings.Contains(login, "@") && !strings.HasPrefix(login, "@") {
+		find, identifier = s.findByEmail, login
+	}
+
+	u, err := find(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	s.withDefaultAvatar(u)
+
+	return u, nil
+}
+
+// SetPassword 实现 UserService 接口 SetPassword 方法
+// 管理员重置密码，同时撤销用户的所有会话
+func (s *userService) SetPassword(ctx context.Context, actorID uuid.UUID, uid uuid.UUID, password string) error {
+	if n := utf8.RuneCountInString(password); n < minPasswordLength || n > maxPasswordLength {
+		return apperrors.NewBadRequest(fmt.Sprintf("password must be %d to %d characters", minPasswordLength, maxPasswordLength))
+	}
+
+	pw, err := hashPassword(password)
+	if err != nil {
+		s.Logger.WithContext(ctx).WithError(err).Error("Unable to hash password for reset")
+		return apperrors.NewInternal()
+	}
+
+	err = runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		if _, err := s.UserRepository.UpdatePassword(ctx, uid, pw); err != nil {
+			return err
+		}
+		payload := &model.SessionEventPayload{UID: uid, Reason: "password_reset"}
+		return appendEvent(ctx, s.EventRepository, model.EventSessionRevoked, uid, payload)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := s.Logger.WithContext(ctx).WithField("uid", uid)
+	logger.WithField("actor_id", actorID).Info("User password reset")
+
+	recordAudit(ctx, s.AuditService, &model.AuditEvent{
+		ActorID:  &actorID,
+		TargetID: &uid,
+		Action:   model.AuditAdminPassword,
+	})
+
+	if err := s.TokenRepository.DeleteUserRefreshTokens(ctx, uid.String()); err != nil {
+		logger.WithError(err).Error("Unable to revoke sessions")
+		return err
+	}
+
+	return nil
+}
+
+// SetProfileImage 实现 UserService 接口 SetProfileImage 方法
+// 上传的图片被处理为多个尺寸，每次上传使用新的对象名，更新成功后删除旧图片
+func (s *userService) SetProfileImage(ctx context.Context, uid uuid.UUID, imageFileHeader *multipart.FileHeader) (*model.User, error) {
+	u, err := s.UserRepository.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	imageFile, err := imageFileHeader.Open()
+	if err != nil {
+		s.Logger.WithContext(ctx).WithError(err).Error("Failed to open image file")
+		return nil, apperrors.NewInternal()
+	}
+	defer imageFile.Close()
+
+	if _, err := detectImageType(imageFile); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(imageFile)
+	if err != nil {
+		s.Logger.WithContext(ctx).WithError(err).Error("Failed to read image file")
+		return nil, apperrors.NewInternal()
+	}
+
+	variants, err := processAvatar(data, model.AvatarSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一次上传的各尺寸放在同一个目录下
+	setID := uuid.New()
+	urls := model.ImageURLs{}
+	for _, v := range variants {
+		objName := fmt.Sprintf("%s/%s/%d%s", uid, setID, v.Size, v.Ext)
+
+		url, err := s.ImageRepository.UpdateProfile(ctx, objName, v.ContentType, bytes.NewReader(v.Data), int64(len(v.Data)))
+		if err != nil {
+			s.Logger.WithContext(ctx).WithField("uid", uid).WithError(err).Error("Unable to upload image")
+			s.deleteImages(ctx, urls)
+			return nil, err
+		}
+		urls.Set(v.Size, url)
+	}
+
+	updatedUser, err := s.updateImage(ctx, uid, urls)
+	if err != nil {
+		// 用户未更新，清理刚上传的图片
+		s.deleteImages(ctx, urls)
+		return nil, err
+	}
+
+	s.deleteImages(ctx, userImageURLs(u))
+
+	return updatedUser, nil
+}
+
+// ClearProfileImage 实现 UserService 接口 ClearProfileImage 方法
+func (s *userService) ClearProfileImage(ctx context.Context, uid uuid.UUID) error {
+	u, err := s.UserRepository.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	old := userImageURLs(u)
+	if len(old) == 0 {
+		return nil
+	}
+
+	if _, err := s.updateImage(ctx, uid, model.ImageURLs{}); err != nil {
+		return err
+	}
+
+	s.deleteImages(ctx, old)
+
+	return nil
+}
+
+// updateImage 更新头像地址并记录事件
+func (s *userService) updateImage(ctx context.Context, uid uuid.UUID, urls model.ImageURLs) (*model.User, error) {
+	var u *model.User
+	err := runInTx(ctx, s.Transactor, func(ctx context.Context) error {
+		var err error
+		if u, err = s.UserRepository.UpdateImage(ctx, uid, urls); err != nil {
+			return err
+		}
+		return appendEvent(ctx, s.EventRepository, model.EventUserUpdated, uid, u)
+	})
+
+	return u, err
+}
+
+// deleteImages 删除图片，失败只记录日志，不影响已完成的更新
+func (s *userService) deleteImages(ctx context.Context, urls model.ImageURLs) {
+	for _, url := range urls {
+		if err := s.ImageRepository.DeleteProfile(ctx, url); err != nil {
+			s.Logger.WithContext(ctx).WithField("url", url).WithError(err).Warn("Unable to delete image")
+		}
+	}
+}
+
+// userImageURLs 用户当前引用的所有图片地址，去除重复地址
+func userImageURLs(u *model.User) model.ImageURLs {
+	urls := model.ImageURLs{}
+	seen := map[string]bool{"": true}
+
+	for size, url := range u.ImageURLs {
+		if !seen[url] {
+			seen[url] = true
+			urls[size] = url
+		}
+	}
+
+	// 旧数据只有 image_url
+	if !seen[u.ImageURL] {
+		urls["legacy"] = u.ImageURL
+	}
+
+	return urls
+}
+
+// withDefaultAvatar 没有上传头像的用户使用生成的默认头像
+func (s *userService) withDefaultAvatar(u *model.User) {
+	if s.AvatarService == nil || len(u.ImageURLs) > 0 {
+		return
+	}
+
+	u.ImageURLs = s.AvatarService.DefaultURLs(u.UID)
+}
+
+// isDefaultAvatar 判断用户的头像是否为默认头像
+func (s *userService) isDefaultAvatar(u *model.User) bool {
+	if s.AvatarService == nil || len(u.ImageURLs) == 0 {
+		return false
+	}
+
+	return u.ImageURLs.Largest() == s.AvatarService.DefaultURLs(u.UID).Largest()
+}
+
+// statusAuditActions 状态到审计动作的映射
+var statusAuditActions = map[string]string{
+	model.StatusActive:    model.AuditAdminReinstate,
+	model.StatusSuspended: model.AuditAdminSuspend,
+	model.StatusDisabled:  model.AuditAdminDisable,
+}
+
+// checkUserActive 非正常状态的用户返回 403 error
+func checkUserActive(u *model.User) error {
+	if u.IsActive(time.Now()) {
+		return nil
+	}
+
+	if u.Status == model.StatusSuspended {
+		if u.StatusExpiresAt != nil {
+			return apperrors.NewForbidden(fmt.Sprintf("Account is suspended until %v", u.StatusExpiresAt.Format(time.RFC3339)))
+		}
+		return apperrors.NewForbidden("Account is suspended")
+	}
+
+	return apperrors.NewForbidden("Account is disabled")
+}
+
+// errUserModified 用户已被其他请求修改
+func errUserModified() *apperrors.Error {
+	return apperrors.NewPreconditionFailed("user has been modified by another request")
+}
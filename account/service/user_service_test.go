@@ -6,7 +6,9 @@ import (
 	"memrizr/model"
 	"memrizr/model/apperrors"
 	"memrizr/model/mocks"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -76,7 +78,7 @@ func TestSignup(t *testing.T) {
 		})
 
 		mockUserRepository.
-			On("Create", mock.AnythingOfType("*context.emptyCtx"), mockUser).
+			On("Create", mock.Anything, mockUser).
 			Run(func(args mock.Arguments) {
 				userArgs := args.Get(1).(*model.User) // arg 0 is context, arg 1 is *User
 				userArgs.UID = uid
@@ -105,7 +107,7 @@ func TestSignup(t *testing.T) {
 
 		mockErr := apperrors.NewConflict("email", mockUser.Email)
 		mockUserRepository.
-			On("Create", mock.AnythingOfType("*context.emptyCtx"), mockUser).
+			On("Create", mock.Anything, mockUser).
 			Return(mockErr)
 
 		ctx := context.TODO()
@@ -117,3 +119,456 @@ func TestSignup(t *testing.T) {
 	})
 
 }
+
+func TestList(t *testing.T) {
+	t.Run("Applies default limit", func(t *testing.T) {
+		mockPage := &model.UserPage{Users: []*model.User{}}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		mockUserRepository.
+			On("List", mock.Anything, &model.UserListQuery{Limit: defaultUserListLimit}).
+			Return(mockPage, nil)
+
+		page, err := us.List(context.TODO(), &model.UserListQuery{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockPage, page)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Clamps limit", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		mockUserRepository.
+			On("List", mock.Anything, &model.UserListQuery{Limit: maxUserListLimit}).
+			Return(&model.UserPage{}, nil)
+
+		_, err := us.List(context.TODO(), &model.UserListQuery{Limit: 1000})
+
+		assert.NoError(t, err)
+		mockUserRepository.AssertExpectations(t)
+	})
+}
+
+func TestAdminUpdate(t *testing.T) {
+	t.Run("Rejects unknown role", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		actorID, _ := uuid.NewRandom()
+		err := us.AdminUpdate(context.TODO(), actorID, &model.User{Role: "superuser"})
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.BadRequest, err.(*apperrors.Error).Type)
+		mockUserRepository.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminCreate(t *testing.T) {
+	actorID, _ := uuid.NewRandom()
+
+	setup := func() (*mocks.MockUserRepository, *mocks.MockEventRepository, *mocks.MockTransactor, model.UserService) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockEventRepository := new(mocks.MockEventRepository)
+		mockTransactor := new(mocks.MockTransactor)
+		mockTransactor.On("WithinTransaction", mock.Anything)
+
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			EventRepository: mockEventRepository,
+			Transactor:      mockTransactor,
+		})
+		return mockUserRepository, mockEventRepository, mockTransactor, us
+	}
+
+	// create 模拟数据库返回只有邮箱和密码的新行
+	create := func(uid uuid.UUID) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			u := args.Get(1).(*model.User)
+			u.UID, u.Name, u.Role = uid, "", model.RoleUser
+		}
+	}
+
+	t.Run("Saves name and role in the same transaction", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		mockUserRepository, mockEventRepository, mockTransactor, us := setup()
+
+		mockUserRepository.On("Create", mock.Anything, mock.AnythingOfType("*model.User")).Run(create(uid)).Return(nil)
+		mockUserRepository.On("Save", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.UID == uid && u.Name == "Bob" && u.Role == model.RoleAdmin
+		})).Return(nil)
+		mockEventRepository.On("Append", mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+			return e.Type == model.EventUserCreated && e.AggregateID == uid
+		})).Return(nil)
+
+		u := &model.User{Email: "bob@Bob.com", Password: "howdyhoneighbor!", Name: "Bob", Role: model.RoleAdmin}
+		err := us.AdminCreate(context.TODO(), actorID, u)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bob@bob.com", u.Email)
+		assert.Equal(t, model.RoleAdmin, u.Role)
+		mockTransactor.AssertNumberOfCalls(t, "WithinTransaction", 1)
+		mockUserRepository.AssertExpectations(t)
+		mockEventRepository.AssertExpectations(t)
+	})
+
+	t.Run("Save failure fails the transaction", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		mockUserRepository, mockEventRepository, _, us := setup()
+
+		mockUserRepository.On("Create", mock.Anything, mock.AnythingOfType("*model.User")).Run(create(uid)).Return(nil)
+		mockUserRepository.On("Save", mock.Anything, mock.AnythingOfType("*model.User")).Return(apperrors.NewInternal())
+
+		err := us.AdminCreate(context.TODO(), actorID, &model.User{Email: "bob@bob.com", Password: "howdyhoneighbor!", Role: model.RoleAdmin})
+
+		assert.Error(t, err)
+		mockEventRepository.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects invalid input", func(t *testing.T) {
+		mockUserRepository, _, _, us := setup()
+
+		err := us.AdminCreate(context.TODO(), actorID, &model.User{Email: "bob@bob.com", Password: "howdyhoneighbor!", Role: "superuser"})
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+
+		err = us.AdminCreate(context.TODO(), actorID, &model.User{Email: "bob@bob.com", Password: "short"})
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+
+		mockUserRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetStatus(t *testing.T) {
+	t.Run("Suspend revokes sessions", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		actorID, _ := uuid.NewRandom()
+		until := time.Now().Add(24 * time.Hour)
+
+		change := &model.StatusChange{
+			Status:    model.StatusSuspended,
+			Reason:    "spam",
+			ExpiresAt: &until,
+			ActorID:   actorID,
+		}
+		mockUser := &model.User{UID: uid, Status: model.StatusSuspended}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockTokenRepository := new(mocks.MockTokenRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockUserRepository.On("UpdateStatus", mock.Anything, uid, change).Return(mockUser, nil)
+		mockTokenRepository.On("DeleteUserRefreshTokens", mock.Anything, uid.String()).Return(nil)
+
+		u, err := us.SetStatus(context.TODO(), uid, change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser, u)
+		mockUserRepository.AssertExpectations(t)
+		mockTokenRepository.AssertExpectations(t)
+	})
+
+	t.Run("Reinstate clears reason and keeps sessions", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		actorID, _ := uuid.NewRandom()
+
+		change := &model.StatusChange{
+			Status:  model.StatusActive,
+			Reason:  "ignored",
+			ActorID: actorID,
+		}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockTokenRepository := new(mocks.MockTokenRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			TokenRepository: mockTokenRepository,
+		})
+
+		mockUserRepository.
+			On("UpdateStatus", mock.Anything, uid, &model.StatusChange{Status: model.StatusActive, ActorID: actorID}).
+			Return(&model.User{UID: uid, Status: model.StatusActive}, nil)
+
+		_, err := us.SetStatus(context.TODO(), uid, change)
+
+		assert.NoError(t, err)
+		mockUserRepository.AssertExpectations(t)
+		mockTokenRepository.AssertNotCalled(t, "DeleteUserRefreshTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cannot change own status", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		_, err := us.SetStatus(context.TODO(), uid, &model.StatusChange{
+			Status:  model.StatusDisabled,
+			ActorID: uid,
+		})
+
+		assert.Error(t, err)
+		mockUserRepository.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestLookup(t *testing.T) {
+	uid, _ := uuid.NewRandom()
+	mockUser := &model.User{UID: uid, Email: "bob@bob.com", Handle: "bob"}
+
+	cases := map[string]struct {
+		login  string
+		method string
+		arg    interface{}
+	}{
+		"UID":    {login: uid.String(), method: "FindByID", arg: uid},
+		"Email":  {login: " bob@BOB.com ", method: "FindByEmail", arg: "bob@bob.com"},
+		"Handle": {login: "@Bob", method: "FindByHandle", arg: "bob"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockUserRepository := new(mocks.MockUserRepository)
+			us := NewUserService(&USConfig{
+				UserRepository: mockUserRepository,
+			})
+			mockUserRepository.On(tc.method, mock.Anything, tc.arg).Return(mockUser, nil)
+
+			u, err := us.Lookup(context.TODO(), tc.login)
+
+			assert.NoError(t, err)
+			assert.Equal(t, mockUser, u)
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Not found", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+		mockUserRepository.On("FindByHandle", mock.Anything, "nobody").Return(nil, apperrors.NewNotFound("handle", "nobody"))
+
+		u, err := us.Lookup(context.TODO(), "nobody")
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusNotFound, apperrors.Status(err))
+	})
+}
+
+func TestSetPassword(t *testing.T) {
+	t.Run("Success revokes sessions", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockTokenRepository := new(mocks.MockTokenRepository)
+		us := NewUserService(&USConfig{
+			UserRepository:  mockUserRepository,
+			TokenRepository: mockTokenRepository,
+		})
+
+		var hash string
+		mockUserRepository.
+			On("UpdatePassword", mock.Anything, uid, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { hash = args.String(2) }).
+			Return(&model.User{UID: uid}, nil)
+		mockTokenRepository.On("DeleteUserRefreshTokens", mock.Anything, uid.String()).Return(nil)
+
+		err := us.SetPassword(context.TODO(), uuid.Nil, uid, "new password")
+
+		assert.NoError(t, err)
+		match, err := comparePasswords(hash, "new password")
+		assert.NoError(t, err)
+		assert.True(t, match)
+		mockUserRepository.AssertExpectations(t)
+		mockTokenRepository.AssertExpectations(t)
+	})
+
+	t.Run("Invalid length", func(t *testing.T) {
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		err := us.SetPassword(context.TODO(), uuid.Nil, uuid.New(), "short")
+
+		assert.Equal(t, http.StatusBadRequest, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSignin(t *testing.T) {
+	t.Run("Suspended user", func(t *testing.T) {
+		pw, _ := hashPassword("howdyhoneighbor!")
+		mockUser := &model.User{
+			Email:    "bob@bob.com",
+			Password: pw,
+			Status:   model.StatusSuspended,
+		}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		mockUserRepository.On("FindByEmail", mock.Anything, "bob@bob.com").Return(mockUser, nil)
+
+		err := us.Signin(context.TODO(), &model.User{
+			Email:    "bob@bob.com",
+			Password: "howdyhoneighbor!",
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.Forbidden, err.(*apperrors.Error).Type)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Expired suspension", func(t *testing.T) {
+		pw, _ := hashPassword("howdyhoneighbor!")
+		expired := time.Now().Add(-time.Hour)
+		mockUser := &model.User{
+			Email:           "bob@bob.com",
+			Password:        pw,
+			Status:          model.StatusSuspended,
+			StatusExpiresAt: &expired,
+		}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		mockUserRepository.On("FindByEmail", mock.Anything, "bob@bob.com").Return(mockUser, nil)
+
+		u := &model.User{
+			Email:    "bob@bob.com",
+			Password: "howdyhoneighbor!",
+		}
+		err := us.Signin(context.TODO(), u)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser, u)
+	})
+}
+
+func TestPatchDetails(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		name := "Bobby"
+		p := model.UserPatch{model.FieldName: &name, model.FieldWebsite: nil}
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Version: 3}, nil)
+		mockUserRepository.On("Patch", mock.Anything, uid, int64(3), p).Return(&model.User{UID: uid, Name: name, Version: 4}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		u, _, err := us.PatchDetails(context.TODO(), uid, 3, p)
+
+		assert.NoError(t, err)
+		assert.Equal(t, name, u.Name)
+		mockUserRepository.AssertExpectations(t)
+	})
+
+	t.Run("Empty patch returns current user", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		u, _, err := us.PatchDetails(context.TODO(), uid, 0, model.UserPatch{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uid, u.UID)
+		mockUserRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Email cannot be cleared", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		u, _, err := us.PatchDetails(context.TODO(), uid, 0, model.UserPatch{model.FieldEmail: nil})
+
+		assert.Nil(t, u)
+		assert.Equal(t, apperrors.NewBadRequest("email cannot be cleared"), err)
+		mockUserRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDetailsVersion(t *testing.T) {
+	t.Run("Patch with stale version", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+		name := "Bobby"
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Version: 5}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		u, _, err := us.PatchDetails(context.TODO(), uid, 4, model.UserPatch{model.FieldName: &name})
+
+		assert.Nil(t, u)
+		assert.Equal(t, http.StatusPreconditionFailed, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Update with stale version", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "bob@bob.com", Version: 5}, nil)
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		_, err := us.UpdateDetails(context.TODO(), &model.User{UID: uid, Name: "Bobby", Version: 4})
+
+		assert.Equal(t, http.StatusPreconditionFailed, apperrors.Status(err))
+		mockUserRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Update races with another request", func(t *testing.T) {
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid, Email: "bob@bob.com", Version: 5}, nil)
+		mockUserRepository.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.Version == 5 && u.Email == "bob@bob.com"
+		})).Return(apperrors.NewPreconditionFailed("user has been modified by another request"))
+
+		us := NewUserService(&USConfig{
+			UserRepository: mockUserRepository,
+		})
+
+		_, err := us.UpdateDetails(context.TODO(), &model.User{UID: uid, Name: "Bobby", Version: 5})
+
+		assert.Equal(t, http.StatusPreconditionFailed, apperrors.Status(err))
+		mockUserRepository.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// webhook 请求头
+const (
+	WebhookSignatureHeader = "X-Memrizr-Signature"
+	WebhookEventHeader     = "X-Memrizr-Event"
+	WebhookEventIDHeader   = "X-Memrizr-Event-ID"
+	WebhookDeliveryHeader  = "X-Memrizr-Delivery"
+)
+
+// 响应体最多读取的字节数，只用于记录错误
+const maxWebhookResponseBody = 1024
+
+// WebhookDispatcher 领取到期的投递记录并发送到 webhook
+// 失败时按指数退避重试，超过最大次数后进入 dead 状态
+type WebhookDispatcher struct {
+	WebhookRepository model.WebhookRepository
+	Client            *http.Client
+	PollInterval      time.Duration
+	BatchSize         int
+	MaxAttempts       int
+	BaseBackoff       time.Duration
+	MaxBackoff        time.Duration
+	Logger            *logrus.Logger
+}
+
+// WDConfig WebhookDispatcher 配置结构体
+// Logger 可选，为空时使用 logging.Default
+type WDConfig struct {
+	WebhookRepository model.WebhookRepository
+	Client            *http.Client
+	PollInterval      time.Duration
+	BatchSize         int
+	MaxAttempts       int
+	BaseBackoff       time.Duration
+	MaxBackoff        time.Duration
+	Logger            *logrus.Logger
+}
+
+// NewWebhookDispatcher 创建实例
+func NewWebhookDispatcher(c *WDConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		WebhookRepository: c.WebhookRepository,
+		Client:            c.Client,
+		PollInterval:      c.PollInterval,
+		BatchSize:         c.BatchSize,
+		MaxAttempts:       c.MaxAttempts,
+		BaseBackoff:       c.BaseBackoff,
+		MaxBackoff:        c.MaxBackoff,
+		Logger:            logging.OrDefault(c.Logger),
+	}
+}
+
+// Run 持续投递，直到 ctx 被取消
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.Logger.WithField("interval", d.PollInterval).Info("Starting webhook dispatcher")
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchBatch(ctx)
+			if err != nil || n < d.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			d.Logger.Info("Stopping webhook dispatcher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchBatch 投递一批到期的记录，返回领取的数量
+func (d *WebhookDispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	// 租约需覆盖整批请求的耗时
+	lease := time.Duration(d.BatchSize)*d.Client.Timeout + time.Minute
+
+	deliveries, err := d.WebhookRepository.ClaimDueDeliveries(ctx, d.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := make(map[uuid.UUID]*model.Webhook)
+
+	for _, delivery := range deliveries {
+		w, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			w, err = d.WebhookRepository.FindByID(ctx, delivery.WebhookID)
+			if err != nil && apperrors.Status(err) != http.StatusNotFound {
+				return 0, err
+			}
+			webhooks[delivery.WebhookID] = w
+		}
+
+		if w == nil || !w.Active {
+			// webhook 已停用，保留记录以便重新启用后手动重试
+			delivery.Status = model.DeliveryDead
+			delivery.LastError = "webhook is not active"
+		} else {
+			d.attempt(ctx, w, delivery)
+		}
+
+		if err := d.WebhookRepository.UpdateDelivery(ctx, delivery); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt 发送一次投递，并根据结果更新投递记录
+func (d *WebhookDispatcher) attempt(ctx context.Context, w *model.Webhook, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+
+	code, err := d.send(ctx, w, delivery)
+	delivery.LastStatusCode = code
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= d.MaxAttempts {
+		d.Logger.WithContext(ctx).WithFields(logrus.Fields{
+			"delivery_id": delivery.ID,
+			"webhook_id":  w.ID,
+			"attempts":    delivery.Attempts,
+		}).WithError(err).Warn("Webhook delivery failed, giving up")
+		delivery.Status = model.DeliveryDead
+		return
+	}
+
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+}
+
+// send 发送带签名的请求，非 2xx 响应视为失败
+func (d *WebhookDispatcher) send(ctx context.Context, w *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "memrizr-webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookEventIDHeader, delivery.EventID.String())
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, signWebhookPayload(w.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff 第 attempts 次失败后的等待时间
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// signWebhookPayload 生成签名头
+// 格式为 t=<unix 时间戳>,v1=<hex(HMAC-SHA256(secret, "<t>.<body>"))>，时间戳用于接收方防重放
+func signWebhookPayload(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
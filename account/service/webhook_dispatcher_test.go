@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"io"
+	"memrizr/model"
+	"memrizr/model/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDispatchBatch(t *testing.T) {
+	newDispatcher := func(r model.WebhookRepository) *WebhookDispatcher {
+		return NewWebhookDispatcher(&WDConfig{
+			WebhookRepository: r,
+			Client:            &http.Client{Timeout: time.Second},
+			BatchSize:         10,
+			MaxAttempts:       3,
+			BaseBackoff:       30 * time.Second,
+			MaxBackoff:        time.Hour,
+		})
+	}
+
+	t.Run("Delivers signed payload", func(t *testing.T) {
+		payload := []byte(`{"type":"user.created"}`)
+
+		var gotSignature, gotEvent string
+		var gotBody []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotSignature = r.Header.Get(WebhookSignatureHeader)
+			gotEvent = r.Header.Get(WebhookEventHeader)
+			gotBody, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		webhook := &model.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec_test", Active: true}
+		d := &model.WebhookDelivery{ID: 1, WebhookID: webhook.ID, EventType: model.EventUserCreated, Payload: payload, Status: model.DeliveryPending}
+
+		mockWebhookRepository := new(mocks.MockWebhookRepository)
+		mockWebhookRepository.On("ClaimDueDeliveries", mock.Anything, 10, mock.Anything).Return([]*model.WebhookDelivery{d}, nil)
+		mockWebhookRepository.On("FindByID", mock.Anything, webhook.ID).Return(webhook, nil)
+		mockWebhookRepository.On("UpdateDelivery", mock.Anything, d).Return(nil)
+
+		n, err := newDispatcher(mockWebhookRepository).DispatchBatch(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, model.DeliverySucceeded, d.Status)
+		assert.Equal(t, http.StatusNoContent, d.LastStatusCode)
+		assert.NotNil(t, d.DeliveredAt)
+		assert.Equal(t, model.EventUserCreated, gotEvent)
+		assert.Equal(t, payload, gotBody)
+
+		// 按接收方的方式验证签名
+		parts := strings.SplitN(gotSignature, ",", 2)
+		assert.Len(t, parts, 2)
+		ts, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, signWebhookPayload("whsec_test", ts, payload), gotSignature)
+		mockWebhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Schedules retry with backoff", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		webhook := &model.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec_test", Active: true}
+		d := &model.WebhookDelivery{ID: 2, WebhookID: webhook.ID, Payload: []byte(`{}`), Status: model.DeliveryPending, Attempts: 1}
+
+		mockWebhookRepository := new(mocks.MockWebhookRepository)
+		mockWebhookRepository.On("ClaimDueDeliveries", mock.Anything, 10, mock.Anything).Return([]*model.WebhookDelivery{d}, nil)
+		mockWebhookRepository.On("FindByID", mock.Anything, webhook.ID).Return(webhook, nil)
+		mockWebhookRepository.On("UpdateDelivery", mock.Anything, d).Return(nil)
+
+		before := time.Now()
+		_, err := newDispatcher(mockWebhookRepository).DispatchBatch(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.DeliveryPending, d.Status)
+		assert.Equal(t, 2, d.Attempts)
+		assert.Equal(t, http.StatusBadGateway, d.LastStatusCode)
+		assert.True(t, !d.NextAttemptAt.Before(before.Add(time.Minute)))
+		mockWebhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Dead letters after max attempts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		webhook := &model.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec_test", Active: true}
+		d := &model.WebhookDelivery{ID: 3, WebhookID: webhook.ID, Payload: []byte(`{}`), Status: model.DeliveryPending, Attempts: 2}
+
+		mockWebhookRepository := new(mocks.MockWebhookRepository)
+		mockWebhookRepository.On("ClaimDueDeliveries", mock.Anything, 10, mock.Anything).Return([]*model.WebhookDelivery{d}, nil)
+		mockWebhookRepository.On("FindByID", mock.Anything, webhook.ID).Return(webhook, nil)
+		mockWebhookRepository.On("UpdateDelivery", mock.Anything, d).Return(nil)
+
+		_, err := newDispatcher(mockWebhookRepository).DispatchBatch(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.DeliveryDead, d.Status)
+		assert.Equal(t, 3, d.Attempts)
+		mockWebhookRepository.AssertExpectations(t)
+	})
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	d := &WebhookDispatcher{BaseBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, d.backoff(1))
+	assert.Equal(t, time.Minute, d.backoff(2))
+	assert.Equal(t, 4*time.Minute, d.backoff(4))
+	assert.Equal(t, 5*time.Minute, d.backoff(5))
+	assert.Equal(t, 5*time.Minute, d.backoff(20))
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"memrizr/logging"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 投递记录分页大小
+const (
+	defaultDeliveryListLimit = 50
+	maxDeliveryListLimit     = 200
+)
+
+// webhookService webhook 服务层结构体
+type webhookService struct {
+	WebhookRepository   model.WebhookRepository
+	AllowPrivateTargets bool
+	LookupIPAddr        ipLookupFunc
+	Logger              *logrus.Logger
+}
+
+// WSConfig webhook 服务层配置结构体
+// AllowPrivateTargets 仅用于本地开发，允许回调内网地址
+// Logger 可选，为空时使用 logging.Default
+type WSConfig struct {
+	WebhookRepository   model.WebhookRepository
+	AllowPrivateTargets bool
+	Logger              *logrus.Logger
+}
+
+// NewWebhookService 创建实例
+func NewWebhookService(c *WSConfig) model.WebhookService {
+	return &webhookService{
+		WebhookRepository:   c.WebhookRepository,
+		AllowPrivateTargets: c.AllowPrivateTargets,
+		LookupIPAddr:        net.DefaultResolver.LookupIPAddr,
+		Logger:              logging.OrDefault(c.Logger),
+	}
+}
+
+// Create 实现 WebhookService 接口 Create 方法
+// 生成的签名密钥只在创建时返回给调用方
+func (s *webhookService) Create(ctx context.Context, w *model.Webhook) error {
+	if err := s.validate(ctx, w); err != nil {
+		return err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		s.Logger.WithContext(ctx).WithError(err).Error("Unable to generate webhook secret")
+		return apperrors.NewInternal()
+	}
+	w.Secret = secret
+
+	return s.WebhookRepository.Create(ctx, w)
+}
+
+// Get 实现 WebhookService 接口 Get 方法
+func (s *webhookService) Get(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	return s.WebhookRepository.FindByID(ctx, id)
+}
+
+// List 实现 WebhookService 接口 List 方法
+func (s *webhookService) List(ctx context.Context) ([]*model.Webhook, error) {
+	return s.WebhookRepository.List(ctx)
+}
+
+// Update 实现 WebhookService 接口 Update 方法
+func (s *webhookService) Update(ctx context.Context, w *model.Webhook) error {
+	if err := s.validate(ctx, w); err != nil {
+		return err
+	}
+
+	return s.WebhookRepository.Update(ctx, w)
+}
+
+// Delete 实现 WebhookService 接口 Delete 方法
+func (s *webhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.WebhookRepository.Delete(ctx, id)
+}
+
+// Deliveries 实现 WebhookService 接口 Deliveries 方法
+func (s *webhookService) Deliveries(ctx context.Context, q *model.WebhookDeliveryQuery) (*model.WebhookDeliveryPage, error) {
+	if _, err := s.WebhookRepository.FindByID(ctx, q.WebhookID); err != nil {
+		return nil, err
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultDeliveryListLimit
+	}
+
+	if q.Limit > maxDeliveryListLimit {
+		q.Limit = maxDeliveryListLimit
+	}
+
+	return s.WebhookRepository.ListDeliveries(ctx, q)
+}
+
+// RetryDelivery 实现 WebhookService 接口 RetryDelivery 方法
+// 将投递记录重置为待投递，并重新计算重试次数
+func (s *webhookService) RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) (*model.WebhookDelivery, error) {
+	d, err := s.WebhookRepository.FindDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Status == model.DeliveryPending {
+		return nil, apperrors.NewConflict("delivery", fmt.Sprintf("%d", deliveryID))
+	}
+
+	d.Status = model.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+
+	if err := s.WebhookRepository.UpdateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	s.Logger.WithContext(ctx).WithFields(logrus.Fields{
+		"webhook_id":  webhookID,
+		"delivery_id": deliveryID,
+	}).Info("Webhook delivery queued for retry")
+
+	return d, nil
+}
+
+// validate 校验回调地址和订阅的事件类型
+func (s *webhookService) validate(ctx context.Context, w *model.Webhook) error {
+	if err := validateTargetURL(ctx, w.URL, s.AllowPrivateTargets, s.LookupIPAddr); err != nil {
+		return apperrors.NewBadRequest(err.Error())
+	}
+
+	if len(w.EventTypes) == 0 {
+		return apperrors.NewBadRequest("at least one event type is required")
+	}
+
+	seen := make(map[string]bool, len(w.EventTypes))
+	types := w.EventTypes[:0]
+	for _, t := range w.EventTypes {
+		if !isWebhookEventType(t) {
+			return apperrors.NewBadRequest(fmt.Sprintf("unknown event type: %v", t))
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	w.EventTypes = types
+
+	return nil
+}
+
+// webhookBroker 将事件转换为订阅它的 webhook 的投递记录
+// 由 EventRelay 在事务中调用，投递记录和事件发布状态一起提交
+// 同一事件重复发布时不会重复创建投递记录，可以放在 multiBroker 的前面
+type webhookBroker struct {
+	WebhookRepository model.WebhookRepository
+}
+
+// NewWebhookBroker 创建实例
+func NewWebhookBroker(r model.WebhookRepository) model.EventBroker {
+	return &webhookBroker{
+		WebhookRepository: r,
+	}
+}
+
+// Publish 为每个订阅的 webhook 创建投递记录
+func (b *webhookBroker) Publish(ctx context.Context, e *model.Event) error {
+	webhooks, err := b.WebhookRepository.FindSubscribed(ctx, e.Type)
+	if err != nil {
+		return err
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		logging.Default().WithContext(ctx).WithField("event_id", e.ID).WithError(err).Error("Unable to marshal event for webhooks")
+		return apperrors.NewInternal()
+	}
+
+	for _, w := range webhooks {
+		d := &model.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       payload,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+
+		if err := b.WebhookRepository.CreateDelivery(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isWebhookEventType 判断是否为可订阅的事件类型
+func isWebhookEventType(t string) bool {
+	for _, et := range model.WebhookEventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret 生成签名密钥
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"memrizr/config"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runToken account token 子命令，TOKEN 为 - 时从 stdin 读取
+// 先输出未验证的 header 和 claims，再用当前的密钥验证，token 无效时返回错误
+func runToken(ctx context.Context, cfg *config.Config, logger *logrus.Logger, args []string) error {
+	if len(args) != 2 || args[0] != "inspect" {
+		return errUsage
+	}
+
+	token := args[1]
+	if token == "-" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("unable to read token from stdin: %w", err)
+		}
+		token = line
+	}
+	token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(token), "Bearer "))
+
+	header, claims, err := decodeJWT(token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Header:")
+	if err := printJSON(header); err != nil {
+		return err
+	}
+	fmt.Println("Claims:")
+	if err := printJSON(claims); err != nil {
+		return err
+	}
+	for _, name := range []string{"iat", "exp"} {
+		if v, ok := claims[name].(float64); ok {
+			fmt.Printf("%s: %s\n", name, time.Unix(int64(v), 0).UTC().Format(time.RFC3339))
+		}
+	}
+
+	return withApp(cfg, logger, func(a *app) error {
+		// id token 使用 RS256，refresh token 使用 HS256
+		if u, err := a.tokenService.ValidateIDToken(token); err == nil {
+			fmt.Printf("Valid id token for %s\n", u.UID)
+			return nil
+		}
+
+		if rt, err := a.tokenService.ValidateRefreshToken(token); err == nil {
+			fmt.Printf("Valid refresh token %s for %s\n", rt.ID, rt.UID)
+			return nil
+		}
+
+		return errors.New("token is expired or was not signed with the current keys")
+	})
+}
+
+// decodeJWT 解码 header 和 claims，不验证签名
+func decodeJWT(token string) (map[string]interface{}, map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("token is not a JWT")
+	}
+
+	decode := func(segment string) (map[string]interface{}, error) {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+		if err != nil {
+			return nil, err
+		}
+
+		v := map[string]interface{}{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	header, err := decode(parts[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	claims, err := decode(parts[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	return header, claims, nil
+}
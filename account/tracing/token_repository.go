@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"memrizr/model"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tokenRepository 为 TokenRepository 的每个方法创建 client span
+type tokenRepository struct {
+	next   model.TokenRepository
+	tracer trace.Tracer
+}
+
+// NewTokenRepository 包装 Redis token 存储
+func NewTokenRepository(next model.TokenRepository, tp trace.TracerProvider) model.TokenRepository {
+	return &tokenRepository{next: next, tracer: tp.Tracer(InstrumentationName)}
+}
+
+func (r *tokenRepository) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "TokenRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis),
+	)
+}
+
+// SetRefreshToken 实现 TokenRepository 接口 SetRefreshToken 方法
+func (r *tokenRepository) SetRefreshToken(ctx context.Context, userID string, tokenID string, expiresIn time.Duration) (err error) {
+	ctx, span := r.start(ctx, "SetRefreshToken")
+	defer func() { end(span, err) }()
+	return r.next.SetRefreshToken(ctx, userID, tokenID, expiresIn)
+}
+
+// DeleteRefreshToken 实现 TokenRepository 接口 DeleteRefreshToken 方法
+func (r *tokenRepository) DeleteRefreshToken(ctx context.Context, userID string, prevTokenID string) (err error) {
+	ctx, span := r.start(ctx, "DeleteRefreshToken")
+	defer func() { end(span, err) }()
+	return r.next.DeleteRefreshToken(ctx, userID, prevTokenID)
+}
+
+// DeleteUserRefreshTokens 实现 TokenRepository 接口 DeleteUserRefreshTokens 方法
+func (r *tokenRepository) DeleteUserRefreshTokens(ctx context.Context, userID string) (err error) {
+	ctx, span := r.start(ctx, "DeleteUserRefreshTokens")
+	defer func() { end(span, err) }()
+	return r.next.DeleteUserRefreshTokens(ctx, userID)
+}
+
+// CountUserRefreshTokens 实现 TokenRepository 接口 CountUserRefreshTokens 方法
+func (r *tokenRepository) CountUserRefreshTokens(ctx context.Context, userID string) (n int, err error) {
+	ctx, span := r.start(ctx, "CountUserRefreshTokens")
+	defer func() { end(span, err) }()
+	return r.next.CountUserRefreshTokens(ctx, userID)
+}
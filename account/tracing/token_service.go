@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tokenService 为 TokenService 中带 ctx 的方法创建 span
+// ValidateIDToken 等方法没有 ctx，无法关联到请求，直接调用
+type tokenService struct {
+	model.TokenService
+	tracer trace.Tracer
+}
+
+// NewTokenService 包装 TokenService
+func NewTokenService(next model.TokenService, tp trace.TracerProvider) model.TokenService {
+	return &tokenService{TokenService: next, tracer: tp.Tracer(InstrumentationName)}
+}
+
+// NewTokenPairFromUser 实现 TokenService 接口 NewTokenPairFromUser 方法
+func (s *tokenService) NewTokenPairFromUser(ctx context.Context, u *model.User, prevIDToken string) (p *model.TokenPair, err error) {
+	ctx, span := s.tracer.Start(ctx, "TokenService.NewTokenPairFromUser", trace.WithAttributes(
+		semconv.EnduserIDKey.String(u.UID.String()),
+	))
+	defer func() { end(span, err) }()
+	return s.TokenService.NewTokenPairFromUser(ctx, u, prevIDToken)
+}
+
+// Signout 实现 TokenService 接口 Signout 方法
+func (s *tokenService) Signout(ctx context.Context, uid uuid.UUID) (err error) {
+	ctx, span := s.tracer.Start(ctx, "TokenService.Signout", trace.WithAttributes(
+		semconv.EnduserIDKey.String(uid.String()),
+	))
+	defer func() { end(span, err) }()
+	return s.TokenService.Signout(ctx, uid)
+}
+
+// CountSessions 实现 TokenService 接口 CountSessions 方法
+func (s *tokenService) CountSessions(ctx context.Context, uid uuid.UUID) (n int, err error) {
+	ctx, span := s.tracer.Start(ctx, "TokenService.CountSessions", trace.WithAttributes(
+		semconv.EnduserIDKey.String(uid.String()),
+	))
+	defer func() { end(span, err) }()
+	return s.TokenService.CountSessions(ctx, uid)
+}
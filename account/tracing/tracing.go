@@ -0,0 +1,85 @@
+// Package tracing 初始化 OpenTelemetry 并为服务层和存储层提供带 span 的包装
+//
+// span 通过各方法原有的 ctx 参数传递，请求的根 span 由 middleware.Tracing 创建
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"memrizr/config"
+	"memrizr/logging"
+	"memrizr/model/apperrors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName 本服务创建 span 使用的 tracer 名称
+const InstrumentationName = "memrizr"
+
+// Init 设置全局的 TracerProvider 和 W3C trace context 传播方式
+// 返回的函数在退出前调用，导出剩余的 span
+func Init(ctx context.Context, c config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch c.Exporter {
+	case config.TracingNone:
+		return func(context.Context) error { return nil }, nil
+	case config.TracingStdout:
+		exporter, err = stdouttrace.New()
+	case config.TracingOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.OTLPEndpoint)}
+		if c.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", c.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not create %s trace exporter: %w", c.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(c.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not create trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	logging.Default().WithField("exporter", c.Exporter).Info("Exporting traces")
+
+	return tp.Shutdown, nil
+}
+
+// end 记录 error 后结束 span
+// 客户端错误（如 NotFound、Conflict）是正常的业务结果，只有 5xx 才标记 span 失败
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if apperrors.Status(err) >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,144 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"memrizr/handler/middleware"
+	"memrizr/model"
+	"memrizr/model/apperrors"
+	"memrizr/model/mocks"
+	"memrizr/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newRecorder 记录结束的 span
+func newRecorder() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	sr := tracetest.NewSpanRecorder()
+	return sr, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+}
+
+// spansByName 结束的 span 按名称索引
+func spansByName(sr *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range sr.Ended() {
+		spans[s.Name()] = s
+	}
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Spans propagate from request to repository", func(t *testing.T) {
+		sr, tp := newRecorder()
+		uid, _ := uuid.NewRandom()
+
+		mockUserRepository := new(mocks.MockUserRepository)
+		mockUserRepository.On("FindByID", mock.Anything, uid).Return(&model.User{UID: uid}, nil)
+		userRepository := tracing.NewUserRepository(mockUserRepository, tp)
+
+		// 服务层使用传入的 ctx 调用存储层
+		mockUserService := new(mocks.MockUserService)
+		mockUserService.On("Get", mock.Anything, uid).
+			Run(func(args mock.Arguments) {
+				userRepository.FindByID(args.Get(0).(context.Context), uid)
+			}).
+			Return(&model.User{UID: uid}, nil)
+		userService := tracing.NewUserService(mockUserService, tp)
+
+		router := gin.New()
+		router.Use(middleware.Tracing("account", tp, propagation.TraceContext{}))
+		router.GET("/users/:id", func(c *gin.Context) {
+			userService.Get(c.Request.Context(), uid)
+			c.Status(http.StatusOK)
+		})
+
+		rr := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/users/"+uid.String(), nil)
+		request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		router.ServeHTTP(rr, request)
+
+		spans := spansByName(sr)
+		require.Len(t, spans, 3)
+
+		server := spans["GET /users/:id"]
+		svc := spans["UserService.Get"]
+		repo := spans["UserRepository.FindByID"]
+		require.NotNil(t, server)
+		require.NotNil(t, svc)
+		require.NotNil(t, repo)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+		assert.True(t, server.Parent().IsRemote())
+		assert.Equal(t, server.SpanContext().SpanID(), svc.Parent().SpanID())
+		assert.Equal(t, svc.SpanContext().SpanID(), repo.Parent().SpanID())
+		assert.Equal(t, server.SpanContext().TraceID(), repo.SpanContext().TraceID())
+	})
+
+	t.Run("Server errors mark the span", func(t *testing.T) {
+		sr, tp := newRecorder()
+		router := gin.New()
+		router.Use(middleware.Tracing("account", tp, propagation.TraceContext{}))
+		router.GET("/fail", func(c *gin.Context) {
+			c.Status(http.StatusInternalServerError)
+		})
+		router.GET("/missing", func(c *gin.Context) {
+			c.Status(http.StatusNotFound)
+		})
+
+		for _, path := range []string{"/fail", "/missing", "/nothing"} {
+			rr := httptest.NewRecorder()
+			request, _ := http.NewRequest(http.MethodGet, path, nil)
+			router.ServeHTTP(rr, request)
+		}
+
+		spans := spansByName(sr)
+		assert.Equal(t, codes.Error, spans["GET /fail"].Status().Code)
+		assert.Equal(t, codes.Unset, spans["GET /missing"].Status().Code)
+		assert.Equal(t, codes.Unset, spans["GET unmatched"].Status().Code)
+	})
+}
+
+func TestEnd(t *testing.T) {
+	sr, tp := newRecorder()
+
+	mockTokenRepository := new(mocks.MockTokenRepository)
+	mockTokenRepository.On("DeleteRefreshToken", mock.Anything, "uid", "missing").Return(apperrors.NewAuthorization("Invalid refresh token"))
+	mockTokenRepository.On("SetRefreshToken", mock.Anything, "uid", "new", mock.Anything).Return(apperrors.NewInternal())
+	mockTokenRepository.On("CountUserRefreshTokens", mock.Anything, "uid").Return(2, nil)
+	tokenRepository := tracing.NewTokenRepository(mockTokenRepository, tp)
+
+	err := tokenRepository.DeleteRefreshToken(context.TODO(), "uid", "missing")
+	assert.Error(t, err)
+	err = tokenRepository.SetRefreshToken(context.TODO(), "uid", "new", 0)
+	assert.Error(t, err)
+	n, err := tokenRepository.CountUserRefreshTokens(context.TODO(), "uid")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	spans := spansByName(sr)
+
+	// 客户端错误只记录事件，不标记失败
+	deleted := spans["TokenRepository.DeleteRefreshToken"]
+	assert.Equal(t, codes.Unset, deleted.Status().Code)
+	assert.Len(t, deleted.Events(), 1)
+
+	assert.Equal(t, codes.Error, spans["TokenRepository.SetRefreshToken"].Status().Code)
+
+	counted := spans["TokenRepository.CountUserRefreshTokens"]
+	assert.Equal(t, codes.Unset, counted.Status().Code)
+	assert.Empty(t, counted.Events())
+}
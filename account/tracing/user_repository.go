@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"memrizr/model"
+
+	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// userRepository 为 UserRepository 的每个方法创建 client span
+type userRepository struct {
+	next   model.UserRepository
+	tracer trace.Tracer
+}
+
+// NewUserRepository 包装 Postgres 用户存储
+func NewUserRepository(next model.UserRepository, tp trace.TracerProvider) model.UserRepository {
+	return &userRepository{next: next, tracer: tp.Tracer(InstrumentationName)}
+}
+
+func (r *userRepository) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "UserRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBSQLTableKey.String("users")),
+	)
+}
+
+// FindByID 实现 UserRepository 接口 FindByID 方法
+func (r *userRepository) FindByID(ctx context.Context, uid uuid.UUID) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "FindByID")
+	defer func() { end(span, err) }()
+	return r.next.FindByID(ctx, uid)
+}
+
+// FindByEmail 实现 UserRepository 接口 FindByEmail 方法
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "FindByEmail")
+	defer func() { end(span, err) }()
+	return r.next.FindByEmail(ctx, email)
+}
+
+// FindByHandle 实现 UserRepository 接口 FindByHandle 方法
+func (r *userRepository) FindByHandle(ctx context.Context, handle string) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "FindByHandle")
+	defer func() { end(span, err) }()
+	return r.next.FindByHandle(ctx, handle)
+}
+
+// Create 实现 UserRepository 接口 Create 方法
+func (r *userRepository) Create(ctx context.Context, u *model.User) (err error) {
+	ctx, span := r.start(ctx, "Create")
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, u)
+}
+
+// Update 实现 UserRepository 接口 Update 方法
+func (r *userRepository) Update(ctx context.Context, u *model.User) (err error) {
+	ctx, span := r.start(ctx, "Update")
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, u)
+}
+
+// Patch 实现 UserRepository 接口 Patch 方法
+func (r *userRepository) Patch(ctx context.Context, uid uuid.UUID, version int64, p model.UserPatch) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "Patch")
+	defer func() { end(span, err) }()
+	return r.next.Patch(ctx, uid, version, p)
+}
+
+// List 实现 UserRepository 接口 List 方法
+func (r *userRepository) List(ctx context.Context, q *model.UserListQuery) (page *model.UserPage, err error) {
+	ctx, span := r.start(ctx, "List")
+	defer func() { end(span, err) }()
+	return r.next.List(ctx, q)
+}
+
+// Save 实现 UserRepository 接口 Save 方法
+func (r *userRepository) Save(ctx context.Context, u *model.User) (err error) {
+	ctx, span := r.start(ctx, "Save")
+	defer func() { end(span, err) }()
+	return r.next.Save(ctx, u)
+}
+
+// UpdateStatus 实现 UserRepository 接口 UpdateStatus 方法
+func (r *userRepository) UpdateStatus(ctx context.Context, uid uuid.UUID, c *model.StatusChange) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "UpdateStatus")
+	defer func() { end(span, err) }()
+	return r.next.UpdateStatus(ctx, uid, c)
+}
+
+// UpdateImage 实现 UserRepository 接口 UpdateImage 方法
+func (r *userRepository) UpdateImage(ctx context.Context, uid uuid.UUID, urls model.ImageURLs) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "UpdateImage")
+	defer func() { end(span, err) }()
+	return r.next.UpdateImage(ctx, uid, urls)
+}
+
+// UpdatePrivacy 实现 UserRepository 接口 UpdatePrivacy 方法
+func (r *userRepository) UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *model.ProfilePrivacy) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "UpdatePrivacy")
+	defer func() { end(span, err) }()
+	return r.next.UpdatePrivacy(ctx, uid, p)
+}
+
+// UpdatePassword 实现 UserRepository 接口 UpdatePassword 方法
+func (r *userRepository) UpdatePassword(ctx context.Context, uid uuid.UUID, password string) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "UpdatePassword")
+	defer func() { end(span, err) }()
+	return r.next.UpdatePassword(ctx, uid, password)
+}
+
+// UpdateHandle 实现 UserRepository 接口 UpdateHandle 方法
+func (r *userRepository) UpdateHandle(ctx context.Context, uid uuid.UUID, handle string) (u *model.User, err error) {
+	ctx, span := r.start(ctx, "UpdateHandle")
+	defer func() { end(span, err) }()
+	return r.next.UpdateHandle(ctx, uid, handle)
+}
@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"context"
+	"memrizr/model"
+	"mime/multipart"
+
+	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// userService 为 UserService 的每个方法创建 span
+type userService struct {
+	next   model.UserService
+	tracer trace.Tracer
+}
+
+// NewUserService 包装 UserService
+func NewUserService(next model.UserService, tp trace.TracerProvider) model.UserService {
+	return &userService{next: next, tracer: tp.Tracer(InstrumentationName)}
+}
+
+func (s *userService) start(ctx context.Context, method string, uid *uuid.UUID) (context.Context, trace.Span) {
+	ctx, span := s.tracer.Start(ctx, "UserService."+method)
+	if uid != nil {
+		span.SetAttributes(semconv.EnduserIDKey.String(uid.String()))
+	}
+	return ctx, span
+}
+
+// Get 实现 UserService 接口 Get 方法
+func (s *userService) Get(ctx context.Context, uid uuid.UUID) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "Get", &uid)
+	defer func() { end(span, err) }()
+	return s.next.Get(ctx, uid)
+}
+
+// Signup 实现 UserService 接口 Signup 方法
+func (s *userService) Signup(ctx context.Context, u *model.User) (err error) {
+	ctx, span := s.start(ctx, "Signup", nil)
+	defer func() { end(span, err) }()
+	return s.next.Signup(ctx, u)
+}
+
+// Signin 实现 UserService 接口 Signin 方法
+func (s *userService) Signin(ctx context.Context, u *model.User) (err error) {
+	ctx, span := s.start(ctx, "Signin", nil)
+	defer func() { end(span, err) }()
+	return s.next.Signin(ctx, u)
+}
+
+// UpdateDetails 实现 UserService 接口 UpdateDetails 方法
+func (s *userService) UpdateDetails(ctx context.Context, u *model.User) (pending bool, err error) {
+	ctx, span := s.start(ctx, "UpdateDetails", &u.UID)
+	defer func() { end(span, err) }()
+	return s.next.UpdateDetails(ctx, u)
+}
+
+// PatchDetails 实现 UserService 接口 PatchDetails 方法
+func (s *userService) PatchDetails(ctx context.Context, uid uuid.UUID, version int64, p model.UserPatch) (u *model.User, pending bool, err error) {
+	ctx, span := s.start(ctx, "PatchDetails", &uid)
+	defer func() { end(span, err) }()
+	return s.next.PatchDetails(ctx, uid, version, p)
+}
+
+// RequestEmailChange 实现 UserService 接口 RequestEmailChange 方法
+func (s *userService) RequestEmailChange(ctx context.Context, uid uuid.UUID, email string) (err error) {
+	ctx, span := s.start(ctx, "RequestEmailChange", &uid)
+	defer func() { end(span, err) }()
+	return s.next.RequestEmailChange(ctx, uid, email)
+}
+
+// ConfirmEmailChange 实现 UserService 接口 ConfirmEmailChange 方法
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "ConfirmEmailChange", nil)
+	defer func() { end(span, err) }()
+	return s.next.ConfirmEmailChange(ctx, token)
+}
+
+// GetPublicProfile 实现 UserService 接口 GetPublicProfile 方法
+func (s *userService) GetPublicProfile(ctx context.Context, uid uuid.UUID) (p *model.PublicProfile, err error) {
+	ctx, span := s.start(ctx, "GetPublicProfile", &uid)
+	defer func() { end(span, err) }()
+	return s.next.GetPublicProfile(ctx, uid)
+}
+
+// UpdatePrivacy 实现 UserService 接口 UpdatePrivacy 方法
+func (s *userService) UpdatePrivacy(ctx context.Context, uid uuid.UUID, p *model.ProfilePrivacy) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "UpdatePrivacy", &uid)
+	defer func() { end(span, err) }()
+	return s.next.UpdatePrivacy(ctx, uid, p)
+}
+
+// GetPublicProfileByHandle 实现 UserService 接口 GetPublicProfileByHandle 方法
+func (s *userService) GetPublicProfileByHandle(ctx context.Context, handle string) (p *model.PublicProfile, err error) {
+	ctx, span := s.start(ctx, "GetPublicProfileByHandle", nil)
+	defer func() { end(span, err) }()
+	return s.next.GetPublicProfileByHandle(ctx, handle)
+}
+
+// CheckHandle 实现 UserService 接口 CheckHandle 方法
+func (s *userService) CheckHandle(ctx context.Context, uid uuid.UUID, handle string) (normalized string, available bool, err error) {
+	ctx, span := s.start(ctx, "CheckHandle", &uid)
+	defer func() { end(span, err) }()
+	return s.next.CheckHandle(ctx, uid, handle)
+}
+
+// SetHandle 实现 UserService 接口 SetHandle 方法
+func (s *userService) SetHandle(ctx context.Context, uid uuid.UUID, handle string) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "SetHandle", &uid)
+	defer func() { end(span, err) }()
+	return s.next.SetHandle(ctx, uid, handle)
+}
+
+// List 实现 UserService 接口 List 方法
+func (s *userService) List(ctx context.Context, q *model.UserListQuery) (page *model.UserPage, err error) {
+	ctx, span := s.start(ctx, "List", nil)
+	defer func() { end(span, err) }()
+	return s.next.List(ctx, q)
+}
+
+// AdminCreate 实现 UserService 接口 AdminCreate 方法
+func (s *userService) AdminCreate(ctx context.Context, actorID uuid.UUID, u *model.User) (err error) {
+	ctx, span := s.start(ctx, "AdminCreate", &actorID)
+	defer func() { end(span, err) }()
+	return s.next.AdminCreate(ctx, actorID, u)
+}
+
+// AdminUpdate 实现 UserService 接口 AdminUpdate 方法
+func (s *userService) AdminUpdate(ctx context.Context, actorID uuid.UUID, u *model.User) (err error) {
+	ctx, span := s.start(ctx, "AdminUpdate", &actorID)
+	defer func() { end(span, err) }()
+	return s.next.AdminUpdate(ctx, actorID, u)
+}
+
+// SetStatus 实现 UserService 接口 SetStatus 方法
+func (s *userService) SetStatus(ctx context.Context, uid uuid.UUID, c *model.StatusChange) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "SetStatus", &uid)
+	defer func() { end(span, err) }()
+	return s.next.SetStatus(ctx, uid, c)
+}
+
+// Lookup 实现 UserService 接口 Lookup 方法
+func (s *userService) Lookup(ctx context.Context, login string) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "Lookup", nil)
+	defer func() { end(span, err) }()
+	return s.next.Lookup(ctx, login)
+}
+
+// SetPassword 实现 UserService 接口 SetPassword 方法
+func (s *userService) SetPassword(ctx context.Context, actorID uuid.UUID, uid uuid.UUID, password string) (err error) {
+	ctx, span := s.start(ctx, "SetPassword", &uid)
+	defer func() { end(span, err) }()
+	return s.next.SetPassword(ctx, actorID, uid, password)
+}
+
+// SetProfileImage 实现 UserService 接口 SetProfileImage 方法
+func (s *userService) SetProfileImage(ctx context.Context, uid uuid.UUID, imageFileHeader *multipart.FileHeader) (u *model.User, err error) {
+	ctx, span := s.start(ctx, "SetProfileImage", &uid)
+	defer func() { end(span, err) }()
+	return s.next.SetProfileImage(ctx, uid, imageFileHeader)
+}
+
+// ClearProfileImage 实现 UserService 接口 ClearProfileImage 方法
+func (s *userService) ClearProfileImage(ctx context.Context, uid uuid.UUID) (err error) {
+	ctx, span := s.start(ctx, "ClearProfileImage", &uid)
+	defer func() { end(span, err) }()
+	return s.next.ClearProfileImage(ctx, uid)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"memrizr/config"
+	"memrizr/model"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runUser account user 子命令，LOGIN 可以是 UID、邮箱或 handle
+func runUser(ctx context.Context, cfg *config.Config, logger *logrus.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		fs := newFlagSet("user create")
+		name := fs.String("name", "", "display name")
+		admin := fs.Bool("admin", false, "create an admin user")
+		passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+		if err := parseFlags(fs, args[1:], 1); err != nil {
+			return err
+		}
+
+		password, generated, err := readPassword(*passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		return withApp(cfg, logger, func(a *app) error {
+			u := &model.User{Email: fs.Arg(0), Password: password, Name: *name, Role: model.RoleUser}
+			if *admin {
+				u.Role = model.RoleAdmin
+			}
+
+			// 姓名和角色与用户一起创建，失败时不会留下只有邮箱的用户
+			if err := a.userService.AdminCreate(ctx, cliActor, u); err != nil {
+				return err
+			}
+
+			if generated {
+				fmt.Printf("Generated password: %s\n", password)
+			}
+			return printJSON(u)
+		})
+
+	case "find":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		return withApp(cfg, logger, func(a *app) error {
+			u, err := a.userService.Lookup(ctx, args[1])
+			if err != nil {
+				return err
+			}
+
+			sessions, err := a.tokenService.CountSessions(ctx, u.UID)
+			if err != nil {
+				return err
+			}
+
+			return printJSON(struct {
+				*model.User
+				Sessions int `json:"sessions"`
+			}{u, sessions})
+		})
+
+	case "set-password":
+		fs := newFlagSet("user set-password")
+		passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+		if err := parseFlags(fs, args[1:], 1); err != nil {
+			return err
+		}
+
+		password, generated, err := readPassword(*passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		return withApp(cfg, logger, func(a *app) error {
+			u, err := a.userService.Lookup(ctx, fs.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			if err := a.userService.SetPassword(ctx, cliActor, u.UID, password); err != nil {
+				return err
+			}
+
+			if generated {
+				fmt.Printf("Generated password: %s\n", password)
+			}
+			fmt.Printf("Password set for %s, all sessions revoked\n", u.UID)
+			return nil
+		})
+
+	case "disable":
+		fs := newFlagSet("user disable")
+		reason := fs.String("reason", "", "reason recorded with the status change")
+		if err := parseFlags(fs, args[1:], 1); err != nil {
+			return err
+		}
+
+		return withApp(cfg, logger, func(a *app) error {
+			u, err := a.userService.Lookup(ctx, fs.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			u, err = a.userService.SetStatus(ctx, u.UID, &model.StatusChange{
+				Status:  model.StatusDisabled,
+				Reason:  *reason,
+				ActorID: cliActor,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Disabled %s, all sessions revoked\n", u.UID)
+			return nil
+		})
+	}
+
+	return errUsage
+}
+
+// runSessions account sessions 子命令
+func runSessions(ctx context.Context, cfg *config.Config, logger *logrus.Logger, args []string) error {
+	if len(args) != 2 || args[0] != "revoke" {
+		return errUsage
+	}
+
+	return withApp(cfg, logger, func(a *app) error {
+		u, err := a.userService.Lookup(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		n, err := a.tokenService.CountSessions(ctx, u.UID)
+		if err != nil {
+			return err
+		}
+
+		if err := a.tokenService.Signout(ctx, u.UID); err != nil {
+			return err
+		}
+
+		fmt.Printf("Revoked %d session(s) for %s\n", n, u.UID)
+		return nil
+	})
+}
+
+// readPassword 从 stdin 读取第一行，不从 stdin 读取时生成随机密码，避免密码出现在命令行历史中
+func readPassword(fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		b := make([]byte, 15)
+		if _, err := rand.Read(b); err != nil {
+			return "", false, fmt.Errorf("unable to generate password: %w", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b), true, nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false, fmt.Errorf("unable to read password from stdin: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), false, nil
+}
+
+// printJSON 缩进格式输出到 stdout
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}